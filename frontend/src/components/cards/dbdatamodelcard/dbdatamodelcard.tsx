@@ -22,7 +22,7 @@ const DBDataModelCard = ({ dataModel, dbConnection }: DBDataModelPropType) => {
                     <div className="card-content">
                         {dbConnection.type === DBConnType.POSTGRES &&
                             <b>{dataModel.schemaName}.{dataModel.name}</b>}
-                        {dbConnection.type === DBConnType.MONGO &&
+                        {dbConnection.type !== DBConnType.POSTGRES &&
                             <b>{dataModel.name}</b>}
                     </div>
                 </a>
@@ -1,7 +1,7 @@
 import styles from './chart.module.scss'
 import React, { useRef, useState } from 'react'
 import { DBConnection, DBQueryData } from '../../../data/models'
-import { DBConnType } from '../../../data/defaults'
+import { isSQLDBConnType } from '../../../data/defaults'
 import { Bar, Line, Pie } from 'react-chartjs-2'
 import {
     Chart as ChartJS,
@@ -59,14 +59,14 @@ const Chart = ({ dbConn, queryData }: ChartPropType) => {
     const selectXAxisRef = useRef<HTMLSelectElement>(null)
     const selectYAxisRef = useRef<HTMLSelectElement>(null)
 
-    const keys = dbConn.type === DBConnType.POSTGRES ? queryData.columns : queryData.keys
+    const keys = isSQLDBConnType(dbConn.type) ? queryData.columns : queryData.keys
 
     const createChart = () => {
         const xaxis = selectXAxisRef.current!.value
         const yaxis = selectYAxisRef.current!.value
         let labels: string[]
         let data: number[]
-        if (dbConn.type === DBConnType.POSTGRES) {
+        if (isSQLDBConnType(dbConn.type)) {
             const xColIdx = keys.findIndex(x => x === xaxis)
             const yColIdx = keys.findIndex(y => y === yaxis)
             labels = queryData.rows.map(row => row[xColIdx])
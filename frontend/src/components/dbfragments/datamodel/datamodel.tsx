@@ -2,7 +2,7 @@ import styles from './datamodel.module.scss'
 import React, { useState } from 'react'
 import { DBConnection, DBDataModel } from '../../../data/models'
 import ReactTooltip from 'react-tooltip'
-import { DBConnType } from '../../../data/defaults'
+import { DBConnType, isSQLDBConnType } from '../../../data/defaults'
 import AddFieldModal from './addfieldmodal'
 import ConfirmModal from '../../widgets/confirmModal'
 import apiService from '../../../network/apiService'
@@ -40,14 +40,14 @@ const DataModel = ({ dbConn, dataModel, isEditable, refreshModel }: DataModelPro
                 <table className={"table is-bordered is-striped is-narrow is-hoverable"}>
                     <thead>
                         <tr>
-                            <th colSpan={dbConn.type === DBConnType.POSTGRES ? 4 : 5}>
+                            <th colSpan={isSQLDBConnType(dbConn.type) ? 4 : 5}>
                                 {label}
                                 {isEditable && <button className="button is-small" style={{ float: 'right' }} onClick={() => { setIsEditing(!isEditing) }}>
                                     {isEditing && <i className={"fas fa-check"} />}
                                     {!isEditing && <i className={"fas fa-pen"} />}
                                 </button>}
                             </th>
-                            {dbConn.type === DBConnType.POSTGRES && isEditing && <th>
+                            {isSQLDBConnType(dbConn.type) && isEditing && <th>
                                 <button className="button is-primary is-small" onClick={() => { setShowingAddModal(true) }}>
                                     <i className={"fas fa-plus"} />
                                 </button>
@@ -67,7 +67,7 @@ const DataModel = ({ dbConn, dataModel, isEditable, refreshModel }: DataModelPro
                                     }</td>
                                     <td>{field.name}</td>
                                     <td colSpan={dbConn.type === DBConnType.MONGO ? 2 : 1}>{field.type}</td>
-                                    {dbConn.type === DBConnType.POSTGRES && <td>
+                                    {isSQLDBConnType(dbConn.type) && <td>
                                         {field.tags.length > 0 && field.tags.map<React.ReactNode>(tag => (
                                             <span key={tag} className="tag is-info is-light">{tag}</span>
                                         )).reduce((prev, curr) => [prev, ' ', curr])}
@@ -100,7 +100,7 @@ const DataModel = ({ dbConn, dataModel, isEditable, refreshModel }: DataModelPro
                             }
                         </tbody>
                     </table>}
-                {dbConn.type === DBConnType.POSTGRES && showingAddModal && <AddFieldModal
+                {isSQLDBConnType(dbConn.type) && showingAddModal && <AddFieldModal
                     dbConn={dbConn}
                     mSchema={dataModel.schemaName}
                     mName={dataModel.name}
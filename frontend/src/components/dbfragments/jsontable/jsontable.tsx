@@ -1,12 +1,96 @@
 import styles from './jsontable.module.scss'
 import React, { useRef, useState } from 'react'
 import { useRowSelect, useTable } from 'react-table'
-import { DBConnection, DBQueryData } from '../../../data/models'
+import { DBConnection, DBDataFilter, DBDataSortField, DBQueryData } from '../../../data/models'
 import JsonCell from './jsoncell'
 import AddModal from './addmodel'
 import apiService from '../../../network/apiService'
 import toast from 'react-hot-toast'
 
+// parseMongoObject parses a mongo shell style object, keys may be unquoted and strings single quoted.
+const parseMongoObject = (text: string): { [key: string]: any } => {
+    const json = text.replace(/"(?:[^"\\]|\\.)*"|'((?:[^'\\]|\\.)*)'|([{,]\s*)([A-Za-z_$][\w$.]*)(\s*:)/g,
+        (match, singleQuoted, prefix, key, colon) => {
+            if (singleQuoted !== undefined) {
+                return JSON.stringify(singleQuoted.replace(/\\'/g, "'"))
+            }
+            if (key !== undefined) {
+                return `${prefix}"${key}"${colon}`
+            }
+            return match
+        })
+    const value = JSON.parse(json)
+    if (value === null || typeof value !== 'object' || Array.isArray(value)) {
+        throw new Error('expected an object')
+    }
+    return value
+}
+
+const mongoOperators: { [operator: string]: string } = {
+    $eq: '=',
+    $ne: '!=',
+    $lt: '<',
+    $gt: '>',
+    $lte: '<=',
+    $gte: '>=',
+    $in: 'IN',
+    $nin: 'NOT IN',
+    $regex: 'REGEX',
+}
+
+// mongoFilterToDataFilter converts a mongo filter document to the filter tree understood by the server.
+const mongoFilterToDataFilter = (mongoFilter: { [key: string]: any }): DBDataFilter | undefined => {
+    const conditions: DBDataFilter[] = []
+    Object.entries(mongoFilter).forEach(([key, value]) => {
+        if (key === '$and' || key === '$or') {
+            if (!Array.isArray(value)) {
+                throw new Error(`${key} must be an array`)
+            }
+            const groupConditions = value.map(x => mongoFilterToDataFilter(x)).filter((x): x is DBDataFilter => x !== undefined)
+            if (groupConditions.length > 0) {
+                conditions.push({ group: key === '$and' ? 'AND' : 'OR', conditions: groupConditions })
+            }
+        } else if (key.startsWith('$')) {
+            throw new Error(`${key} is not supported`)
+        } else if (value === null) {
+            conditions.push({ field: key, operator: 'IS NULL' })
+        } else if (typeof value === 'object' && !Array.isArray(value)) {
+            Object.entries(value).forEach(([operator, operand]) => {
+                if (!(operator in mongoOperators)) {
+                    throw new Error(`${operator} is not supported`)
+                }
+                if (operator === '$ne' && operand === null) {
+                    conditions.push({ field: key, operator: 'IS NOT NULL' })
+                } else if (operator === '$in' || operator === '$nin') {
+                    if (!Array.isArray(operand)) {
+                        throw new Error(`${operator} must be an array`)
+                    }
+                    conditions.push({ field: key, operator: mongoOperators[operator], values: operand })
+                } else {
+                    conditions.push({ field: key, operator: mongoOperators[operator], value: operand })
+                }
+            })
+        } else {
+            conditions.push({ field: key, operator: '=', value })
+        }
+    })
+    if (conditions.length === 0) {
+        return undefined
+    }
+    return conditions.length === 1 ? conditions[0] : { group: 'AND', conditions }
+}
+
+// mongoSortToDataSort converts a mongo sort document of 1 or -1 directions to the sort fields.
+const mongoSortToDataSort = (mongoSort: { [key: string]: any }): DBDataSortField[] | undefined => {
+    const sort: DBDataSortField[] = Object.entries(mongoSort).map(([field, direction]) => {
+        if (direction !== 1 && direction !== -1) {
+            throw new Error(`direction of ${field} must be 1 or -1`)
+        }
+        return { field, direction: direction === 1 ? 'ASC' : 'DESC' }
+    })
+    return sort.length > 0 ? sort : undefined
+}
+
 type JsonTablePropType = {
     queryData: DBQueryData,
     dbConnection: DBConnection
@@ -16,8 +100,8 @@ type JsonTablePropType = {
     onAddData: (newData: any) => void,
     onDeleteRows: (indexes: number[]) => void,
     updateCellData: (underscoreId: string, newData: object) => void,
-    onFilterChanged: (newFilter: string[] | undefined) => void,
-    onSortChanged: (newSort: string[] | undefined) => void,
+    onFilterChanged: (newFilter: DBDataFilter | undefined) => void,
+    onSortChanged: (newSort: DBDataSortField[] | undefined) => void,
 }
 
 const JsonTable = ({ queryData, dbConnection, mName, isEditable, showHeader, onAddData, onDeleteRows, updateCellData, onFilterChanged, onSortChanged }: JsonTablePropType) => {
@@ -55,10 +139,15 @@ const JsonTable = ({ queryData, dbConnection, mName, isEditable, showHeader, onA
     }
 
     const changeFilter = () => {
-        let filter: string[] | undefined = undefined
+        let filter: DBDataFilter | undefined = undefined
         let filterText = filterRef.current!.value.trim()
-        if (filterText !== '' && filterText.startsWith("{") && filterText.endsWith("}")) {
-            filter = [filterText]
+        if (filterText !== '') {
+            try {
+                filter = mongoFilterToDataFilter(parseMongoObject(filterText))
+            } catch (e: any) {
+                toast.error(`invalid filter: ${e.message}`)
+                return
+            }
         }
         onFilterChanged(filter)
     }
@@ -67,10 +156,15 @@ const JsonTable = ({ queryData, dbConnection, mName, isEditable, showHeader, onA
         if (!isEditable) {
             return
         }
-        let sort: string[] | undefined = undefined
+        let sort: DBDataSortField[] | undefined = undefined
         let sortText = sortRef.current!.value.trim()
-        if (sortText !== '' && sortText.startsWith("{") && sortText.endsWith("}")) {
-            sort = [sortText]
+        if (sortText !== '') {
+            try {
+                sort = mongoSortToDataSort(parseMongoObject(sortText))
+            } catch (e: any) {
+                toast.error(`invalid sort: ${e.message}`)
+                return
+            }
         }
         onSortChanged(sort)
     }
@@ -6,7 +6,7 @@ import QueryEditor from './queryeditor/queryeditor'
 import apiService from '../../network/apiService'
 import { selectDBConnection } from '../../redux/dbConnectionSlice'
 import { useAppSelector } from '../../redux/hooks'
-import { DBConnType } from '../../data/defaults'
+import { DBConnType, isSQLDBConnType } from '../../data/defaults'
 import JsonTable from './jsontable/jsontable'
 import Table from './table/table'
 import Chart from './chart/chart'
@@ -88,7 +88,7 @@ const DBQueryFragment = ({ queryId, dbQuery, onQuerySaved }: DBQueryPropType) =>
                 </React.Fragment>
                 :
                 <React.Fragment>
-                    {dbConnection!.type === DBConnType.POSTGRES &&
+                    {isSQLDBConnType(dbConnection!.type) &&
                         <Table
                             dbConnection={dbConnection!}
                             queryData={queryData}
@@ -6,7 +6,7 @@ import { saveDBQuery, selectDBConnection } from '../../../redux/dbConnectionSlic
 import { DBConnection } from '../../../data/models'
 import toast from 'react-hot-toast'
 import { format } from 'sql-formatter'
-import { DBConnType } from '../../../data/defaults'
+import { DBConnType, isSQLDBConnType } from '../../../data/defaults'
 import { js_beautify } from 'js-beautify'
 
 
@@ -77,6 +77,18 @@ const QueryEditor = ({ initialValue, initQueryName, queryId, dbType, runQuery, o
                 uppercase: true,
                 linesBetweenQueries: 2,
             })
+        } else if (dbType == DBConnType.MYSQL) {
+            formattedQuery = format(value, {
+                language: "mysql",
+                uppercase: true,
+                linesBetweenQueries: 2,
+            })
+        } else if (dbType == DBConnType.SQLITE) {
+            formattedQuery = format(value, {
+                language: "sql",
+                uppercase: true,
+                linesBetweenQueries: 2,
+            })
         } else if (dbType == DBConnType.MONGO) {
             formattedQuery = js_beautify(value)
         }
@@ -90,7 +102,7 @@ const QueryEditor = ({ initialValue, initQueryName, queryId, dbType, runQuery, o
                 ref={editorRef}
                 value={value}
                 options={{
-                    mode: dbType == DBConnType.POSTGRES ? 'sql' : 'javascript',
+                    mode: isSQLDBConnType(dbType) ? 'sql' : 'javascript',
                     theme: 'duotone-light',
                     lineNumbers: true
                 }}
@@ -1,13 +1,13 @@
 import styles from './showdata.module.scss'
 import { useRouter } from 'next/router'
 import React, { useEffect, useState } from 'react'
-import { DBConnection, DBDataModel, DBQueryData, Project } from '../../data/models'
+import { DBConnection, DBDataFilter, DBDataModel, DBDataSortField, DBQueryData, Project } from '../../data/models'
 import apiService from '../../network/apiService'
 import { selectDBConnection, selectDBDataModels } from '../../redux/dbConnectionSlice'
 import { useAppSelector } from '../../redux/hooks'
 import Table from './table/table'
 import { ProjectPermissions, selectCurrentProject, selectProjectMemberPermissions, selectProjects } from '../../redux/projectsSlice'
-import { DBConnType } from '../../data/defaults'
+import { DBConnType, isSQLDBConnType } from '../../data/defaults'
 import { selectIsShowingSidebar } from '../../redux/configSlice'
 import JsonTable from './jsontable/jsontable'
 
@@ -30,9 +30,9 @@ const DBShowDataFragment = (_: DBShowDataPropType) => {
     const [queryData, setQueryData] = useState<DBQueryData>()
     const [queryOffset, setQueryOffset] = useState(0)
     const [queryCount, setQueryCount] = useState<number | undefined>(undefined)
-    const [queryLimit] = useState(dbConnection ? dbConnection.type === DBConnType.POSTGRES ? 200 : 50 : 100)
-    const [queryFilter, setQueryFilter] = useState<string[] | undefined>(undefined)
-    const [querySort, setQuerySort] = useState<string[] | undefined>(undefined)
+    const [queryLimit] = useState(dbConnection ? isSQLDBConnType(dbConnection.type) ? 200 : 50 : 100)
+    const [queryFilter, setQueryFilter] = useState<DBDataFilter | undefined>(undefined)
+    const [querySort, setQuerySort] = useState<DBDataSortField[] | undefined>(undefined)
     const [dataLoading, setDataLoading] = useState(false)
 
 
@@ -72,34 +72,37 @@ const DBShowDataFragment = (_: DBShowDataPropType) => {
         setDataLoading(false)
     }
 
+    // the server returns fewer rows than asked when the role has a lower max rows
+    const pageLimit = queryData?.limit ?? queryLimit
+
     const onPreviousPage = () => {
-        let previousOffset = queryOffset - queryLimit
+        let previousOffset = queryOffset - pageLimit
         if (previousOffset < 0) {
             previousOffset = 0
         }
         setQueryOffset(previousOffset)
     }
     const onNextPage = () => {
-        let nextOffset = queryOffset + queryLimit
+        let nextOffset = queryOffset + pageLimit
         if (nextOffset > (queryCount ?? 0)) {
             return
         }
         setQueryOffset(nextOffset)
     }
-    const onFilterChanged = (newFilter: string[] | undefined) => {
+    const onFilterChanged = (newFilter: DBDataFilter | undefined) => {
         setQueryFilter(newFilter)
         setQueryOffset(0)
     }
 
-    const onSortChanged = (newSort: string[] | undefined) => {
+    const onSortChanged = (newSort: DBDataSortField[] | undefined) => {
         setQuerySort(newSort)
     }
 
-    const updatePostgresCellData = (oldCtid: string, newCtid: string, columnIdx: string, newValue: string | null | boolean) => {
-        const rowIdx = queryData!.rows.findIndex(x => x["0"] == oldCtid)
+    const updateTableCellData = (oldRowId: string, newRowId: string, columnIdx: string, newValue: string | null | boolean) => {
+        const rowIdx = queryData!.rows.findIndex(x => x["0"] == oldRowId)
         if (rowIdx) {
             const newQueryData: DBQueryData = { ...queryData! }
-            newQueryData!.rows[rowIdx] = { ...newQueryData!.rows[rowIdx], ctid: newCtid }
+            newQueryData!.rows[rowIdx] = { ...newQueryData!.rows[rowIdx], "0": newRowId }
             newQueryData!.rows[rowIdx][columnIdx] = newValue
             setQueryData(newQueryData)
         } else {
@@ -119,7 +122,7 @@ const DBShowDataFragment = (_: DBShowDataPropType) => {
     }
 
     const onDeleteRows = (indexes: number[]) => {
-        if (dbConnection!.type === DBConnType.POSTGRES) {
+        if (isSQLDBConnType(dbConnection!.type)) {
             const filteredRows = queryData!.rows.filter((_, i) => !indexes.includes(i))
             const newQueryData: DBQueryData = { ...queryData!, rows: filteredRows }
             setQueryData(newQueryData)
@@ -131,7 +134,7 @@ const DBShowDataFragment = (_: DBShowDataPropType) => {
     }
 
     const onAddData = (newData: any) => {
-        if (dbConnection!.type === DBConnType.POSTGRES) {
+        if (isSQLDBConnType(dbConnection!.type)) {
             const updatedRows = [newData, ...queryData!.rows]
             const updateQueryData: DBQueryData = { ...queryData!, rows: updatedRows }
             setQueryData(updateQueryData)
@@ -143,12 +146,12 @@ const DBShowDataFragment = (_: DBShowDataPropType) => {
     }
 
     const rowsLength = queryData ? (queryData.rows ? queryData.rows.length : queryData.data.length) : 0
-    const queryOffsetRangeEnd = (rowsLength ?? 0) === queryLimit ?
-        queryOffset + queryLimit : queryOffset + (rowsLength ?? 0)
+    const queryOffsetRangeEnd = (rowsLength ?? 0) === pageLimit ?
+        queryOffset + pageLimit : queryOffset + (rowsLength ?? 0)
 
     return (
         <React.Fragment>
-            {project && dbConnection && queryData && dbConnection.type === DBConnType.POSTGRES &&
+            {project && dbConnection && queryData && isSQLDBConnType(dbConnection.type) &&
                 <Table
                     dbConnection={dbConnection}
                     mSchema={String(mschema)}
@@ -157,7 +160,7 @@ const DBShowDataFragment = (_: DBShowDataPropType) => {
                     querySort={querySort}
                     isEditable={!projectMemberPermissions.readOnly}
                     showHeader={true}
-                    updateCellData={updatePostgresCellData}
+                    updateCellData={updateTableCellData}
                     onDeleteRows={onDeleteRows}
                     onAddData={onAddData}
                     onFilterChanged={onFilterChanged}
@@ -17,6 +17,8 @@ const AddModal = ({ queryData, dbConnection, mSchema, mName, onAddData, onClose
 
     const [newData, setNewData] = useState<any>({})
 
+    const rowIdColumn = queryData.rowIdIsCtid ? 'ctid' : '_rowid'
+
     const onFieldChange = (e: React.ChangeEvent<HTMLInputElement>, col: string) => {
         let tmpData = { ...newData }
         tmpData[col] = e.target.value
@@ -27,7 +29,7 @@ const AddModal = ({ queryData, dbConnection, mSchema, mName, onAddData, onClose
         const result: ApiResult<AddDataResponse> = await apiService.addDBData(dbConnection.id, mSchema, mName, newData)
         if (result.success) {
             toast.success('data added')
-            let mNewData = { ...newData, ctid: result.data.newId }
+            let mNewData = { ...newData, [rowIdColumn]: result.data.newId }
             queryData.columns.forEach((col, i) => {
                 const colIdx = i.toString()
                 if (mNewData[col] === undefined) {
@@ -53,7 +55,7 @@ const AddModal = ({ queryData, dbConnection, mSchema, mName, onAddData, onClose
                     <button className="delete" aria-label="close" onClick={onClose}></button>
                 </header>
                 <section className="modal-card-body">
-                    {queryData.columns.filter(col => col !== 'ctid').map(col => {
+                    {queryData.columns.filter((col, i) => i !== 0 || col !== rowIdColumn).map(col => {
                         return (
                             <div className="field" key={col}>
                                 <label className="label">{col}</label>
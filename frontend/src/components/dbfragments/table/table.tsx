@@ -2,7 +2,7 @@ import styles from './table.module.scss'
 import React, { useState, useRef } from 'react'
 import { Cell, useRowSelect, useTable, UseTableInstanceProps } from 'react-table'
 import toast from 'react-hot-toast';
-import { DBConnection, DBQueryData } from '../../../data/models'
+import { DBConnection, DBDataFilter, DBDataSortField, DBQueryData } from '../../../data/models'
 import EditableCell from './editablecell'
 import apiService from '../../../network/apiService'
 import AddModal from './addmodal';
@@ -15,12 +15,12 @@ type TablePropType = {
     mName: string,
     isEditable: boolean,
     showHeader?: boolean,
-    querySort?: string[],
-    updateCellData: (oldCtid: string, newCtid: string, columnName: string, newValue: string | null | boolean) => void,
+    querySort?: DBDataSortField[],
+    updateCellData: (oldRowId: string, newRowId: string, columnName: string, newValue: string | null | boolean) => void,
     onDeleteRows: (indexes: number[]) => void,
     onAddData: (newData: any) => void,
-    onFilterChanged: (newFilter: string[] | undefined) => void,
-    onSortChanged: (newSort: string[] | undefined) => void,
+    onFilterChanged: (newFilter: DBDataFilter | undefined) => void,
+    onSortChanged: (newSort: DBDataSortField[] | undefined) => void,
 }
 
 const Table = ({ queryData, dbConnection, mSchema, mName, isEditable, showHeader, querySort, updateCellData, onDeleteRows, onAddData, onFilterChanged, onSortChanged }: TablePropType) => {
@@ -37,18 +37,22 @@ const Table = ({ queryData, dbConnection, mSchema, mName, isEditable, showHeader
         [queryData]
     )
 
-    const displayColumns = queryData.columns.filter(col => col !== 'ctid')
-    const ctidExists = queryData.columns.length != displayColumns.length
+    // the first column identifies the row, by its primary key or by its ctid when the table has none,
+    // the rows of views have no row id column and cannot be edited
+    const rowsEditable = isEditable && queryData.editable !== false
+    const rowIdColumn = queryData.rowIdIsCtid ? 'ctid' : '_rowid'
+    const displayColumns = queryData.columns.filter((col, i) => i !== 0 || col !== rowIdColumn || queryData.editable === false)
+    const rowIdExists = queryData.columns.length != displayColumns.length
 
     const columns = React.useMemo(
         () => displayColumns.map((col, i) => ({
-            Header: <>{col}{querySort && querySort[0] === col ?
-                querySort[1] === 'ASC' ?
+            Header: <>{col}{querySort && querySort[0].field === col ?
+                querySort[0].direction === 'ASC' ?
                     <>&nbsp;<i className="fas fa-caret-up" /></>
                     :
                     <>&nbsp;<i className="fas fa-caret-down" /></>
                 : undefined}</>,
-            accessor: (ctidExists ? i + 1 : i).toString(),
+            accessor: (rowIdExists ? i + 1 : i).toString(),
         })),
         [queryData, querySort]
     )
@@ -61,11 +65,11 @@ const Table = ({ queryData, dbConnection, mSchema, mName, isEditable, showHeader
         setEditCell([])
     }
 
-    const onSaveCell = async (ctid: string, columnIdx: string, newValue: string) => {
+    const onSaveCell = async (rowId: string, columnIdx: string, newValue: string) => {
         const columnName = queryData.columns[parseInt(columnIdx)]
-        const result = await apiService.updateDBSingleData(dbConnection.id, mSchema, mName, ctid, columnName, newValue)
+        const result = await apiService.updateDBSingleData(dbConnection.id, mSchema, mName, rowId, columnName, newValue)
         if (result.success) {
-            updateCellData(ctid, result.data.ctid, columnIdx, newValue)
+            updateCellData(rowId, result.data.rowId, columnIdx, newValue)
             resetEditCell()
             toast.success('1 row updated');
         } else {
@@ -88,7 +92,7 @@ const Table = ({ queryData, dbConnection, mSchema, mName, isEditable, showHeader
     },
         useRowSelect,
         hooks => {
-            if (isEditable)
+            if (rowsEditable)
                 hooks.visibleColumns.push(columns => [
                     {
                         id: 'selection',
@@ -104,11 +108,11 @@ const Table = ({ queryData, dbConnection, mSchema, mName, isEditable, showHeader
     const newState: any = state // temporary typescript hack
     const selectedRowIds: any = newState.selectedRowIds
     const selectedRows: number[] = Object.keys(selectedRowIds).map(x => parseInt(x))
-    const selectedCTIDs = rows.filter((_, i) => selectedRows.includes(i)).map(x => x.original['0']).filter(x => x)
+    const selectedRowKeys = rows.filter((_, i) => selectedRows.includes(i)).map(x => x.original['0']).filter(x => x)
 
     const onDeleteBtnPressed = async () => {
-        if (selectedCTIDs.length > 0) {
-            const result = await apiService.deleteDBData(dbConnection.id, mSchema, mName, selectedCTIDs)
+        if (selectedRowKeys.length > 0) {
+            const result = await apiService.deleteDBData(dbConnection.id, mSchema, mName, selectedRowKeys)
             if (result.success) {
                 toast.success('rows deleted');
                 onDeleteRows(selectedRows)
@@ -119,18 +123,18 @@ const Table = ({ queryData, dbConnection, mSchema, mName, isEditable, showHeader
     }
 
     const startEditing = (cell: Cell<any, any>) => {
-        if (isEditable)
+        if (rowsEditable)
             setEditCell([cell.row.index, cell.column.id])
     }
 
     const changeFilter = () => {
-        let filter: string[] | undefined = undefined
+        let filter: DBDataFilter | undefined = undefined
         if (filter0Ref.current!.value !== 'default' && filter1Ref.current!.value !== 'default') {
             let operator = filter1Ref.current!.value
             if (operator === 'IS NULL' || operator === 'IS NOT NULL') {
-                filter = [filter0Ref.current!.value, operator]
+                filter = { field: filter0Ref.current!.value, operator }
             } else {
-                filter = [filter0Ref.current!.value, operator, filter2Ref.current!.value]
+                filter = { field: filter0Ref.current!.value, operator, value: filter2Ref.current!.value }
             }
         }
         onFilterChanged(filter)
@@ -141,17 +145,17 @@ const Table = ({ queryData, dbConnection, mSchema, mName, isEditable, showHeader
             return
         }
         const newSortName: string = displayColumns.find((_, i) => {
-            const colIdx = ctidExists ? i + 1 : i
+            const colIdx = rowIdExists ? i + 1 : i
             return colIdx.toString() === newSortIdx
         })!
-        if (querySort && newSortName === querySort[0]) {
-            if (querySort[1] === 'ASC') {
-                onSortChanged([querySort[0], 'DESC'])
-            } else if (querySort[1] === 'DESC') {
+        if (querySort && newSortName === querySort[0].field) {
+            if (querySort[0].direction === 'ASC') {
+                onSortChanged([{ field: newSortName, direction: 'DESC' }])
+            } else if (querySort[0].direction === 'DESC') {
                 onSortChanged(undefined)
             }
         } else {
-            onSortChanged([newSortName, 'ASC'])
+            onSortChanged([{ field: newSortName, direction: 'ASC' }])
         }
     }
 
@@ -196,9 +200,9 @@ const Table = ({ queryData, dbConnection, mSchema, mName, isEditable, showHeader
                             </p>
                         </div>
                     </div>
-                    {isEditable && <React.Fragment>
+                    {rowsEditable && <React.Fragment>
                         <div className="column is-3 is-flex is-justify-content-flex-end">
-                            <button className="button" disabled={selectedCTIDs.length === 0} onClick={onDeleteBtnPressed}>
+                            <button className="button" disabled={selectedRowKeys.length === 0} onClick={onDeleteBtnPressed}>
                                 <span className="icon is-small">
                                     <i className="fas fa-trash" />
                                 </span>
@@ -1,8 +1,15 @@
 export enum DBConnType {
     POSTGRES = "POSTGRES",
-    MONGO = "MONGO"
+    MONGO = "MONGO",
+    MYSQL = "MYSQL",
+    SQLITE = "SQLITE"
 }
 
+// the data of sql databases is shown as tables of columns and rows
+const SQL_DB_CONN_TYPES: string[] = [DBConnType.POSTGRES, DBConnType.MYSQL, DBConnType.SQLITE]
+
+export const isSQLDBConnType = (type: string): boolean => SQL_DB_CONN_TYPES.includes(type)
+
 export enum DBConnectionUseSSHType {
     NONE = "NONE",
     PASSWORD = "PASSWORD",
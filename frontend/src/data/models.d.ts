@@ -80,6 +80,24 @@ export interface DBQueryData {
     keys: string[]
     data: any[]
     count?: number
+    rowIdIsCtid?: boolean
+    editable?: boolean
+    limit?: number
+    truncated?: boolean
+}
+
+export interface DBDataFilter {
+    group?: 'AND' | 'OR'
+    conditions?: DBDataFilter[]
+    field?: string
+    operator?: string
+    value?: any
+    values?: any[]
+}
+
+export interface DBDataSortField {
+    field: string
+    direction: 'ASC' | 'DESC'
 }
 
 export interface DBQueryResult {
@@ -118,8 +136,9 @@ export interface PaginatedApiResult<T, N> {
     error?: string
 }
 
-export interface CTIDResponse {
-    ctid: string
+export interface RowIDResponse {
+    rowId: string
+    rowIdIsCtid: boolean
 }
 
 export interface AddDataResponse {
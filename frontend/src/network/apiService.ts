@@ -1,5 +1,5 @@
 import Request from './request'
-import { UserSession, ApiResult, Project, DBConnection, ProjectMember, DBDataModel, DBQueryData, User, CTIDResponse, DBQuery, DBQueryResult, DBQueryLog, PaginatedApiResult, Role, RolePermission } from '../data/models'
+import { UserSession, ApiResult, Project, DBConnection, ProjectMember, DBDataModel, DBDataFilter, DBDataSortField, DBQueryData, User, RowIDResponse, DBQuery, DBQueryResult, DBQueryLog, PaginatedApiResult, Role, RolePermission } from '../data/models'
 import { AddDBConnPayload, AddProjectMemberPayload } from './payloads'
 import { AxiosResponse } from 'axios'
 
@@ -152,7 +152,7 @@ const deleteDBSingleDataModelField = async function (dbConnId: string, schemaNam
         .then(res => res.data)
 }
 
-const getDBDataInDataModel = async function (dbConnId: string, schemaName: string, mName: string, limit: number, offset: number, fetchCount: boolean, filter?: string[], sort?: string[]): Promise<ApiResult<DBQueryData>> {
+const getDBDataInDataModel = async function (dbConnId: string, schemaName: string, mName: string, limit: number, offset: number, fetchCount: boolean, filter?: DBDataFilter, sort?: DBDataSortField[]): Promise<ApiResult<DBQueryData>> {
     return await Request.getApiInstance()
         .get<ApiResult<DBQueryData>>(`/query/data/${dbConnId}`, {
             params: {
@@ -161,16 +161,16 @@ const getDBDataInDataModel = async function (dbConnId: string, schemaName: strin
                 limit: limit,
                 offset: offset,
                 count: fetchCount,
-                filter,
-                sort,
+                filter: filter ? JSON.stringify(filter) : undefined,
+                sort: sort ? JSON.stringify(sort) : undefined,
             }
         })
         .then(res => res.data)
 }
 
-const updateDBSingleData = async function (dbConnId: string, schemaName: string, mName: string, id: string, columnName: string, value: string): Promise<ApiResult<CTIDResponse>> {
+const updateDBSingleData = async function (dbConnId: string, schemaName: string, mName: string, id: string, columnName: string, value: string): Promise<ApiResult<RowIDResponse>> {
     return await Request.getApiInstance()
-        .post<any, AxiosResponse<ApiResult<CTIDResponse>>>(`/query/data/${dbConnId}/single`, { schema: schemaName, name: mName, id, columnName, value })
+        .post<any, AxiosResponse<ApiResult<RowIDResponse>>>(`/query/data/${dbConnId}/single`, { schema: schemaName, name: mName, id, columnName, value })
         .then(res => res.data)
 }
 
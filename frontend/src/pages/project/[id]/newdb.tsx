@@ -94,6 +94,8 @@ const NewDBPage: NextPage = () => {
 							<select onChange={(e: React.ChangeEvent<HTMLSelectElement>) => { setDBType(e.target.value); setDBScheme('') }}>
 								<option value={DBConnType.POSTGRES}>PostgresSQL</option>
 								<option value={DBConnType.MONGO}>MongoDB</option>
+								<option value={DBConnType.MYSQL}>MySQL</option>
+								<option value={DBConnType.SQLITE}>SQLite</option>
 							</select>
 						</div>
 					</div>
@@ -110,140 +112,153 @@ const NewDBPage: NextPage = () => {
 						</div>
 					</div>
 				</div>}
-				<div className="field">
-					<label className="label">Host:</label>
+				{dbType == DBConnType.SQLITE && <div className="field">
+					<label className="label">Database File:</label>
 					<div className="control">
 						<input
 							className="input"
 							type="text"
 							value={dbHost}
 							onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBHost(e.target.value) }}
-							placeholder="Enter host" />
+							placeholder="Enter path of the database file in the sqlite files directory of the server" />
 					</div>
-				</div>
-				<div className="field">
-					<label className="label">Port:</label>
-					<div className="control">
-						<input
-							className="input"
-							type="text"
-							value={dbPort}
-							onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBPort(e.target.value) }}
-							placeholder="Enter port" />
-					</div>
-				</div>
-				<div className="field">
-					<label className="label">Database Name:</label>
-					<div className="control">
-						<input
-							className="input"
-							type="text"
-							value={dbDatabase}
-							onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBDatabase(e.target.value) }}
-							placeholder="Enter database" />
+				</div>}
+				{dbType != DBConnType.SQLITE && <React.Fragment>
+					<div className="field">
+						<label className="label">Host:</label>
+						<div className="control">
+							<input
+								className="input"
+								type="text"
+								value={dbHost}
+								onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBHost(e.target.value) }}
+								placeholder="Enter host" />
+						</div>
 					</div>
-				</div>
-				<div className="field">
-					<label className="label">Database User:</label>
-					<div className="control">
-						<input
-							className="input"
-							type="text"
-							value={dbUsername}
-							onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBUsername(e.target.value) }}
-							placeholder="Enter database username" />
+					<div className="field">
+						<label className="label">Port:</label>
+						<div className="control">
+							<input
+								className="input"
+								type="text"
+								value={dbPort}
+								onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBPort(e.target.value) }}
+								placeholder="Enter port" />
+						</div>
 					</div>
-				</div>
-				<div className="field">
-					<label className="label">Database Password:</label>
-					<div className="control">
-						<input
-							className="input"
-							type="password"
-							value={dbPassword}
-							onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBPassword(e.target.value) }}
-							placeholder="Enter database password" />
+					<div className="field">
+						<label className="label">Database Name:</label>
+						<div className="control">
+							<input
+								className="input"
+								type="text"
+								value={dbDatabase}
+								onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBDatabase(e.target.value) }}
+								placeholder="Enter database" />
+						</div>
 					</div>
-				</div>
-				<div className="field">
-					<label className="label">Use SSH:</label>
-					<div className="select">
-						<select
-							value={dbUseSSH}
-							onChange={(e: React.ChangeEvent<HTMLSelectElement>) => {
-								setUseSSH(e.target.value)
-							}}
-						>
-							<option
-								value={DBConnectionUseSSHType.NONE}>
-								None
-							</option>
-							<option
-								value={DBConnectionUseSSHType.PASSWORD}>
-								Password
-							</option>
-							<option
-								value={DBConnectionUseSSHType.KEYFILE}>
-								Identity File
-							</option>
-							<option
-								value={DBConnectionUseSSHType.PASSKEYFILE}>
-								Identity File with Password
-							</option>
-						</select>
+					<div className="field">
+						<label className="label">Database User:</label>
+						<div className="control">
+							<input
+								className="input"
+								type="text"
+								value={dbUsername}
+								onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBUsername(e.target.value) }}
+								placeholder="Enter database username" />
+						</div>
 					</div>
-				</div>
-				{dbUseSSH !== DBConnectionUseSSHType.NONE &&
-					<React.Fragment>
-						<div className="field">
-							<label className="label">SSH Host:</label>
-							<div className="control">
-								<input
-									className="input"
-									type="text"
-									value={dbSSHHost}
-									onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setSSHHost(e.target.value) }}
-									placeholder="Enter SSH Host" />
-							</div>
+					<div className="field">
+						<label className="label">Database Password:</label>
+						<div className="control">
+							<input
+								className="input"
+								type="password"
+								value={dbPassword}
+								onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setDBPassword(e.target.value) }}
+								placeholder="Enter database password" />
 						</div>
-						<div className="field">
-							<label className="label">SSH User:</label>
-							<div className="control">
-								<input
-									className="input"
-									type="text"
-									value={dbSSHUser}
-									onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setSSHUser(e.target.value) }}
-									placeholder="Enter SSH User" />
-							</div>
+					</div>
+					<div className="field">
+						<label className="label">Use SSH:</label>
+						<div className="select">
+							<select
+								value={dbUseSSH}
+								onChange={(e: React.ChangeEvent<HTMLSelectElement>) => {
+									setUseSSH(e.target.value)
+								}}
+							>
+								<option
+									value={DBConnectionUseSSHType.NONE}>
+									None
+								</option>
+								<option
+									value={DBConnectionUseSSHType.PASSWORD}>
+									Password
+								</option>
+								<option
+									value={DBConnectionUseSSHType.KEYFILE}>
+									Identity File
+								</option>
+								<option
+									value={DBConnectionUseSSHType.PASSKEYFILE}>
+									Identity File with Password
+								</option>
+							</select>
 						</div>
-						{(dbUseSSH === DBConnectionUseSSHType.PASSWORD || dbUseSSH === DBConnectionUseSSHType.PASSKEYFILE) &&
-							< div className="field">
-								<label className="label">SSH Password:</label>
+					</div>
+					{dbUseSSH !== DBConnectionUseSSHType.NONE &&
+						<React.Fragment>
+							<div className="field">
+								<label className="label">SSH Host:</label>
 								<div className="control">
 									<input
 										className="input"
-										type="password"
-										value={dbSSHPassword}
-										onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setSSHPassword(e.target.value) }}
-										placeholder="Enter SSH Password" />
+										type="text"
+										value={dbSSHHost}
+										onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setSSHHost(e.target.value) }}
+										placeholder="Enter SSH Host" />
 								</div>
 							</div>
-						}
-						{(dbUseSSH === DBConnectionUseSSHType.KEYFILE || dbUseSSH === DBConnectionUseSSHType.PASSKEYFILE) &&
 							<div className="field">
-								<label className="label">SSH Identity File:</label>
+								<label className="label">SSH User:</label>
 								<div className="control">
-									<textarea
-										className="textarea"
-										value={dbSSHKeyFile}
-										onChange={(e: React.ChangeEvent<HTMLTextAreaElement>) => { setSSHKeyFile(e.target.value) }}
-										placeholder="Paste the contents of SSH Identity File here" />
+									<input
+										className="input"
+										type="text"
+										value={dbSSHUser}
+										onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setSSHUser(e.target.value) }}
+										placeholder="Enter SSH User" />
 								</div>
 							</div>
-						}
-					</React.Fragment>
-				}
+							{(dbUseSSH === DBConnectionUseSSHType.PASSWORD || dbUseSSH === DBConnectionUseSSHType.PASSKEYFILE) &&
+								< div className="field">
+									<label className="label">SSH Password:</label>
+									<div className="control">
+										<input
+											className="input"
+											type="password"
+											value={dbSSHPassword}
+											onChange={(e: React.ChangeEvent<HTMLInputElement>) => { setSSHPassword(e.target.value) }}
+											placeholder="Enter SSH Password" />
+									</div>
+								</div>
+							}
+							{(dbUseSSH === DBConnectionUseSSHType.KEYFILE || dbUseSSH === DBConnectionUseSSHType.PASSKEYFILE) &&
+								<div className="field">
+									<label className="label">SSH Identity File:</label>
+									<div className="control">
+										<textarea
+											className="textarea"
+											value={dbSSHKeyFile}
+											onChange={(e: React.ChangeEvent<HTMLTextAreaElement>) => { setSSHKeyFile(e.target.value) }}
+											placeholder="Paste the contents of SSH Identity File here" />
+									</div>
+								</div>
+							}
+						</React.Fragment>
+					}
+				</React.Fragment>}
 				<div className="control">
 					{!adding && <button className="button is-primary" onClick={startAddingDB}>Add</button>}
 					{adding && <button className="button is-primary">Adding...</button>}
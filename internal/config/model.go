@@ -7,6 +7,7 @@ type AppConfig struct {
 	Port              string
 	AuthTokenSecret   string
 	CryptedDataSecret string
+	SQLiteFilesDir    string
 }
 
 func newConfig() AppConfig {
@@ -15,5 +16,6 @@ func newConfig() AppConfig {
 		Port:              os.Getenv("PORT"),
 		AuthTokenSecret:   os.Getenv("AUTH_TOKEN_SECRET"),
 		CryptedDataSecret: os.Getenv("CRYPTED_DATA_SECRET"),
+		SQLiteFilesDir:    os.Getenv("SQLITE_FILES_DIR"),
 	}
 }
@@ -1,8 +1,15 @@
 package controllers
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
+	"slashbase.com/backend/internal/config"
 	"slashbase.com/backend/internal/dao"
 	"slashbase.com/backend/internal/models"
 	"slashbase.com/backend/pkg/queryengines/queryconfig"
@@ -39,11 +46,60 @@ func getQueryConfigsForProjectMember(projectMember *models.ProjectMember, dbConn
 		go dao.DBQueryLog.CreateDBQueryLog(queryLog)
 	}
 	rolePermissions, _ := dao.RolePermission.GetRolePermissionsForRole(projectMember.RoleID)
-	readOnly := false
+	config := queryconfig.NewQueryConfig(false, createLog)
+	config.UserID = projectMember.UserID
 	for _, perm := range *rolePermissions {
 		if perm.Name == models.ROLE_PERMISSION_NAME_READ_ONLY {
-			readOnly = true
+			config.ReadOnly = true
+		}
+		if perm.Name == models.ROLE_PERMISSION_NAME_MAX_EXECUTION_TIME && perm.Value {
+			config.MaxExecutionTime = time.Duration(perm.IntValue) * time.Millisecond
+		}
+		if perm.Name == models.ROLE_PERMISSION_NAME_MAX_ROWS && perm.Value {
+			config.MaxRows = perm.IntValue
+		}
+	}
+	return config
+}
+
+// getProjectMemberCanManageSessions reports if the project member can cancel and terminate the sessions of the
+// database servers of the project, admins always can and other roles need the MANAGE_SESSIONS permission.
+func getProjectMemberCanManageSessions(projectMember *models.ProjectMember) bool {
+	if projectMember.Role.Name == models.ROLE_ADMIN {
+		return true
+	}
+	rolePermissions, _ := dao.RolePermission.GetRolePermissionsForRole(projectMember.RoleID)
+	for _, perm := range *rolePermissions {
+		if perm.Name == models.ROLE_PERMISSION_NAME_MANAGE_SESSIONS && perm.Value {
+			return true
 		}
 	}
-	return queryconfig.NewQueryConfig(readOnly, createLog)
+	return false
+}
+
+// confirmationTokenValidity is how long the confirmation token of a destructive operation can be used.
+const confirmationTokenValidity = 5 * time.Minute
+
+// newConfirmationToken returns the token confirming the operation of the user, the operation is its name
+// followed by the names of its targets. It is signed so that it cannot be made by the client and expires
+// after confirmationTokenValidity.
+func newConfirmationToken(authUser *models.User, operation ...string) string {
+	expiresAt := strconv.FormatInt(time.Now().Add(confirmationTokenValidity).Unix(), 10)
+	return expiresAt + "." + confirmationTokenSignature(authUser, expiresAt, operation)
+}
+
+// isValidConfirmationToken reports if the token was returned by newConfirmationToken for the operation of the user and has not expired.
+func isValidConfirmationToken(token string, authUser *models.User, operation ...string) bool {
+	expiresAt, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(confirmationTokenSignature(authUser, expiresAt, operation))) {
+		return false
+	}
+	expiresAtUnix, err := strconv.ParseInt(expiresAt, 10, 64)
+	return err == nil && time.Now().Unix() < expiresAtUnix
+}
+
+func confirmationTokenSignature(authUser *models.User, expiresAt string, operation []string) string {
+	mac := hmac.New(sha256.New, []byte(config.GetConfig().AuthTokenSecret))
+	mac.Write([]byte(strings.Join(append([]string{authUser.ID, expiresAt}, operation...), "\x00")))
+	return hex.EncodeToString(mac.Sum(nil))
 }
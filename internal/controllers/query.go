@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -9,11 +10,13 @@ import (
 	"slashbase.com/backend/internal/models"
 	"slashbase.com/backend/internal/utils"
 	"slashbase.com/backend/pkg/queryengines"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
 )
 
 type QueryController struct{}
 
-func (QueryController) RunQuery(authUser *models.User, dbConnectionId, query string) (map[string]interface{}, error) {
+// RunQuery runs the query until it finishes or ctx is done, a query run with a queryId can be cancelled with CancelQuery.
+func (QueryController) RunQuery(ctx context.Context, authUser *models.User, dbConnectionId, query, queryId string) (map[string]interface{}, error) {
 
 	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnectionId)
 	if err != nil {
@@ -25,16 +28,211 @@ func (QueryController) RunQuery(authUser *models.User, dbConnectionId, query str
 		return nil, err
 	}
 
-	data, err := queryengines.RunQuery(dbConn, query, getQueryConfigsForProjectMember(pm, dbConn))
+	config := getQueryConfigsForProjectMember(pm, dbConn)
+	config.Context = ctx
+	if queryId != "" {
+		config.QueryID = getRunningQueryID(authUser, queryId)
+	}
+	data, err := queryengines.RunQuery(dbConn, query, config)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (QueryController) StreamQuery(ctx context.Context, authUser *models.User, dbConnectionId, query, queryId string, stream queryengines.ResultStream) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnectionId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := getQueryConfigsForProjectMember(pm, dbConn)
+	config.Context = ctx
+	if queryId != "" {
+		config.QueryID = getRunningQueryID(authUser, queryId)
+	}
+	data, err := queryengines.StreamQuery(dbConn, query, stream, config)
 	if err != nil {
 		return nil, err
 	}
 	return data, nil
 }
 
-func (QueryController) GetData(authUser *models.User, authUserProjectIds *[]string,
+func (QueryController) CancelQuery(authUser *models.User, dbConnectionId, queryId string) error {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnectionId)
+	if err != nil {
+		return errors.New("there was some problem")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	return queryengines.CancelQuery(dbConn, getRunningQueryID(authUser, queryId), getQueryConfigsForProjectMember(pm, dbConn))
+}
+
+// getRunningQueryID scopes the query id sent by the client to the user, so users can only cancel their own queries.
+func getRunningQueryID(authUser *models.User, queryId string) string {
+	return authUser.ID + ":" + queryId
+}
+
+func (QueryController) ExplainQuery(ctx context.Context, authUser *models.User, dbConnectionId, query string, analyze bool) (*queryengines.QueryPlan, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnectionId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := getQueryConfigsForProjectMember(pm, dbConn)
+	config.Context = ctx
+	plan, err := queryengines.ExplainQuery(dbConn, query, analyze, config)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (QueryController) RunScript(ctx context.Context, authUser *models.User, dbConnectionId, script string, inTransaction bool) ([]*queryengines.StatementResult, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnectionId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := getQueryConfigsForProjectMember(pm, dbConn)
+	config.Context = ctx
+	results, err := queryengines.RunScript(dbConn, script, inTransaction, config)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (QueryController) BeginTransaction(authUser *models.User, dbConnectionId string) (string, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnectionId)
+	if err != nil {
+		return "", errors.New("there was some problem")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return "", err
+	}
+
+	return queryengines.BeginTransaction(dbConn, getQueryConfigsForProjectMember(pm, dbConn))
+}
+
+func (QueryController) RunTransactionQuery(authUser *models.User, dbConnectionId, transactionId, query string) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnectionId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := queryengines.RunTransactionQuery(dbConn, transactionId, query, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// EndTransaction commits the transaction if commit is true, else rolls it back.
+func (QueryController) EndTransaction(authUser *models.User, dbConnectionId, transactionId string, commit bool) error {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnectionId)
+	if err != nil {
+		return errors.New("there was some problem")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if commit {
+		return queryengines.CommitTransaction(dbConn, transactionId, getQueryConfigsForProjectMember(pm, dbConn))
+	}
+	return queryengines.RollbackTransaction(dbConn, transactionId, getQueryConfigsForProjectMember(pm, dbConn))
+}
+
+func (QueryController) GetData(ctx context.Context, authUser *models.User, authUserProjectIds *[]string,
 	dbConnId, schema, name string, fetchCount bool, limit int, offset int64,
-	filter, sort []string) (map[string]interface{}, error) {
+	filter *queryengines.Filter, sort []queryengines.SortField) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := getQueryConfigsForProjectMember(pm, dbConn)
+	config.Context = ctx
+	data, err := queryengines.GetData(dbConn, schema, name, limit, offset, fetchCount, filter, sort, config)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (QueryController) GetDataByCursor(ctx context.Context, authUser *models.User, authUserProjectIds *[]string,
+	dbConnId, schema, name string, fetchCount bool, limit int, cursor string,
+	filter *queryengines.Filter, sort []queryengines.SortField) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := getQueryConfigsForProjectMember(pm, dbConn)
+	config.Context = ctx
+	data, err := queryengines.GetDataByCursor(dbConn, schema, name, limit, cursor, fetchCount, filter, sort, config)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (QueryController) GetKeyValue(authUser *models.User, authUserProjectIds *[]string,
+	dbConnId, key string) (map[string]interface{}, error) {
 
 	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
 	if err != nil {
@@ -49,7 +247,7 @@ func (QueryController) GetData(authUser *models.User, authUserProjectIds *[]stri
 		return nil, err
 	}
 
-	data, err := queryengines.GetData(dbConn, schema, name, limit, offset, fetchCount, filter, sort, getQueryConfigsForProjectMember(pm, dbConn))
+	data, err := queryengines.GetKeyValue(dbConn, key, getQueryConfigsForProjectMember(pm, dbConn))
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +299,129 @@ func (QueryController) GetSingleDataModel(authUser *models.User, authUserProject
 	return data, nil
 }
 
+func (QueryController) GetSingleDataModelDDL(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	schema, name string) (string, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return "", errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return "", errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return "", err
+	}
+
+	ddl, err := queryengines.GetSingleDataModelDDL(dbConn, schema, name, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return "", err
+	}
+	return ddl, nil
+}
+
+// DiffDBSchemas compares the schema of the target connection with the one of the source connection,
+// the user must be able to query both.
+func (QueryController) DiffDBSchemas(authUser *models.User, authUserProjectIds *[]string, sourceDBConnId, targetDBConnId string) (*queryengines.SchemaDiff, error) {
+
+	sourceDBConn, err := dao.DBConnection.GetDBConnectionByID(sourceDBConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	targetDBConn, err := dao.DBConnection.GetDBConnectionByID(targetDBConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, sourceDBConn.ProjectID) || !utils.ContainsString(*authUserProjectIds, targetDBConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+
+	sourcePM, err := getAuthUserProjectMemberForProject(authUser, sourceDBConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	targetPM, err := getAuthUserProjectMemberForProject(authUser, targetDBConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := queryengines.DiffDBSchemas(sourceDBConn, targetDBConn,
+		getQueryConfigsForProjectMember(sourcePM, sourceDBConn), getQueryConfigsForProjectMember(targetPM, targetDBConn))
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+func (QueryController) GetDBGraph(authUser *models.User, authUserProjectIds *[]string, dbConnId string) (*queryengines.DBGraph, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := queryengines.GetDBGraph(dbConn, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func (QueryController) GetDBObjects(authUser *models.User, authUserProjectIds *[]string, dbConnId, kind string) ([]*queryengines.DBObject, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbObjects, err := queryengines.GetDBObjects(dbConn, kind, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return dbObjects, nil
+}
+
+func (QueryController) GetSingleDBObject(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	kind, schema, name string) (*queryengines.DBObject, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbObject, err := queryengines.GetSingleDBObject(dbConn, kind, schema, name, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return dbObject, nil
+}
+
 func (QueryController) AddSingleDataModelField(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
 	schema, name string, fieldName, dataType string) (map[string]interface{}, error) {
 
@@ -145,6 +466,166 @@ func (QueryController) DeleteSingleDataModelField(authUser *models.User, authUse
 	return data, nil
 }
 
+func (QueryController) CreateDataModel(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	schema string, spec *queryengines.DataModelSpec) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := queryengines.CreateDataModel(dbConn, schema, spec, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (QueryController) RenameDataModel(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	schema, name, newName string) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := queryengines.RenameDataModel(dbConn, schema, name, newName, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// TruncateDataModel deletes all the data of the data model, see runDestructiveDataModelOperation for the confirmation.
+func (QueryController) TruncateDataModel(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	schema, name, confirmationToken string) (map[string]interface{}, error) {
+	return runDestructiveDataModelOperation(authUser, authUserProjectIds, dbConnId, "truncate", schema, name, confirmationToken, queryengines.TruncateDataModel)
+}
+
+// DropDataModel drops the data model, see runDestructiveDataModelOperation for the confirmation.
+func (QueryController) DropDataModel(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	schema, name, confirmationToken string) (map[string]interface{}, error) {
+	return runDestructiveDataModelOperation(authUser, authUserProjectIds, dbConnId, "drop", schema, name, confirmationToken, queryengines.DropDataModel)
+}
+
+// runDestructiveDataModelOperation runs the operation on the data model only when it is confirmed with the token
+// returned by a previous call without a token, the operation is not run then and confirmationRequired is set.
+// Read only roles do not get a token.
+func runDestructiveDataModelOperation(authUser *models.User, authUserProjectIds *[]string, dbConnId, operation, schema, name, confirmationToken string,
+	run func(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error)) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := getQueryConfigsForProjectMember(pm, dbConn)
+	if config.ReadOnly {
+		return nil, errors.New("not allowed run this query")
+	}
+	if confirmationToken == "" {
+		return map[string]interface{}{
+			"confirmationRequired": true,
+			"confirmationToken":    newConfirmationToken(authUser, operation, dbConn.ID, schema, name),
+		}, nil
+	}
+	if !isValidConfirmationToken(confirmationToken, authUser, operation, dbConn.ID, schema, name) {
+		return nil, errors.New("invalid or expired confirmation token")
+	}
+	data, err := run(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (QueryController) AlterSingleDataModelField(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	schema, name, fieldName string, alteration *queryengines.FieldAlteration, preview bool) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := queryengines.AlterSingleDataModelField(dbConn, schema, name, fieldName, alteration, preview, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (QueryController) CreateIndex(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	schema, name string, index *queryengines.IndexSpec) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := queryengines.CreateIndex(dbConn, schema, name, index, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (QueryController) DropIndex(authUser *models.User, authUserProjectIds *[]string, dbConnId string,
+	schema, name, indexName string, concurrently bool) (map[string]interface{}, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := queryengines.DropIndex(dbConn, schema, name, indexName, concurrently, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 func (QueryController) AddData(authUser *models.User, dbConnId string,
 	schema, name string, data map[string]interface{}) (*queryengines.AddDataResponse, error) {
 
@@ -299,3 +780,69 @@ func (QueryController) GetQueryHistoryInDBConnection(authUser *models.User, auth
 
 	return dbQueryLogs, next, nil
 }
+
+func (QueryController) GetDBSessions(authUser *models.User, authUserProjectIds *[]string, dbConnId string) ([]*queryengines.DBSession, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := queryengines.GetDBSessions(dbConn, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (QueryController) GetDBLocks(authUser *models.User, authUserProjectIds *[]string, dbConnId string) (*queryengines.DBLocks, error) {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return nil, errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return nil, errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	locks, err := queryengines.GetDBLocks(dbConn, getQueryConfigsForProjectMember(pm, dbConn))
+	if err != nil {
+		return nil, err
+	}
+	return locks, nil
+}
+
+// CancelDBSession cancels the query of the session, or terminates the session, it needs the MANAGE_SESSIONS permission.
+func (QueryController) CancelDBSession(authUser *models.User, authUserProjectIds *[]string, dbConnId string, pid int32, terminate bool) error {
+
+	dbConn, err := dao.DBConnection.GetDBConnectionByID(dbConnId)
+	if err != nil {
+		return errors.New("there was some problem")
+	}
+	if !utils.ContainsString(*authUserProjectIds, dbConn.ProjectID) {
+		return errors.New("not allowed to run query")
+	}
+
+	pm, err := getAuthUserProjectMemberForProject(authUser, dbConn.ProjectID)
+	if err != nil {
+		return err
+	}
+	if !getProjectMemberCanManageSessions(pm) {
+		return errors.New("not allowed to manage sessions")
+	}
+
+	return queryengines.CancelDBSession(dbConn, pid, terminate, getQueryConfigsForProjectMember(pm, dbConn))
+}
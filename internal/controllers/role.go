@@ -65,13 +65,17 @@ func (RoleController) DeleteRole(user *models.User, roleID string) error {
 	return nil
 }
 
-func (RoleController) AddOrUpdateRolePermission(user *models.User, roleID, name string, value bool) (*models.RolePermission, error) {
+func (RoleController) AddOrUpdateRolePermission(user *models.User, roleID, name string, value bool, intValue int) (*models.RolePermission, error) {
 
 	if !user.IsRoot {
 		return nil, errors.New("not allowed")
 	}
 
-	rp, err := dao.RolePermission.UpdateRolePermission(roleID, name, value)
+	if intValue < 0 {
+		return nil, errors.New("invalid value for role permission: " + name)
+	}
+
+	rp, err := dao.RolePermission.UpdateRolePermission(roleID, name, value, intValue)
 	if err != nil {
 		return nil, errors.New("cannot update role permission: " + name)
 	}
@@ -27,11 +27,11 @@ func (rolePermissionDao) GetAllRolePermissions() (*[]models.RolePermission, erro
 	return &rps, err
 }
 
-func (rolePermissionDao) UpdateRolePermission(roleID, name string, value bool) (*models.RolePermission, error) {
-	rolePermission := models.NewRolePermission(roleID, name, value)
+func (rolePermissionDao) UpdateRolePermission(roleID, name string, value bool, intValue int) (*models.RolePermission, error) {
+	rolePermission := models.NewRolePermission(roleID, name, value, intValue)
 	err := db.GetDB().Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "role_id"}, {Name: "name"}},
-		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+		DoUpdates: clause.AssignmentColumns([]string{"value", "int_value"}),
 	}).Create(rolePermission).Error
 	return rolePermission, err
 }
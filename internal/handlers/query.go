@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"slashbase.com/backend/internal/middlewares"
 	"slashbase.com/backend/internal/utils"
 	"slashbase.com/backend/internal/views"
+	"slashbase.com/backend/pkg/queryengines"
 )
 
 type QueryHandlers struct{}
@@ -20,11 +23,144 @@ func (QueryHandlers) RunQuery(c *gin.Context) {
 	var runBody struct {
 		DBConnectionID string `json:"dbConnectionId"`
 		Query          string `json:"query"`
+		QueryID        string `json:"queryId"`
+		Stream         bool   `json:"stream"`
 	}
 	c.BindJSON(&runBody)
 	authUser := middlewares.GetAuthUser(c)
 
-	data, err := queryController.RunQuery(authUser, runBody.DBConnectionID, runBody.Query)
+	if runBody.Stream {
+		stream := &ndjsonResultStream{c: c, encoder: json.NewEncoder(c.Writer)}
+		data, err := queryController.StreamQuery(c.Request.Context(), authUser, runBody.DBConnectionID, runBody.Query, runBody.QueryID, stream)
+		if err != nil && !stream.started {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			stream.write(gin.H{"type": "error", "error": err.Error()})
+			return
+		}
+		stream.write(gin.H{"type": "end", "data": data})
+		return
+	}
+
+	data, err := queryController.RunQuery(c.Request.Context(), authUser, runBody.DBConnectionID, runBody.Query, runBody.QueryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (QueryHandlers) CancelQuery(c *gin.Context) {
+	var cancelBody struct {
+		DBConnectionID string `json:"dbConnectionId"`
+		QueryID        string `json:"queryId"`
+	}
+	c.BindJSON(&cancelBody)
+	authUser := middlewares.GetAuthUser(c)
+
+	err := queryController.CancelQuery(authUser, cancelBody.DBConnectionID, cancelBody.QueryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+func (QueryHandlers) ExplainQuery(c *gin.Context) {
+	var explainBody struct {
+		DBConnectionID string `json:"dbConnectionId"`
+		Query          string `json:"query"`
+		Analyze        bool   `json:"analyze"`
+	}
+	c.BindJSON(&explainBody)
+	authUser := middlewares.GetAuthUser(c)
+
+	plan, err := queryController.ExplainQuery(c.Request.Context(), authUser, explainBody.DBConnectionID, explainBody.Query, explainBody.Analyze)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+func (QueryHandlers) RunScript(c *gin.Context) {
+	var runBody struct {
+		DBConnectionID string `json:"dbConnectionId"`
+		Query          string `json:"query"`
+		InTransaction  bool   `json:"inTransaction"`
+	}
+	c.BindJSON(&runBody)
+	authUser := middlewares.GetAuthUser(c)
+
+	results, err := queryController.RunScript(c.Request.Context(), authUser, runBody.DBConnectionID, runBody.Query, runBody.InTransaction)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+func (QueryHandlers) BeginTransaction(c *gin.Context) {
+	var beginBody struct {
+		DBConnectionID string `json:"dbConnectionId"`
+	}
+	c.BindJSON(&beginBody)
+	authUser := middlewares.GetAuthUser(c)
+
+	transactionId, err := queryController.BeginTransaction(authUser, beginBody.DBConnectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"transactionId": transactionId,
+		},
+	})
+}
+
+func (QueryHandlers) RunTransactionQuery(c *gin.Context) {
+	var runBody struct {
+		DBConnectionID string `json:"dbConnectionId"`
+		TransactionID  string `json:"transactionId"`
+		Query          string `json:"query"`
+	}
+	c.BindJSON(&runBody)
+	authUser := middlewares.GetAuthUser(c)
+
+	data, err := queryController.RunTransactionQuery(authUser, runBody.DBConnectionID, runBody.TransactionID, runBody.Query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -38,6 +174,35 @@ func (QueryHandlers) RunQuery(c *gin.Context) {
 	})
 }
 
+func (QueryHandlers) CommitTransaction(c *gin.Context) {
+	endTransaction(c, true)
+}
+
+func (QueryHandlers) RollbackTransaction(c *gin.Context) {
+	endTransaction(c, false)
+}
+
+func endTransaction(c *gin.Context, commit bool) {
+	var endBody struct {
+		DBConnectionID string `json:"dbConnectionId"`
+		TransactionID  string `json:"transactionId"`
+	}
+	c.BindJSON(&endBody)
+	authUser := middlewares.GetAuthUser(c)
+
+	err := queryController.EndTransaction(authUser, endBody.DBConnectionID, endBody.TransactionID, commit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
 func (QueryHandlers) GetData(c *gin.Context) {
 	dbConnId := c.Param("dbConnId")
 
@@ -54,12 +219,77 @@ func (QueryHandlers) GetData(c *gin.Context) {
 	if err != nil {
 		offset = int64(0)
 	}
-	filter, _ := c.GetQueryArray("filter[]")
-	sort, _ := c.GetQueryArray("sort[]")
+	filter, sort, err := getDataFilterAndSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	var data map[string]interface{}
+	if c.Query("pagination") == "cursor" {
+		data, err = queryController.GetDataByCursor(c.Request.Context(), authUser, authUserProjectIds, dbConnId, schema, name, fetchCount, limit, c.Query("cursor"), filter, sort)
+	} else {
+		data, err = queryController.GetData(c.Request.Context(), authUser, authUserProjectIds, dbConnId, schema, name, fetchCount, limit, offset, filter, sort)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// getDataFilterAndSort reads the json filter tree and sort fields from the query params,
+// the older filter[] (column, operator, value) and sort[] (column, direction) arrays are also accepted.
+func getDataFilterAndSort(c *gin.Context) (*queryengines.Filter, []queryengines.SortField, error) {
+	var filter *queryengines.Filter
+	if filterStr := c.Query("filter"); filterStr != "" {
+		filter = &queryengines.Filter{}
+		if err := json.Unmarshal([]byte(filterStr), filter); err != nil {
+			return nil, nil, errors.New("invalid filter")
+		}
+	} else if filterArr, ok := c.GetQueryArray("filter[]"); ok {
+		// legacy filter of field, operator and optional value
+		if len(filterArr) != 2 && len(filterArr) != 3 {
+			return nil, nil, errors.New("invalid filter")
+		}
+		filter = &queryengines.Filter{Field: filterArr[0], Operator: filterArr[1]}
+		if len(filterArr) == 3 {
+			filter.Value = filterArr[2]
+		}
+	}
+	sort := []queryengines.SortField{}
+	if sortStr := c.Query("sort"); sortStr != "" {
+		if err := json.Unmarshal([]byte(sortStr), &sort); err != nil {
+			return nil, nil, errors.New("invalid sort")
+		}
+	} else if sortArr, ok := c.GetQueryArray("sort[]"); ok {
+		// legacy sort of field and direction
+		if len(sortArr) != 2 {
+			return nil, nil, errors.New("invalid sort")
+		}
+		sort = append(sort, queryengines.SortField{Field: sortArr[0], Direction: sortArr[1]})
+	}
+	return filter, sort, nil
+}
+
+func (QueryHandlers) GetKeyValue(c *gin.Context) {
+	dbConnId := c.Param("dbConnId")
+	key := c.Query("key")
 	authUser := middlewares.GetAuthUser(c)
 	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
 
-	data, err := queryController.GetData(authUser, authUserProjectIds, dbConnId, schema, name, fetchCount, limit, offset, filter, sort)
+	data, err := queryController.GetKeyValue(authUser, authUserProjectIds, dbConnId, key)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -115,6 +345,110 @@ func (QueryHandlers) GetSingleDataModel(c *gin.Context) {
 	})
 }
 
+func (QueryHandlers) GetSingleDataModelDDL(c *gin.Context) {
+	dbConnId := c.Param("dbConnId")
+
+	schema := c.Query("schema")
+	name := c.Query("name")
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	ddl, err := queryController.GetSingleDataModelDDL(authUser, authUserProjectIds, dbConnId, schema, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ddl,
+	})
+}
+
+func (QueryHandlers) DiffDBSchemas(c *gin.Context) {
+	sourceDBConnId := c.Query("sourceDbConnId")
+	targetDBConnId := c.Query("targetDbConnId")
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	diff, err := queryController.DiffDBSchemas(authUser, authUserProjectIds, sourceDBConnId, targetDBConnId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    diff,
+	})
+}
+
+func (QueryHandlers) GetDBGraph(c *gin.Context) {
+	dbConnId := c.Param("dbConnId")
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	graph, err := queryController.GetDBGraph(authUser, authUserProjectIds, dbConnId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    graph,
+	})
+}
+
+func (QueryHandlers) GetDBObjects(c *gin.Context) {
+	dbConnId := c.Param("dbConnId")
+	kind := c.Query("kind")
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	dbObjects, err := queryController.GetDBObjects(authUser, authUserProjectIds, dbConnId, kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dbObjects,
+	})
+}
+
+func (QueryHandlers) GetSingleDBObject(c *gin.Context) {
+	dbConnId := c.Param("dbConnId")
+
+	kind := c.Query("kind")
+	schema := c.Query("schema")
+	name := c.Query("name")
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	data, err := queryController.GetSingleDBObject(authUser, authUserProjectIds, dbConnId, kind, schema, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
 func (QueryHandlers) AddSingleDataModelField(c *gin.Context) {
 	var reqBody struct {
 		DBConnectionID string `json:"dbConnectionId"`
@@ -166,6 +500,183 @@ func (QueryHandlers) DeleteSingleDataModelField(c *gin.Context) {
 	})
 }
 
+func (QueryHandlers) CreateDataModel(c *gin.Context) {
+	var reqBody struct {
+		DBConnectionID string                     `json:"dbConnectionId"`
+		Schema         string                     `json:"schema"`
+		DataModel      queryengines.DataModelSpec `json:"dataModel"`
+	}
+	c.BindJSON(&reqBody)
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	data, err := queryController.CreateDataModel(authUser, authUserProjectIds, reqBody.DBConnectionID, reqBody.Schema, &reqBody.DataModel)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (QueryHandlers) RenameDataModel(c *gin.Context) {
+	var reqBody struct {
+		DBConnectionID string `json:"dbConnectionId"`
+		Schema         string `json:"schema"`
+		Name           string `json:"name"`
+		NewName        string `json:"newName"`
+	}
+	c.BindJSON(&reqBody)
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	data, err := queryController.RenameDataModel(authUser, authUserProjectIds, reqBody.DBConnectionID, reqBody.Schema, reqBody.Name, reqBody.NewName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (QueryHandlers) TruncateDataModel(c *gin.Context) {
+	var reqBody struct {
+		DBConnectionID    string `json:"dbConnectionId"`
+		Schema            string `json:"schema"`
+		Name              string `json:"name"`
+		ConfirmationToken string `json:"confirmationToken"`
+	}
+	c.BindJSON(&reqBody)
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	data, err := queryController.TruncateDataModel(authUser, authUserProjectIds, reqBody.DBConnectionID, reqBody.Schema, reqBody.Name, reqBody.ConfirmationToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (QueryHandlers) DropDataModel(c *gin.Context) {
+	var reqBody struct {
+		DBConnectionID    string `json:"dbConnectionId"`
+		Schema            string `json:"schema"`
+		Name              string `json:"name"`
+		ConfirmationToken string `json:"confirmationToken"`
+	}
+	c.BindJSON(&reqBody)
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	data, err := queryController.DropDataModel(authUser, authUserProjectIds, reqBody.DBConnectionID, reqBody.Schema, reqBody.Name, reqBody.ConfirmationToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (QueryHandlers) AlterSingleDataModelField(c *gin.Context) {
+	var reqBody struct {
+		DBConnectionID string                       `json:"dbConnectionId"`
+		Schema         string                       `json:"schema"`
+		Name           string                       `json:"name"`
+		FieldName      string                       `json:"fieldName"`
+		Alteration     queryengines.FieldAlteration `json:"alteration"`
+		Preview        bool                         `json:"preview"`
+	}
+	c.BindJSON(&reqBody)
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	data, err := queryController.AlterSingleDataModelField(authUser, authUserProjectIds, reqBody.DBConnectionID, reqBody.Schema, reqBody.Name, reqBody.FieldName, &reqBody.Alteration, reqBody.Preview)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (QueryHandlers) CreateIndex(c *gin.Context) {
+	var reqBody struct {
+		DBConnectionID string                 `json:"dbConnectionId"`
+		Schema         string                 `json:"schema"`
+		Name           string                 `json:"name"`
+		Index          queryengines.IndexSpec `json:"index"`
+	}
+	c.BindJSON(&reqBody)
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	data, err := queryController.CreateIndex(authUser, authUserProjectIds, reqBody.DBConnectionID, reqBody.Schema, reqBody.Name, &reqBody.Index)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func (QueryHandlers) DropIndex(c *gin.Context) {
+	var reqBody struct {
+		DBConnectionID string `json:"dbConnectionId"`
+		Schema         string `json:"schema"`
+		Name           string `json:"name"`
+		IndexName      string `json:"indexName"`
+		Concurrently   bool   `json:"concurrently"`
+	}
+	c.BindJSON(&reqBody)
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	data, err := queryController.DropIndex(authUser, authUserProjectIds, reqBody.DBConnectionID, reqBody.Schema, reqBody.Name, reqBody.IndexName, reqBody.Concurrently)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
 func (QueryHandlers) AddData(c *gin.Context) {
 	dbConnId := c.Param("dbConnId")
 	var addBody struct {
@@ -196,7 +707,7 @@ func (QueryHandlers) DeleteData(c *gin.Context) {
 	var deleteBody struct {
 		Schema string   `json:"schema"`
 		Name   string   `json:"name"`
-		IDs    []string `json:"ids"` // ctid for postgres, _id for mongo
+		IDs    []string `json:"ids"` // row ids returned by GetData, _id for mongo
 	}
 	c.BindJSON(&deleteBody)
 
@@ -338,3 +849,109 @@ func (QueryHandlers) GetQueryHistoryInDBConnection(c *gin.Context) {
 		},
 	})
 }
+
+// ndjsonResultStreamFlushRows is the number of rows after which the stream is flushed to the client.
+const ndjsonResultStreamFlushRows = 100
+
+// ndjsonResultStream writes the result of a streamed query as newline delimited json, a line per row.
+// Writes block while the client is not reading, which also pauses reading rows from the database,
+// and fail once the client has disconnected, which aborts the query.
+type ndjsonResultStream struct {
+	c             *gin.Context
+	encoder       *json.Encoder
+	started       bool
+	unflushedRows int
+}
+
+func (stream *ndjsonResultStream) WriteColumns(columns []string) error {
+	if err := stream.write(gin.H{"type": "columns", "columns": columns}); err != nil {
+		return err
+	}
+	stream.c.Writer.Flush()
+	return nil
+}
+
+func (stream *ndjsonResultStream) WriteRow(row map[string]interface{}) error {
+	if err := stream.write(gin.H{"type": "row", "row": row}); err != nil {
+		return err
+	}
+	stream.unflushedRows++
+	if stream.unflushedRows == ndjsonResultStreamFlushRows {
+		stream.c.Writer.Flush()
+		stream.unflushedRows = 0
+	}
+	return nil
+}
+
+func (stream *ndjsonResultStream) write(line gin.H) error {
+	if err := stream.c.Request.Context().Err(); err != nil {
+		return err
+	}
+	if !stream.started {
+		stream.c.Header("Content-Type", "application/x-ndjson")
+		stream.c.Status(http.StatusOK)
+		stream.started = true
+	}
+	return stream.encoder.Encode(line)
+}
+
+func (QueryHandlers) GetDBSessions(c *gin.Context) {
+	dbConnId := c.Param("dbConnId")
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	sessions, err := queryController.GetDBSessions(authUser, authUserProjectIds, dbConnId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sessions,
+	})
+}
+
+func (QueryHandlers) GetDBLocks(c *gin.Context) {
+	dbConnId := c.Param("dbConnId")
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	locks, err := queryController.GetDBLocks(authUser, authUserProjectIds, dbConnId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    locks,
+	})
+}
+
+func (QueryHandlers) CancelDBSession(c *gin.Context) {
+	dbConnId := c.Param("dbConnId")
+	var reqBody struct {
+		PID       int32 `json:"pid"`
+		Terminate bool  `json:"terminate"`
+	}
+	c.BindJSON(&reqBody)
+	authUser := middlewares.GetAuthUser(c)
+	authUserProjectIds := middlewares.GetAuthUserProjectIds(c)
+
+	err := queryController.CancelDBSession(authUser, authUserProjectIds, dbConnId, reqBody.PID, reqBody.Terminate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -86,12 +86,13 @@ func (RoleHandlers) DeleteRole(c *gin.Context) {
 func (RoleHandlers) UpdateRolePermission(c *gin.Context) {
 	roleID := c.Param("id")
 	var reqBody struct {
-		Name  string `json:"name"`
-		Value bool   `json:"value"`
+		Name     string `json:"name"`
+		Value    bool   `json:"value"`
+		IntValue int    `json:"intValue"`
 	}
 	c.BindJSON(&reqBody)
 	authUser := middlewares.GetAuthUser(c)
-	rp, err := roleController.AddOrUpdateRolePermission(authUser, roleID, reqBody.Name, reqBody.Value)
+	rp, err := roleController.AddOrUpdateRolePermission(authUser, roleID, reqBody.Name, reqBody.Value, reqBody.IntValue)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
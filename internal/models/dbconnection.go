@@ -38,6 +38,9 @@ type DBConnection struct {
 const (
 	DBTYPE_POSTGRES = "POSTGRES"
 	DBTYPE_MONGO    = "MONGO"
+	DBTYPE_MYSQL    = "MYSQL"
+	DBTYPE_SQLITE   = "SQLITE"
+	DBTYPE_REDIS    = "REDIS"
 
 	DBUSESSH_NONE        = "NONE"
 	DBUSESSH_PASSWORD    = "PASSWORD"
@@ -48,23 +51,45 @@ const (
 	// DBLOGINTYPE_ROLE_ACCOUNTS = "ROLE_ACCOUNTS"
 )
 
+// DBTypeInfo describes the connection details required by a db type.
+type DBTypeInfo struct {
+	// Schemes are the connection schemes allowed, the first one is the default.
+	Schemes []string
+	// IsFile is true when DBHost is the path of a database file on the server.
+	IsFile bool
+}
+
+// dbTypeInfos contains the db types registered by the query engines.
+var dbTypeInfos = map[string]DBTypeInfo{}
+
+func RegisterDBType(dbType string, info DBTypeInfo) {
+	dbTypeInfos[dbType] = info
+}
+
 func NewDBConnection(userID string, projectID string, name string, dbtype string, dbscheme, dbhost, dbport, dbuser, dbpassword, databaseName, useSSH, sshHost, sshUser, sshPassword, sshKeyFile string) (*DBConnection, error) {
 
 	if !utils.ContainsString([]string{DBUSESSH_NONE, DBUSESSH_PASSWORD, DBUSESSH_KEYFILE, DBUSESSH_PASSKEYFILE}, useSSH) {
 		return nil, errors.New("useSSH is not correct")
 	}
 
-	if dbtype == DBTYPE_POSTGRES {
-		dbscheme = "postgres"
-	} else if dbtype == DBTYPE_MONGO {
-		if !utils.ContainsString([]string{"mongodb", "mongodb+srv"}, dbscheme) {
-			return nil, errors.New("invalid dbscheme")
-		}
-	} else {
+	dbTypeInfo, exists := dbTypeInfos[dbtype]
+	if !exists {
 		return nil, errors.New("dbtype is not correct")
 	}
+	if len(dbTypeInfo.Schemes) == 1 {
+		dbscheme = dbTypeInfo.Schemes[0]
+	} else if !utils.ContainsString(dbTypeInfo.Schemes, dbscheme) {
+		return nil, errors.New("invalid dbscheme")
+	}
 
-	if name == "" || dbhost == "" || dbport == "" || databaseName == "" {
+	if dbTypeInfo.IsFile {
+		if useSSH != DBUSESSH_NONE {
+			return nil, errors.New("ssh is not supported for dbtype")
+		}
+		if name == "" || dbhost == "" {
+			return nil, errors.New("cannot be empty")
+		}
+	} else if name == "" || dbhost == "" || dbport == "" || databaseName == "" {
 		return nil, errors.New("cannot be empty")
 	}
 
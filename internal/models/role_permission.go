@@ -11,6 +11,7 @@ type RolePermission struct {
 	RoleID    string `gorm:"index:idx_roleid_name,unique"`
 	Name      string `gorm:"index:idx_roleid_name,unique"`
 	Value     bool
+	IntValue  int
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 
@@ -19,13 +20,19 @@ type RolePermission struct {
 
 const (
 	ROLE_PERMISSION_NAME_READ_ONLY = "READ_ONLY"
+	// cancel and terminate the sessions of the database servers, admins always can
+	ROLE_PERMISSION_NAME_MANAGE_SESSIONS = "MANAGE_SESSIONS"
+	// limits are set with IntValue and enforced when Value is true
+	ROLE_PERMISSION_NAME_MAX_EXECUTION_TIME = "MAX_EXECUTION_TIME" // in milliseconds
+	ROLE_PERMISSION_NAME_MAX_ROWS           = "MAX_ROWS"
 )
 
-func NewRolePermission(roleID string, name string, value bool) *RolePermission {
+func NewRolePermission(roleID string, name string, value bool, intValue int) *RolePermission {
 	return &RolePermission{
-		ID:     uuid.NewString(),
-		RoleID: roleID,
-		Name:   name,
-		Value:  value,
+		ID:       uuid.NewString(),
+		RoleID:   roleID,
+		Name:     name,
+		Value:    value,
+		IntValue: intValue,
 	}
 }
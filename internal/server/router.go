@@ -67,13 +67,24 @@ func NewRouter() *gin.Engine {
 			queryGroup.Use(middlewares.FindUserMiddleware())
 			queryGroup.Use(middlewares.AuthUserMiddleware())
 			queryGroup.POST("/run", queryHandlers.RunQuery)
+			queryGroup.POST("/cancel", queryHandlers.CancelQuery)
+			queryGroup.POST("/explain", queryHandlers.ExplainQuery)
+			queryGroup.POST("/runscript", queryHandlers.RunScript)
 			queryGroup.POST("/save/:dbConnId", queryHandlers.SaveDBQuery)
 			queryGroup.GET("/getall/:dbConnId", queryHandlers.GetDBQueriesInDBConnection)
 			queryGroup.GET("/get/:queryId", queryHandlers.GetSingleDBQuery)
 			queryGroup.GET("/history/:dbConnId", queryHandlers.GetQueryHistoryInDBConnection)
+			transactionGroup := queryGroup.Group("transaction")
+			{
+				transactionGroup.POST("/begin", queryHandlers.BeginTransaction)
+				transactionGroup.POST("/run", queryHandlers.RunTransactionQuery)
+				transactionGroup.POST("/commit", queryHandlers.CommitTransaction)
+				transactionGroup.POST("/rollback", queryHandlers.RollbackTransaction)
+			}
 			dataGroup := queryGroup.Group("data")
 			{
 				dataGroup.GET("/:dbConnId", queryHandlers.GetData)
+				dataGroup.GET("/:dbConnId/key", queryHandlers.GetKeyValue)
 				dataGroup.POST("/:dbConnId/single", queryHandlers.UpdateSingleData)
 				dataGroup.POST("/:dbConnId/add", queryHandlers.AddData)
 				dataGroup.POST("/:dbConnId/delete", queryHandlers.DeleteData)
@@ -82,8 +93,29 @@ func NewRouter() *gin.Engine {
 			{
 				dataModelGroup.GET("/all/:dbConnId", queryHandlers.GetDataModels)
 				dataModelGroup.GET("/single/:dbConnId", queryHandlers.GetSingleDataModel)
+				dataModelGroup.GET("/single/:dbConnId/ddl", queryHandlers.GetSingleDataModelDDL)
+				dataModelGroup.GET("/graph/:dbConnId", queryHandlers.GetDBGraph)
+				dataModelGroup.GET("/diff", queryHandlers.DiffDBSchemas)
+				dataModelGroup.POST("/create", queryHandlers.CreateDataModel)
+				dataModelGroup.POST("/single/rename", queryHandlers.RenameDataModel)
+				dataModelGroup.POST("/single/truncate", queryHandlers.TruncateDataModel)
+				dataModelGroup.POST("/single/drop", queryHandlers.DropDataModel)
 				dataModelGroup.POST("/single/addfield", queryHandlers.AddSingleDataModelField)
 				dataModelGroup.POST("/single/deletefield", queryHandlers.DeleteSingleDataModelField)
+				dataModelGroup.POST("/single/alterfield", queryHandlers.AlterSingleDataModelField)
+				dataModelGroup.POST("/single/addindex", queryHandlers.CreateIndex)
+				dataModelGroup.POST("/single/deleteindex", queryHandlers.DropIndex)
+			}
+			dbObjectGroup := queryGroup.Group("dbobject")
+			{
+				dbObjectGroup.GET("/all/:dbConnId", queryHandlers.GetDBObjects)
+				dbObjectGroup.GET("/single/:dbConnId", queryHandlers.GetSingleDBObject)
+			}
+			activityGroup := queryGroup.Group("activity")
+			{
+				activityGroup.GET("/:dbConnId", queryHandlers.GetDBSessions)
+				activityGroup.GET("/:dbConnId/locks", queryHandlers.GetDBLocks)
+				activityGroup.POST("/:dbConnId/cancel", queryHandlers.CancelDBSession)
 			}
 		}
 		settingGroup := api.Group("setting")
@@ -19,6 +19,7 @@ type RolePermissionView struct {
 	RoleID    string    `json:"roleId"`
 	Name      string    `json:"name"`
 	Value     bool      `json:"value"`
+	IntValue  int       `json:"intValue"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -46,6 +47,7 @@ func BuildRolePermission(rp *models.RolePermission) RolePermissionView {
 		RoleID:    rp.RoleID,
 		Name:      rp.Name,
 		Value:     rp.Value,
+		IntValue:  rp.IntValue,
 		CreatedAt: rp.CreatedAt,
 		UpdatedAt: rp.UpdatedAt,
 	}
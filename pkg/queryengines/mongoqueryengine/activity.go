@@ -0,0 +1,27 @@
+package mongoqueryengine
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/mongoqueryengine/mongoutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// GetDBLocks returns the operations which block or are blocked by other operations with their locks from
+// currentOp, including the idle sessions holding the locks of a transaction, and the trees of their blocking chains.
+func (mqe *MongoQueryEngine) GetDBLocks(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (*qemodels.DBLocks, error) {
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+	var currentOp bson.M
+	err = db.Client().Database("admin").RunCommand(ctx, bson.D{{Key: "currentOp", Value: 1}, {Key: "$all", Value: true}}).Decode(&currentOp)
+	if err != nil {
+		return nil, err
+	}
+	ops, _ := currentOp["inprog"].(bson.A)
+	return qemodels.NewDBLocks(mongoutils.CurrentOpToLockedSessions(ops)), nil
+}
@@ -0,0 +1,96 @@
+package mongoqueryengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// mongoRunningQuery is a query started with a query id, it runs in its own session
+// so that its operations can be found by the session id and killed.
+type mongoRunningQuery struct {
+	dbConnectionId string
+	client         *mongo.Client
+	sessionID      interface{}
+}
+
+func (mqe *MongoQueryEngine) runCancellableQuery(dbConn *models.DBConnection, db *mongo.Database, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	ctx, removeRunningQuery, err := mqe.addRunningQuery(config.GetContext(), dbConn, db, config.QueryID)
+	if err != nil {
+		return nil, err
+	}
+	defer removeRunningQuery()
+	return runQuery(ctx, db, query, config)
+}
+
+// addRunningQuery starts the session of the query and registers it, the returned context runs
+// the operations in the session and the returned function ends it.
+func (mqe *MongoQueryEngine) addRunningQuery(ctx context.Context, dbConn *models.DBConnection, db *mongo.Database, queryID string) (context.Context, func(), error) {
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	mqe.runningQueriesMutex.Lock()
+	if _, exists := mqe.runningQueries[queryID]; exists {
+		mqe.runningQueriesMutex.Unlock()
+		session.EndSession(context.Background())
+		return nil, nil, errors.New("query id is already in use")
+	}
+	mqe.runningQueries[queryID] = &mongoRunningQuery{
+		dbConnectionId: dbConn.ID,
+		client:         db.Client(),
+		sessionID:      session.ID().Lookup("id"),
+	}
+	mqe.runningQueriesMutex.Unlock()
+	return mongo.NewSessionContext(ctx, session), func() {
+		mqe.runningQueriesMutex.Lock()
+		delete(mqe.runningQueries, queryID)
+		mqe.runningQueriesMutex.Unlock()
+		session.EndSession(context.Background())
+	}, nil
+}
+
+// CancelQuery kills the operations of the running query with killOp.
+func (mqe *MongoQueryEngine) CancelQuery(dbConn *models.DBConnection, queryID string, config *queryconfig.QueryConfig) error {
+	mqe.runningQueriesMutex.Lock()
+	running, exists := mqe.runningQueries[queryID]
+	mqe.runningQueriesMutex.Unlock()
+	if !exists || running.dbConnectionId != dbConn.ID {
+		return errors.New("query not found, it may have already finished")
+	}
+	ctx := config.GetContext()
+	adminDB := running.client.Database("admin")
+	var result struct {
+		InProg []struct {
+			OpID interface{} `bson:"opid"`
+		} `bson:"inprog"`
+	}
+	err := adminDB.RunCommand(ctx, bson.D{
+		{Key: "currentOp", Value: true},
+		{Key: "lsid.id", Value: running.sessionID},
+	}).Decode(&result)
+	if err != nil {
+		return err
+	}
+	if len(result.InProg) == 0 {
+		return errors.New("query not found, it may have already finished")
+	}
+	for _, op := range result.InProg {
+		err := adminDB.RunCommand(ctx, bson.D{
+			{Key: "killOp", Value: 1},
+			{Key: "op", Value: op.OpID},
+		}).Err()
+		if err != nil {
+			return err
+		}
+		if config.CreateLogFn != nil {
+			config.CreateLogFn(fmt.Sprintf("db.killOp(%v)", op.OpID))
+		}
+	}
+	return nil
+}
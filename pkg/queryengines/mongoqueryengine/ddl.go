@@ -0,0 +1,50 @@
+package mongoqueryengine
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/mongoqueryengine/mongoutils"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// GetSingleDataModelDDL returns the shell commands creating the collection with its options, like the validator,
+// and its indexes. The index specs are read with the driver as the key order of compound indexes matters.
+func (mqe *MongoQueryEngine) GetSingleDataModelDDL(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (string, error) {
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+	cursor, err := db.ListCollections(ctx, bson.D{{Key: "name", Value: name}})
+	if err != nil {
+		return "", err
+	}
+	collections := []bson.D{}
+	if err := cursor.All(ctx, &collections); err != nil {
+		return "", err
+	}
+	if len(collections) == 0 {
+		return "", errors.New("data model not found")
+	}
+	var collectionOptions bson.D
+	for _, e := range collections[0] {
+		if e.Key == "options" {
+			collectionOptions, _ = e.Value.(bson.D)
+		}
+	}
+	indexes := []bson.D{}
+	// views do not have indexes
+	if collections[0].Map()["type"] != "view" {
+		cursor, err = db.Collection(name).Indexes().List(ctx)
+		if err != nil {
+			return "", err
+		}
+		if err := cursor.All(ctx, &indexes); err != nil {
+			return "", err
+		}
+	}
+	return mongoutils.CreateCollectionScript(name, collectionOptions, indexes)
+}
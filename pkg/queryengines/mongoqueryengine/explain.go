@@ -0,0 +1,73 @@
+package mongoqueryengine
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/mongoqueryengine/mongoutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// ExplainQuery gets the plan of a find or aggregate query with the explain command,
+// with executionStats verbosity when analyze is true.
+func (mqe *MongoQueryEngine) ExplainQuery(dbConn *models.DBConnection, query string, analyze bool, config *queryconfig.QueryConfig) (*qemodels.QueryPlan, error) {
+	queryType := mongoutils.GetMongoQueryType(query)
+	var arg interface{}
+	if len(queryType.Args) > 0 {
+		arg = queryType.Args[0]
+	}
+	var command bson.D
+	switch queryType.QueryType {
+	case mongoutils.QUERY_FIND:
+		filter := arg
+		if filter == nil {
+			filter = bson.D{}
+		}
+		command = bson.D{{Key: "find", Value: queryType.CollectionName}, {Key: "filter", Value: filter}}
+		if queryType.Sort != nil {
+			command = append(command, bson.E{Key: "sort", Value: queryType.Sort})
+		}
+		if queryType.Skip != nil {
+			command = append(command, bson.E{Key: "skip", Value: *queryType.Skip})
+		}
+		if queryType.Limit != nil {
+			command = append(command, bson.E{Key: "limit", Value: *queryType.Limit})
+		}
+	case mongoutils.QUERY_AGGREGATE:
+		pipeline := arg
+		if pipeline == nil {
+			pipeline = bson.A{}
+		}
+		if analyze && config.ReadOnly && mongoutils.IsPipelineWrite(pipeline) {
+			return nil, errors.New("not allowed to analyze this query")
+		}
+		command = bson.D{{Key: "aggregate", Value: queryType.CollectionName}, {Key: "pipeline", Value: pipeline}, {Key: "cursor", Value: bson.D{}}}
+	default:
+		return nil, errors.New("only find and aggregate queries can be explained")
+	}
+
+	if config.MaxExecutionTime > 0 {
+		command = append(command, bson.E{Key: "maxTimeMS", Value: config.MaxExecutionTime.Milliseconds()})
+	}
+
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	verbosity := "queryPlanner"
+	if analyze {
+		verbosity = "executionStats"
+	}
+	var explain bson.M
+	err = db.RunCommand(config.GetContext(), bson.D{{Key: "explain", Value: command}, {Key: "verbosity", Value: verbosity}}).Decode(&explain)
+	if err != nil {
+		return nil, err
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(fmt.Sprintf(`%s.explain("%s")`, query, verbosity))
+	}
+	return mongoutils.ExplainToPlan(explain, analyze), nil
+}
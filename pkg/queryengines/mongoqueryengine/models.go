@@ -0,0 +1,31 @@
+package mongoqueryengine
+
+import (
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+func buildDBDataModel(collectionData map[string]interface{}) *qemodels.DBDataModel {
+	view := qemodels.DBDataModel{
+		Name: collectionData["collectionName"].(string),
+		Kind: qemodels.DATA_MODEL_KIND_COLLECTION,
+	}
+	return &view
+}
+
+func buildDBDataModelField(fieldData map[string]interface{}) *qemodels.DBDataModelField {
+	view := qemodels.DBDataModelField{
+		Name:       fieldData["name"].(string),
+		Type:       fieldData["types"].(string),
+		IsNullable: fieldData["isNullable"].(bool),
+		IsPrimary:  fieldData["isPrimary"].(bool),
+	}
+	return &view
+}
+
+func buildDBDataModelIndex(indexData map[string]interface{}) *qemodels.DBDataModelIndex {
+	view := qemodels.DBDataModelIndex{
+		Name:     indexData["name"].(string),
+		IndexDef: indexData["key"].(string),
+	}
+	return &view
+}
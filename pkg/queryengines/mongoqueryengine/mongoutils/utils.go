@@ -3,7 +3,9 @@ package mongoutils
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -14,25 +16,49 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"gopkg.in/yaml.v2"
 	"slashbase.com/backend/internal/utils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
 )
 
-func MongoCursorToJson(cur *mongo.Cursor) ([]string, []map[string]interface{}) {
+// MongoCursorToJson reads the documents of the cursor as json, stopping after maxRows documents (0 for no limit).
+// truncated is true when there were more documents.
+func MongoCursorToJson(ctx context.Context, cur *mongo.Cursor, maxRows int) ([]string, []map[string]interface{}, bool, error) {
 	keysMap := map[string]bool{}
 	resultData := make([]map[string]interface{}, 0)
-	for cur.Next(context.Background()) {
-		var rowData bson.D
-		err := cur.Decode(&rowData)
-		if err != nil {
-			log.Fatal(err)
+	truncated, err := MongoCursorEach(ctx, cur, maxRows, func(rowDataMap map[string]interface{}) error {
+		for key := range rowDataMap {
+			keysMap[key] = true
 		}
-		rowDataMap := bsonDtoJsonMap(&rowData, &keysMap)
 		resultData = append(resultData, rowDataMap)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, false, err
 	}
 	keysList := []string{}
 	for key := range keysMap {
 		keysList = append(keysList, key)
 	}
-	return keysList, resultData
+	return keysList, resultData, truncated, nil
+}
+
+// MongoCursorEach calls fn with each document of the cursor as json, stopping after maxRows documents
+// (0 for no limit) or when fn returns an error. truncated is true when there were more documents.
+func MongoCursorEach(ctx context.Context, cur *mongo.Cursor, maxRows int, fn func(map[string]interface{}) error) (bool, error) {
+	rowCount := 0
+	for cur.Next(ctx) {
+		if maxRows > 0 && rowCount == maxRows {
+			return true, nil
+		}
+		var rowData bson.D
+		if err := cur.Decode(&rowData); err != nil {
+			return false, err
+		}
+		if err := fn(bsonDtoJsonMap(&rowData, nil)); err != nil {
+			return false, err
+		}
+		rowCount++
+	}
+	return false, cur.Err()
 }
 
 func MongoSingleResultToJson(result *mongo.SingleResult) ([]string, []map[string]interface{}) {
@@ -411,3 +437,512 @@ func GetCollectionIndexes(indexesData []map[string]interface{}) []map[string]int
 	}
 	return indexes
 }
+
+// FilterToBson compiles the filter tree to a bson filter.
+func FilterToBson(filter *qemodels.Filter) bson.D {
+	if filter.IsGroup() {
+		conditions := bson.A{}
+		for _, condition := range filter.Conditions {
+			conditions = append(conditions, FilterToBson(condition))
+		}
+		if filter.Group == qemodels.FILTER_GROUP_OR {
+			return bson.D{{Key: "$or", Value: conditions}}
+		}
+		return bson.D{{Key: "$and", Value: conditions}}
+	}
+	value := filterValue(filter.Field, filter.Value)
+	var condition interface{}
+	switch filter.Operator {
+	case qemodels.FILTER_OP_EQUAL:
+		condition = value
+	case qemodels.FILTER_OP_NOT_EQUAL:
+		condition = bson.D{{Key: "$ne", Value: value}}
+	case qemodels.FILTER_OP_LESS:
+		condition = bson.D{{Key: "$lt", Value: value}}
+	case qemodels.FILTER_OP_GREATER:
+		condition = bson.D{{Key: "$gt", Value: value}}
+	case qemodels.FILTER_OP_LESS_EQUAL:
+		condition = bson.D{{Key: "$lte", Value: value}}
+	case qemodels.FILTER_OP_GREATER_EQUAL:
+		condition = bson.D{{Key: "$gte", Value: value}}
+	case qemodels.FILTER_OP_IN, qemodels.FILTER_OP_NOT_IN:
+		values := bson.A{}
+		for _, item := range filter.Values {
+			values = append(values, filterValue(filter.Field, item))
+		}
+		operator := "$in"
+		if filter.Operator == qemodels.FILTER_OP_NOT_IN {
+			operator = "$nin"
+		}
+		condition = bson.D{{Key: operator, Value: values}}
+	case qemodels.FILTER_OP_BETWEEN:
+		condition = bson.D{
+			{Key: "$gte", Value: filterValue(filter.Field, filter.Values[0])},
+			{Key: "$lte", Value: filterValue(filter.Field, filter.Values[1])},
+		}
+	case qemodels.FILTER_OP_LIKE:
+		condition = primitive.Regex{Pattern: qemodels.LikeToRegex(filter.Value.(string))}
+	case qemodels.FILTER_OP_NOT_LIKE:
+		condition = bson.D{{Key: "$not", Value: primitive.Regex{Pattern: qemodels.LikeToRegex(filter.Value.(string))}}}
+	case qemodels.FILTER_OP_ILIKE:
+		condition = primitive.Regex{Pattern: qemodels.LikeToRegex(filter.Value.(string)), Options: "i"}
+	case qemodels.FILTER_OP_NOT_ILIKE:
+		condition = bson.D{{Key: "$not", Value: primitive.Regex{Pattern: qemodels.LikeToRegex(filter.Value.(string)), Options: "i"}}}
+	case qemodels.FILTER_OP_REGEX:
+		condition = primitive.Regex{Pattern: filter.Value.(string)}
+	case qemodels.FILTER_OP_IS_NULL:
+		condition = nil
+	case qemodels.FILTER_OP_IS_NOT_NULL:
+		condition = bson.D{{Key: "$ne", Value: nil}}
+	}
+	return bson.D{{Key: filter.Field, Value: condition}}
+}
+
+// filterValue converts the hex string values of _id to ObjectId.
+func filterValue(field string, value interface{}) interface{} {
+	if str, ok := value.(string); ok && field == "_id" {
+		if objectID, err := primitive.ObjectIDFromHex(str); err == nil {
+			return objectID
+		}
+	}
+	return value
+}
+
+// SortToBson converts the sort fields to a bson sort document.
+func SortToBson(sort []qemodels.SortField) bson.D {
+	sortData := bson.D{}
+	for _, field := range sort {
+		direction := 1
+		if field.Direction == "DESC" {
+			direction = -1
+		}
+		sortData = append(sortData, bson.E{Key: field.Field, Value: direction})
+	}
+	return sortData
+}
+
+// KeysetToBson builds the filter selecting the documents after the cursor values of the keys,
+// null and missing values are sorted before any other value by mongo.
+func KeysetToBson(keys []qemodels.SortField, values bson.D) bson.D {
+	terms := bson.A{}
+	equalConditions := bson.D{}
+	for i, key := range keys {
+		value := values[i].Value
+		var after interface{}
+		switch {
+		case value == nil && key.Direction == "DESC":
+			// nothing is after null in descending order
+		case value == nil:
+			after = bson.D{{Key: "$ne", Value: nil}}
+		case key.Direction == "DESC":
+			after = bson.D{{Key: "$lt", Value: value}}
+		default:
+			after = bson.D{{Key: "$gt", Value: value}}
+		}
+		if after != nil {
+			term := append(append(bson.D{}, equalConditions...), bson.E{Key: key.Field, Value: after})
+			if value != nil && key.Direction == "DESC" {
+				// null values are after any other value in descending order
+				term = bson.D{{Key: "$and", Value: bson.A{
+					append(bson.D{}, equalConditions...),
+					bson.D{{Key: "$or", Value: bson.A{
+						bson.D{{Key: key.Field, Value: after}},
+						bson.D{{Key: key.Field, Value: nil}},
+					}}},
+				}}}
+			}
+			terms = append(terms, term)
+		}
+		equalConditions = append(equalConditions, bson.E{Key: key.Field, Value: value})
+	}
+	return bson.D{{Key: "$or", Value: terms}}
+}
+
+// DocumentFieldValue returns the value of the field of the json document, field can be a dotted path.
+func DocumentFieldValue(document map[string]interface{}, field string) interface{} {
+	var value interface{} = document
+	for _, key := range strings.Split(field, ".") {
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = valueMap[key]
+	}
+	return value
+}
+
+// IsPipelineWrite reports if the aggregation pipeline writes its result with $out or $merge.
+func IsPipelineWrite(pipeline interface{}) bool {
+	stages, _ := pipeline.(bson.A)
+	for _, stage := range stages {
+		if stageDoc, ok := stage.(bson.D); ok && len(stageDoc) > 0 && (stageDoc[0].Key == "$out" || stageDoc[0].Key == "$merge") {
+			return true
+		}
+	}
+	return false
+}
+
+// ExplainToPlan normalises the output of the explain command to a plan tree. The executionStats
+// stages are used when the query was analyzed, aggregate stages which do not run in the query layer
+// are chained on top of the $cursor stage.
+func ExplainToPlan(explain bson.M, analyze bool) *qemodels.QueryPlan {
+	plan := &qemodels.QueryPlan{
+		Analyzed: analyze,
+		Raw:      explain,
+	}
+	if stages, ok := explain["stages"].(bson.A); ok {
+		for _, stage := range stages {
+			stageDoc := toBsonM(stage)
+			var node *qemodels.PlanNode
+			for name, value := range stageDoc {
+				if name == "$cursor" {
+					node = queryLayerPlanNode(toBsonM(value), analyze)
+				} else if strings.HasPrefix(name, "$") {
+					node = &qemodels.PlanNode{
+						NodeType:     name,
+						ActualRows:   explainNumber(stageDoc["nReturned"]),
+						ActualTimeMs: explainNumber(stageDoc["executionTimeMillisEstimate"]),
+						Details:      map[string]interface{}{"spec": value},
+						Children:     []*qemodels.PlanNode{},
+					}
+				}
+			}
+			if node == nil {
+				continue
+			}
+			if plan.Plan != nil {
+				node.Children = append(node.Children, plan.Plan)
+			}
+			plan.Plan = node
+		}
+		return plan
+	}
+	plan.Plan = queryLayerPlanNode(explain, analyze)
+	if stats := toBsonM(explain["executionStats"]); analyze && stats != nil {
+		plan.ExecutionTimeMs = explainNumber(stats["executionTimeMillis"])
+	}
+	return plan
+}
+
+// queryLayerPlanNode returns the plan tree of the queryPlanner or executionStats section of the explain output.
+func queryLayerPlanNode(explain bson.M, analyze bool) *qemodels.PlanNode {
+	namespace, _ := toBsonM(explain["queryPlanner"])["namespace"].(string)
+	if stats := toBsonM(explain["executionStats"]); analyze && stats != nil {
+		if stages := toBsonM(stats["executionStages"]); stages != nil {
+			return explainStageNode(stages, namespace)
+		}
+	}
+	winningPlan := toBsonM(toBsonM(explain["queryPlanner"])["winningPlan"])
+	if queryPlan := toBsonM(winningPlan["queryPlan"]); queryPlan != nil {
+		// plans of the slot based execution engine
+		winningPlan = queryPlan
+	}
+	return explainStageNode(winningPlan, namespace)
+}
+
+var explainStageKeys = []string{"stage", "indexName", "nReturned", "executionTimeMillisEstimate", "inputStage", "inputStages"}
+
+func explainStageNode(stage bson.M, namespace string) *qemodels.PlanNode {
+	node := &qemodels.PlanNode{
+		Details:  map[string]interface{}{},
+		Children: []*qemodels.PlanNode{},
+	}
+	node.NodeType, _ = stage["stage"].(string)
+	node.Index, _ = stage["indexName"].(string)
+	if strings.HasSuffix(node.NodeType, "SCAN") || node.NodeType == "IDHACK" {
+		node.Relation = namespace
+	}
+	node.ActualRows = explainNumber(stage["nReturned"])
+	node.ActualTimeMs = explainNumber(stage["executionTimeMillisEstimate"])
+	if inputStage := toBsonM(stage["inputStage"]); inputStage != nil {
+		node.Children = append(node.Children, explainStageNode(inputStage, namespace))
+	}
+	if inputStages, ok := stage["inputStages"].(bson.A); ok {
+		for _, inputStage := range inputStages {
+			node.Children = append(node.Children, explainStageNode(toBsonM(inputStage), namespace))
+		}
+	}
+	for key, value := range stage {
+		if !utils.ContainsString(explainStageKeys, key) {
+			node.Details[key] = value
+		}
+	}
+	return node
+}
+
+func toBsonM(value interface{}) bson.M {
+	switch doc := value.(type) {
+	case bson.M:
+		return doc
+	case bson.D:
+		return doc.Map()
+	}
+	return nil
+}
+
+func explainNumber(value interface{}) *float64 {
+	var number float64
+	switch n := value.(type) {
+	case int32:
+		number = float64(n)
+	case int64:
+		number = float64(n)
+	case float64:
+		number = n
+	default:
+		return nil
+	}
+	return &number
+}
+
+// CreateCollectionScript returns the shell commands creating the collection with its options and indexes,
+// the _id index is created with the collection.
+func CreateCollectionScript(name string, collectionOptions bson.D, indexes []bson.D) (string, error) {
+	collectionName, err := json.Marshal(name)
+	if err != nil {
+		return "", err
+	}
+	script := fmt.Sprintf("db.createCollection(%s", collectionName)
+	if len(collectionOptions) > 0 {
+		optionsJson, err := bson.MarshalExtJSON(collectionOptions, false, false)
+		if err != nil {
+			return "", err
+		}
+		script += ", " + string(optionsJson)
+	}
+	script += ");\n"
+	for _, index := range indexes {
+		isIDIndex := false
+		for _, e := range index {
+			isIDIndex = isIDIndex || (e.Key == "name" && e.Value == "_id_")
+		}
+		if isIDIndex {
+			continue
+		}
+		command, err := CreateIndexCommand(name, index)
+		if err != nil {
+			return "", err
+		}
+		script += command + ";\n"
+	}
+	return script, nil
+}
+
+// CreateIndexCommand returns the shell command creating the index on the collection from its document
+// as returned by listIndexes.
+func CreateIndexCommand(name string, index bson.D) (string, error) {
+	collectionName, err := json.Marshal(name)
+	if err != nil {
+		return "", err
+	}
+	key := bson.D{}
+	indexOptions := bson.D{}
+	for _, e := range index {
+		switch e.Key {
+		case "key":
+			key, _ = e.Value.(bson.D)
+		case "v", "ns":
+		default:
+			indexOptions = append(indexOptions, e)
+		}
+	}
+	keyJson, err := bson.MarshalExtJSON(key, false, false)
+	if err != nil {
+		return "", err
+	}
+	optionsJson, err := bson.MarshalExtJSON(indexOptions, false, false)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("db.getCollection(%s).createIndex(%s, %s)", collectionName, keyJson, optionsJson), nil
+}
+
+// IndexDocument returns the document of the index for the createIndexes command. Without a name
+// the index is named like the shell does, from its fields and their directions or types.
+func IndexDocument(index *qemodels.IndexSpec) (bson.D, error) {
+	if len(index.Fields) == 0 {
+		return nil, errors.New("index must have fields")
+	}
+	key := bson.D{}
+	nameParts := []string{}
+	for _, field := range index.Fields {
+		var value interface{} = int32(1)
+		if field.Type != "" {
+			value = field.Type
+		} else if field.Descending {
+			value = int32(-1)
+		}
+		key = append(key, bson.E{Key: field.Name, Value: value})
+		nameParts = append(nameParts, fmt.Sprintf("%s_%v", field.Name, value))
+	}
+	name := index.Name
+	if name == "" {
+		name = strings.Join(nameParts, "_")
+	}
+	document := bson.D{{Key: "key", Value: key}, {Key: "name", Value: name}}
+	if index.Unique {
+		document = append(document, bson.E{Key: "unique", Value: true})
+	}
+	if index.ExpireAfterSeconds != nil {
+		document = append(document, bson.E{Key: "expireAfterSeconds", Value: *index.ExpireAfterSeconds})
+	}
+	if index.Where != "" {
+		var partialFilter bson.D
+		if err := bson.UnmarshalExtJSON([]byte(index.Where), false, &partialFilter); err != nil {
+			return nil, errors.New("invalid partial filter: " + err.Error())
+		}
+		document = append(document, bson.E{Key: "partialFilterExpression", Value: partialFilter})
+	}
+	return document, nil
+}
+
+// CollectionValidator returns the $jsonSchema validator of the fields of the data model, nil if it has none.
+// The types of the fields are bson types, like string or date, a field without type can have any type.
+// Nullable fields can be null or missing.
+func CollectionValidator(spec *qemodels.DataModelSpec) bson.D {
+	if len(spec.Fields) == 0 {
+		return nil
+	}
+	required := bson.A{}
+	properties := bson.D{}
+	for _, field := range spec.Fields {
+		if !field.IsNullable {
+			required = append(required, field.Name)
+		}
+		if field.Type == "" {
+			continue
+		}
+		var bsonType interface{} = field.Type
+		if field.IsNullable {
+			bsonType = bson.A{field.Type, "null"}
+		}
+		properties = append(properties, bson.E{Key: field.Name, Value: bson.D{{Key: "bsonType", Value: bsonType}}})
+	}
+	jsonSchema := bson.D{{Key: "bsonType", Value: "object"}}
+	if len(required) > 0 {
+		jsonSchema = append(jsonSchema, bson.E{Key: "required", Value: required})
+	}
+	jsonSchema = append(jsonSchema, bson.E{Key: "properties", Value: properties})
+	return bson.D{{Key: "$jsonSchema", Value: jsonSchema}}
+}
+
+// mongoLockModesConflict reports if the lock modes of currentOp conflict, r and w are the intent shared and
+// intent exclusive modes, R and W the shared and exclusive modes.
+func mongoLockModesConflict(mode, otherMode string) bool {
+	if mode == "W" || otherMode == "W" {
+		return true
+	}
+	return (mode == "R" && otherMode == "w") || (mode == "w" && otherMode == "R")
+}
+
+// CurrentOpToLockedSessions returns the operations of the inprog array of currentOp which block or are blocked
+// by other operations, with the opid as pid. currentOp does not tell which operations hold the locks an
+// operation waits for, an operation waiting for a lock is blocked by the operations which are not waiting and
+// hold a conflicting lock on the same resource. Operations identified by strings, like the operations of the
+// shards from mongos, are skipped. The locks of a waiting operation which conflict are the ones not granted.
+func CurrentOpToLockedSessions(ops bson.A) []*qemodels.DBLockedSession {
+	sessions := []*qemodels.DBLockedSession{}
+	waiting := map[int32]bool{}
+	// resources are the lock types and namespaces of the locks, with the modes of the operations by opid
+	resources := map[string]map[int32]string{}
+	for _, op := range ops {
+		opDoc := toBsonM(op)
+		var opid int32
+		switch id := opDoc["opid"].(type) {
+		case int32:
+			opid = id
+		case int64:
+			opid = int32(id)
+		default:
+			continue
+		}
+		locks := toBsonM(opDoc["locks"])
+		if len(locks) == 0 {
+			continue
+		}
+		namespace, _ := opDoc["ns"].(string)
+		databaseName, _, _ := strings.Cut(namespace, ".")
+		waitingForLock, _ := opDoc["waitingForLock"].(bool)
+		session := &qemodels.DBLockedSession{
+			DBSession: qemodels.DBSession{
+				PID:          opid,
+				DatabaseName: databaseName,
+				State:        "idle",
+			},
+			Locks:     []qemodels.DBLock{},
+			BlockedBy: []int32{},
+		}
+		if active, _ := opDoc["active"].(bool); active {
+			session.State = "active"
+		}
+		if waitingForLock {
+			session.WaitEventType = "Lock"
+		}
+		session.ApplicationName, _ = opDoc["appName"].(string)
+		session.ClientAddress, _ = opDoc["client"].(string)
+		if users, ok := opDoc["effectiveUsers"].(bson.A); ok && len(users) > 0 {
+			session.User, _ = toBsonM(users[0])["user"].(string)
+		}
+		if command, ok := opDoc["command"]; ok {
+			if commandJson, err := bson.MarshalExtJSON(command, false, false); err == nil {
+				session.Query = string(commandJson)
+			}
+		}
+		if duration := explainNumber(opDoc["microsecs_running"]); duration != nil {
+			durationMs := int64(*duration / 1000)
+			session.Duration = &durationMs
+		}
+		lockTypes := []string{}
+		for lockType := range locks {
+			lockTypes = append(lockTypes, lockType)
+		}
+		sort.Strings(lockTypes)
+		for _, lockType := range lockTypes {
+			mode, _ := locks[lockType].(string)
+			lock := qemodels.DBLock{LockType: lockType, Mode: mode, Granted: true}
+			switch lockType {
+			case "Database":
+				lock.Relation = databaseName
+			case "Collection":
+				lock.Relation = namespace
+			}
+			session.Locks = append(session.Locks, lock)
+			resource := lockType + "\x00" + lock.Relation
+			if resources[resource] == nil {
+				resources[resource] = map[int32]string{}
+			}
+			resources[resource][opid] = mode
+		}
+		waiting[opid] = waitingForLock
+		sessions = append(sessions, session)
+	}
+
+	blocking := map[int32]bool{}
+	for _, session := range sessions {
+		if !waiting[session.PID] {
+			continue
+		}
+		blockedBy := map[int32]bool{}
+		for i, lock := range session.Locks {
+			for opid, mode := range resources[lock.LockType+"\x00"+lock.Relation] {
+				if opid == session.PID || waiting[opid] || !mongoLockModesConflict(lock.Mode, mode) {
+					continue
+				}
+				session.Locks[i].Granted = false
+				if !blockedBy[opid] {
+					blockedBy[opid] = true
+					session.BlockedBy = append(session.BlockedBy, opid)
+					blocking[opid] = true
+				}
+			}
+		}
+		sort.Slice(session.BlockedBy, func(i, j int) bool { return session.BlockedBy[i] < session.BlockedBy[j] })
+	}
+	lockedSessions := []*qemodels.DBLockedSession{}
+	for _, session := range sessions {
+		if len(session.BlockedBy) > 0 || blocking[session.PID] {
+			lockedSessions = append(lockedSessions, session)
+		}
+	}
+	return lockedSessions
+}
@@ -0,0 +1,377 @@
+package mongoutils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+// extJson returns the relaxed extended json of the document, to compare documents in the tests.
+func extJson(t *testing.T, document interface{}) string {
+	data, err := bson.MarshalExtJSON(document, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFilterToBson(t *testing.T) {
+	tests := []struct {
+		filter   *qemodels.Filter
+		expected string
+	}{
+		{
+			&qemodels.Filter{Field: "name", Operator: qemodels.FILTER_OP_EQUAL, Value: "alice"},
+			`{"name":"alice"}`,
+		},
+		{
+			&qemodels.Filter{Field: "age", Operator: qemodels.FILTER_OP_GREATER_EQUAL, Value: 18.0},
+			`{"age":{"$gte":18.0}}`,
+		},
+		{
+			&qemodels.Filter{Field: "_id", Operator: qemodels.FILTER_OP_NOT_EQUAL, Value: "5f1d7a8b9c0d1e2f3a4b5c6d"},
+			`{"_id":{"$ne":{"$oid":"5f1d7a8b9c0d1e2f3a4b5c6d"}}}`,
+		},
+		{
+			&qemodels.Filter{Field: "_id", Operator: qemodels.FILTER_OP_EQUAL, Value: "custom-id"},
+			`{"_id":"custom-id"}`,
+		},
+		{
+			&qemodels.Filter{Field: "status", Operator: qemodels.FILTER_OP_NOT_IN, Values: []interface{}{"a", "b"}},
+			`{"status":{"$nin":["a","b"]}}`,
+		},
+		{
+			&qemodels.Filter{Field: "age", Operator: qemodels.FILTER_OP_BETWEEN, Values: []interface{}{1.0, 5.0}},
+			`{"age":{"$gte":1.0,"$lte":5.0}}`,
+		},
+		{
+			&qemodels.Filter{Field: "email", Operator: qemodels.FILTER_OP_ILIKE, Value: "%@example.com"},
+			`{"email":{"$regularExpression":{"pattern":"^.*@example\\.com$","options":"i"}}}`,
+		},
+		{
+			&qemodels.Filter{Field: "email", Operator: qemodels.FILTER_OP_NOT_LIKE, Value: "a_%"},
+			`{"email":{"$not":{"$regularExpression":{"pattern":"^a..*$","options":""}}}}`,
+		},
+		{
+			&qemodels.Filter{Field: "deleted_at", Operator: qemodels.FILTER_OP_IS_NULL},
+			`{"deleted_at":null}`,
+		},
+		{
+			&qemodels.Filter{Field: "deleted_at", Operator: qemodels.FILTER_OP_IS_NOT_NULL},
+			`{"deleted_at":{"$ne":null}}`,
+		},
+		{
+			&qemodels.Filter{Group: qemodels.FILTER_GROUP_OR, Conditions: []*qemodels.Filter{
+				{Field: "name", Operator: qemodels.FILTER_OP_EQUAL, Value: "alice"},
+				{Group: qemodels.FILTER_GROUP_AND, Conditions: []*qemodels.Filter{
+					{Field: "age", Operator: qemodels.FILTER_OP_LESS, Value: 30.0},
+					{Field: "name", Operator: qemodels.FILTER_OP_REGEX, Value: "^b"},
+				}},
+			}},
+			`{"$or":[{"name":"alice"},{"$and":[{"age":{"$lt":30.0}},{"name":{"$regularExpression":{"pattern":"^b","options":""}}}]}]}`,
+		},
+	}
+	for _, test := range tests {
+		if bsonFilter := extJson(t, FilterToBson(test.filter)); bsonFilter != test.expected {
+			t.Errorf("filter: %s, expected %s", bsonFilter, test.expected)
+		}
+	}
+}
+
+func TestSortToBson(t *testing.T) {
+	tests := []struct {
+		sort     []qemodels.SortField
+		expected string
+	}{
+		{[]qemodels.SortField{}, `{}`},
+		{[]qemodels.SortField{{Field: "age", Direction: "DESC"}, {Field: "name", Direction: "ASC"}}, `{"age":-1,"name":1}`},
+		{[]qemodels.SortField{{Field: "name"}}, `{"name":1}`},
+	}
+	for _, test := range tests {
+		if sort := extJson(t, SortToBson(test.sort)); sort != test.expected {
+			t.Errorf("sort: %s, expected %s", sort, test.expected)
+		}
+	}
+}
+
+func TestKeysetToBson(t *testing.T) {
+	tests := []struct {
+		sort     []qemodels.SortField
+		values   []interface{}
+		expected string
+	}{
+		{
+			// ties on the age are broken by _id
+			[]qemodels.SortField{{Field: "age", Direction: "ASC"}},
+			[]interface{}{30, "b"},
+			`{"$or":[{"age":{"$gt":30}},{"age":30,"_id":{"$gt":"b"}}]}`,
+		},
+		{
+			// null values are after the others in descending order
+			[]qemodels.SortField{{Field: "age", Direction: "DESC"}},
+			[]interface{}{30, "b"},
+			`{"$or":[{"$and":[{},{"$or":[{"age":{"$lt":30}},{"age":null}]}]},{"age":30,"_id":{"$gt":"b"}}]}`,
+		},
+		{
+			// a null value is before the others in ascending order
+			[]qemodels.SortField{{Field: "age", Direction: "ASC"}},
+			[]interface{}{nil, "b"},
+			`{"$or":[{"age":{"$ne":null}},{"age":null,"_id":{"$gt":"b"}}]}`,
+		},
+		{
+			// nothing is after a null value in descending order but the ties
+			[]qemodels.SortField{{Field: "age", Direction: "DESC"}},
+			[]interface{}{nil, "b"},
+			`{"$or":[{"age":null,"_id":{"$gt":"b"}}]}`,
+		},
+		{
+			// sorting on _id needs no tie break
+			[]qemodels.SortField{{Field: "_id", Direction: "DESC"}},
+			[]interface{}{"b"},
+			`{"$or":[{"$and":[{},{"$or":[{"_id":{"$lt":"b"}},{"_id":null}]}]}]}`,
+		},
+	}
+	for _, test := range tests {
+		keys := qemodels.KeysetFields(test.sort, []string{"_id"})
+		if len(keys) != len(test.values) {
+			t.Fatalf("keys: %v", keys)
+		}
+		values := bson.D{}
+		for i, key := range keys {
+			values = append(values, bson.E{Key: key.Field, Value: test.values[i]})
+		}
+		if keyset := extJson(t, KeysetToBson(keys, values)); keyset != test.expected {
+			t.Errorf("keyset: %s, expected %s", keyset, test.expected)
+		}
+	}
+}
+
+func TestCurrentOpToLockedSessions(t *testing.T) {
+	op := func(opid interface{}, ns string, waitingForLock bool, locks bson.D) bson.D {
+		return bson.D{
+			{Key: "opid", Value: opid},
+			{Key: "active", Value: true},
+			{Key: "ns", Value: ns},
+			{Key: "waitingForLock", Value: waitingForLock},
+			{Key: "locks", Value: locks},
+			{Key: "microsecs_running", Value: int64(2500)},
+		}
+	}
+	ops := bson.A{
+		op(int32(1), "shop.users", false, bson.D{{Key: "Global", Value: "w"}, {Key: "Database", Value: "w"}, {Key: "Collection", Value: "W"}}),
+		op(int32(2), "shop.users", true, bson.D{{Key: "Global", Value: "r"}, {Key: "Database", Value: "r"}, {Key: "Collection", Value: "r"}}),
+		op(int64(3), "shop.users", true, bson.D{{Key: "Global", Value: "w"}, {Key: "Database", Value: "w"}, {Key: "Collection", Value: "w"}}),
+		op(int32(4), "shop.posts", false, bson.D{{Key: "Global", Value: "r"}, {Key: "Database", Value: "r"}, {Key: "Collection", Value: "r"}}),
+		op("shard01:5", "shop.users", false, bson.D{{Key: "Global", Value: "W"}}),
+		op(int32(6), "admin.$cmd", true, bson.D{{Key: "Global", Value: "w"}, {Key: "Database", Value: "W"}}),
+		op(int32(8), "admin.system", false, bson.D{{Key: "Global", Value: "r"}, {Key: "Database", Value: "r"}}),
+		op(int32(7), "admin.system", false, bson.D{{Key: "Global", Value: "r"}, {Key: "Database", Value: "R"}}),
+		op(int32(9), "shop.orders", false, bson.D{}),
+	}
+	sessions := CurrentOpToLockedSessions(ops)
+	result := []string{}
+	for _, session := range sessions {
+		notGranted := []string{}
+		for _, lock := range session.Locks {
+			if !lock.Granted {
+				notGranted = append(notGranted, lock.LockType+":"+lock.Relation)
+			}
+		}
+		result = append(result, fmt.Sprintf("%d blocked by %v waiting for %v", session.PID, session.BlockedBy, notGranted))
+	}
+	// 4 holds no conflicting lock, 5 has a string opid and 9 holds no locks
+	expected := []string{
+		"1 blocked by [] waiting for []",
+		"2 blocked by [1] waiting for [Collection:shop.users]",
+		"3 blocked by [1] waiting for [Collection:shop.users]",
+		"6 blocked by [7 8] waiting for [Database:admin]",
+		"8 blocked by [] waiting for []",
+		"7 blocked by [] waiting for []",
+	}
+	if strings.Join(result, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("sessions:\n%s\nexpected:\n%s", strings.Join(result, "\n"), strings.Join(expected, "\n"))
+	}
+	if sessions[1].WaitEventType != "Lock" || sessions[1].DatabaseName != "shop" || *sessions[1].Duration != 2 {
+		t.Errorf("session: %+v", sessions[1].DBSession)
+	}
+}
+
+// planString writes the plan tree as type(children) with the relation, index and actual rows of the nodes.
+func planString(node *qemodels.PlanNode) string {
+	str := node.NodeType
+	if node.Relation != "" {
+		str += " on " + node.Relation
+	}
+	if node.Index != "" {
+		str += " using " + node.Index
+	}
+	if node.ActualRows != nil {
+		str += fmt.Sprintf(" rows=%v", *node.ActualRows)
+	}
+	children := []string{}
+	for _, child := range node.Children {
+		children = append(children, planString(child))
+	}
+	if len(children) > 0 {
+		str += " (" + strings.Join(children, ", ") + ")"
+	}
+	return str
+}
+
+func TestExplainToPlan(t *testing.T) {
+	queryPlanner := bson.M{
+		"namespace": "shop.users",
+		"winningPlan": bson.M{
+			"stage": "FETCH",
+			"inputStage": bson.D{
+				{Key: "stage", Value: "OR"},
+				{Key: "inputStages", Value: bson.A{
+					bson.M{"stage": "IXSCAN", "indexName": "age_1", "keyPattern": bson.M{"age": 1}},
+					bson.M{"stage": "COLLSCAN", "direction": "forward"},
+				}},
+			},
+		},
+	}
+	plan := ExplainToPlan(bson.M{"queryPlanner": queryPlanner}, false)
+	if str := planString(plan.Plan); str != "FETCH (OR (IXSCAN on shop.users using age_1, COLLSCAN on shop.users))" {
+		t.Error("plan:", str)
+	}
+	if plan.Analyzed || plan.ExecutionTimeMs != nil {
+		t.Errorf("plan is analyzed: %+v", plan)
+	}
+	if details := plan.Plan.Children[0].Children[0].Details; details["keyPattern"] == nil || details["stage"] != nil {
+		t.Error("details:", details)
+	}
+
+	analyzed := ExplainToPlan(bson.M{
+		"queryPlanner": queryPlanner,
+		"executionStats": bson.M{
+			"executionTimeMillis": int32(12),
+			"executionStages": bson.M{
+				"stage":                       "FETCH",
+				"nReturned":                   int32(3),
+				"executionTimeMillisEstimate": int64(10),
+				"inputStage":                  bson.M{"stage": "IXSCAN", "indexName": "age_1", "nReturned": int32(3)},
+			},
+		},
+	}, true)
+	if str := planString(analyzed.Plan); str != "FETCH rows=3 (IXSCAN on shop.users using age_1 rows=3)" {
+		t.Error("analyzed plan:", str)
+	}
+	if !analyzed.Analyzed || analyzed.ExecutionTimeMs == nil || *analyzed.ExecutionTimeMs != 12 || *analyzed.Plan.ActualTimeMs != 10 {
+		t.Errorf("analyzed plan: %+v", analyzed)
+	}
+
+	// the slot based execution engine nests the plan in queryPlan
+	sbe := ExplainToPlan(bson.M{"queryPlanner": bson.M{
+		"namespace":   "shop.users",
+		"winningPlan": bson.M{"queryPlan": bson.M{"stage": "IDHACK"}, "slotBasedPlan": bson.M{}},
+	}}, false)
+	if str := planString(sbe.Plan); str != "IDHACK on shop.users" {
+		t.Error("slot based plan:", str)
+	}
+
+	aggregate := ExplainToPlan(bson.M{"stages": bson.A{
+		bson.D{{Key: "$cursor", Value: bson.M{"queryPlanner": queryPlanner}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$age"}}, {Key: "nReturned", Value: int64(2)}},
+		bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}}, false)
+	if str := planString(aggregate.Plan); str != "$sort ($group rows=2 (FETCH (OR (IXSCAN on shop.users using age_1, COLLSCAN on shop.users))))" {
+		t.Error("aggregate plan:", str)
+	}
+}
+
+func TestIndexDocument(t *testing.T) {
+	expireAfterSeconds := int32(3600)
+	tests := []struct {
+		index    *qemodels.IndexSpec
+		expected string
+	}{
+		{
+			&qemodels.IndexSpec{Fields: []qemodels.IndexField{{Name: "age"}, {Name: "name", Descending: true}}},
+			`{"key":{"age":1,"name":-1},"name":"age_1_name_-1"}`,
+		},
+		{
+			&qemodels.IndexSpec{Fields: []qemodels.IndexField{{Name: "body", Type: "text"}}},
+			`{"key":{"body":"text"},"name":"body_text"}`,
+		},
+		{
+			&qemodels.IndexSpec{Name: "email_unique", Unique: true, Fields: []qemodels.IndexField{{Name: "email"}}},
+			`{"key":{"email":1},"name":"email_unique","unique":true}`,
+		},
+		{
+			&qemodels.IndexSpec{ExpireAfterSeconds: &expireAfterSeconds, Fields: []qemodels.IndexField{{Name: "created_at"}}},
+			`{"key":{"created_at":1},"name":"created_at_1","expireAfterSeconds":3600}`,
+		},
+		{
+			&qemodels.IndexSpec{Where: `{"age": {"$gt": 18}}`, Fields: []qemodels.IndexField{{Name: "age"}}},
+			`{"key":{"age":1},"name":"age_1","partialFilterExpression":{"age":{"$gt":18}}}`,
+		},
+	}
+	for _, test := range tests {
+		document, err := IndexDocument(test.index)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if str := extJson(t, document); str != test.expected {
+			t.Errorf("index: %s, expected %s", str, test.expected)
+		}
+	}
+	if _, err := IndexDocument(&qemodels.IndexSpec{}); err == nil {
+		t.Error("index without fields is allowed")
+	}
+	if _, err := IndexDocument(&qemodels.IndexSpec{Where: "{age: ", Fields: []qemodels.IndexField{{Name: "age"}}}); err == nil {
+		t.Error("invalid partial filter is allowed")
+	}
+}
+
+func TestCollectionValidator(t *testing.T) {
+	if validator := CollectionValidator(&qemodels.DataModelSpec{Name: "posts"}); validator != nil {
+		t.Error("validator of no fields:", validator)
+	}
+	spec := &qemodels.DataModelSpec{
+		Name: "posts",
+		Fields: []qemodels.FieldSpec{
+			{Name: "title", Type: "string"},
+			{Name: "published_at", Type: "date", IsNullable: true},
+			{Name: "tags"},
+			{Name: "meta", IsNullable: true},
+		},
+	}
+	expected := `{"$jsonSchema":{"bsonType":"object","required":["title","tags"],"properties":{"title":{"bsonType":"string"},"published_at":{"bsonType":["date","null"]}}}}`
+	if validator := extJson(t, CollectionValidator(spec)); validator != expected {
+		t.Errorf("validator: %s, expected %s", validator, expected)
+	}
+	spec.Fields = []qemodels.FieldSpec{{Name: "meta", IsNullable: true}}
+	expected = `{"$jsonSchema":{"bsonType":"object","properties":{}}}`
+	if validator := extJson(t, CollectionValidator(spec)); validator != expected {
+		t.Errorf("validator: %s, expected %s", validator, expected)
+	}
+}
+
+func TestDocumentFieldValue(t *testing.T) {
+	document := map[string]interface{}{
+		"_id":  "b",
+		"age":  30,
+		"user": map[string]interface{}{"address": map[string]interface{}{"city": "Pune"}},
+		"tags": []interface{}{"a"},
+	}
+	tests := map[string]interface{}{
+		"_id":               "b",
+		"age":               30,
+		"user.address.city": "Pune",
+		"user.name":         nil,
+		"missing":           nil,
+		"age.value":         nil,
+		"tags.0":            nil,
+	}
+	for field, expected := range tests {
+		if value := DocumentFieldValue(document, field); value != expected {
+			t.Errorf("value of %s: %v, expected %v", field, value, expected)
+		}
+	}
+}
@@ -7,27 +7,38 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"slashbase.com/backend/internal/models"
 	"slashbase.com/backend/pkg/queryengines/mongoqueryengine/mongoutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
 	"slashbase.com/backend/pkg/queryengines/queryconfig"
 	"slashbase.com/backend/pkg/sbsql"
 	"slashbase.com/backend/pkg/sshtunnel"
 )
 
 type MongoQueryEngine struct {
-	openClients map[string]mongoClientInstance
+	openClients         map[string]mongoClientInstance
+	openTransactions    map[string]*mongoTransactionInstance
+	transactionsMutex   sync.Mutex
+	runningQueries      map[string]*mongoRunningQuery
+	runningQueriesMutex sync.Mutex
 }
 
 func InitMongoQueryEngine() *MongoQueryEngine {
 	return &MongoQueryEngine{
-		openClients: map[string]mongoClientInstance{},
+		openClients:      map[string]mongoClientInstance{},
+		openTransactions: map[string]*mongoTransactionInstance{},
+		runningQueries:   map[string]*mongoRunningQuery{},
 	}
 }
 
-func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+func (mqe *MongoQueryEngine) getDatabase(dbConn *models.DBConnection) (*mongo.Database, error) {
 	port, _ := strconv.Atoi(string(dbConn.DBPort))
 	if dbConn.UseSSH != models.DBUSESSH_NONE {
 		remoteHost := string(dbConn.DBHost)
@@ -46,7 +57,22 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 	if err != nil {
 		return nil, err
 	}
-	db := conn.Database(string(dbConn.DBName))
+	return conn.Database(string(dbConn.DBName)), nil
+}
+
+func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	if config.QueryID != "" {
+		return mqe.runCancellableQuery(dbConn, db, query, config)
+	}
+	return runQuery(config.GetContext(), db, query, config)
+}
+
+// runQuery runs the query on the database, ctx is a session context when the query is part of a transaction.
+func runQuery(ctx context.Context, db *mongo.Database, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
 	queryType := mongoutils.GetMongoQueryType(query)
 
 	queryTypeRead := mongoutils.IsQueryTypeRead(queryType.QueryType)
@@ -54,9 +80,17 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 		return nil, errors.New("not allowed run this query")
 	}
 
+	// maxTimeMS is set on the reads which support it, the context timeout covers the other operations
+	maxTime := maxTimeOption(config)
+	if config.MaxExecutionTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.MaxExecutionTime)
+		defer cancel()
+	}
+
 	if queryType.QueryType == mongoutils.QUERY_FINDONE {
 		result := db.Collection(queryType.CollectionName).
-			FindOne(context.Background(), queryType.Args[0])
+			FindOne(ctx, queryType.Args[0], &options.FindOneOptions{MaxTime: maxTime})
 		if result.Err() != nil {
 			return nil, result.Err()
 		}
@@ -66,23 +100,26 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 			"data": data,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_FIND {
-		cursor, err := db.Collection(queryType.CollectionName).
-			Find(context.Background(), queryType.Args[0], &options.FindOptions{Limit: queryType.Limit, Skip: queryType.Skip, Sort: queryType.Sort})
+		cursor, err := openCursor(ctx, db, queryType, config)
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(ctx)
+		keys, data, truncated, err := mongoutils.MongoCursorToJson(ctx, cursor, config.MaxRows)
 		if err != nil {
 			return nil, err
 		}
-		defer cursor.Close(context.Background())
-		keys, data := mongoutils.MongoCursorToJson(cursor)
 		if config.CreateLogFn != nil {
 			config.CreateLogFn(query)
 		}
 		return map[string]interface{}{
-			"keys": keys,
-			"data": data,
+			"keys":      keys,
+			"data":      data,
+			"truncated": truncated,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_INSERTONE {
 		result, err := db.Collection(queryType.CollectionName).
-			InsertOne(context.Background(), queryType.Args[0])
+			InsertOne(ctx, queryType.Args[0])
 		if err != nil {
 			return nil, err
 		}
@@ -96,7 +133,7 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_INSERT {
 		result, err := db.Collection(queryType.CollectionName).
-			InsertMany(context.Background(), queryType.Args[0].(bson.A))
+			InsertMany(ctx, queryType.Args[0].(bson.A))
 		if err != nil {
 			return nil, err
 		}
@@ -110,7 +147,7 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_DELETEONE {
 		result, err := db.Collection(queryType.CollectionName).
-			DeleteOne(context.Background(), queryType.Args[0])
+			DeleteOne(ctx, queryType.Args[0])
 		if err != nil {
 			return nil, err
 		}
@@ -124,7 +161,7 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_DELETEMANY {
 		result, err := db.Collection(queryType.CollectionName).
-			DeleteMany(context.Background(), queryType.Args[0].(bson.D))
+			DeleteMany(ctx, queryType.Args[0].(bson.D))
 		if err != nil {
 			return nil, err
 		}
@@ -138,7 +175,7 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_UPDATEONE {
 		result, err := db.Collection(queryType.CollectionName).
-			UpdateOne(context.Background(), queryType.Args[0], queryType.Args[1])
+			UpdateOne(ctx, queryType.Args[0], queryType.Args[1])
 		if err != nil {
 			return nil, err
 		}
@@ -153,7 +190,7 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_UPDATEMANY {
 		result, err := db.Collection(queryType.CollectionName).
-			UpdateMany(context.Background(), queryType.Args[0], queryType.Args[1])
+			UpdateMany(ctx, queryType.Args[0], queryType.Args[1])
 		if err != nil {
 			return nil, err
 		}
@@ -167,7 +204,7 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 			},
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_RUNCMD {
-		result := db.RunCommand(context.Background(), queryType.Args[0])
+		result := db.RunCommand(ctx, queryType.Args[0])
 		if result.Err() != nil {
 			return nil, result.Err()
 		}
@@ -180,23 +217,27 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 			"data": data,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_GETINDEXES {
-		cursor, err := db.RunCommandCursor(context.Background(), bson.M{
+		cursor, err := db.RunCommandCursor(ctx, bson.M{
 			"listIndexes": queryType.CollectionName,
 		})
 		if err != nil {
 			return nil, err
 		}
-		defer cursor.Close(context.Background())
-		keys, data := mongoutils.MongoCursorToJson(cursor)
+		defer cursor.Close(ctx)
+		keys, data, truncated, err := mongoutils.MongoCursorToJson(ctx, cursor, config.MaxRows)
+		if err != nil {
+			return nil, err
+		}
 		if config.CreateLogFn != nil {
 			config.CreateLogFn(query)
 		}
 		return map[string]interface{}{
-			"keys": keys,
-			"data": data,
+			"keys":      keys,
+			"data":      data,
+			"truncated": truncated,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_LISTCOLLECTIONS {
-		list, err := db.ListCollectionNames(context.Background(), queryType.Args[0])
+		list, err := db.ListCollectionNames(ctx, queryType.Args[0])
 		if err != nil {
 			return nil, err
 		}
@@ -213,7 +254,7 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_COUNT {
 		count, err := db.Collection(queryType.CollectionName).
-			CountDocuments(context.Background(), queryType.Args[0])
+			CountDocuments(ctx, queryType.Args[0], &options.CountOptions{MaxTime: maxTime})
 		if err != nil {
 			return nil, err
 		}
@@ -229,24 +270,50 @@ func (mqe *MongoQueryEngine) RunQuery(dbConn *models.DBConnection, query string,
 			},
 		}, nil
 	} else if queryType.QueryType == mongoutils.QUERY_AGGREGATE {
-		cursor, err := db.Collection(queryType.CollectionName).
-			Aggregate(context.Background(), queryType.Args[0])
+		cursor, err := openCursor(ctx, db, queryType, config)
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(ctx)
+		keys, data, truncated, err := mongoutils.MongoCursorToJson(ctx, cursor, config.MaxRows)
 		if err != nil {
 			return nil, err
 		}
-		defer cursor.Close(context.Background())
-		keys, data := mongoutils.MongoCursorToJson(cursor)
 		if config.CreateLogFn != nil {
 			config.CreateLogFn(query)
 		}
 		return map[string]interface{}{
-			"keys": keys,
-			"data": data,
+			"keys":      keys,
+			"data":      data,
+			"truncated": truncated,
 		}, nil
 	}
 	return nil, errors.New("unknown query")
 }
 
+// openCursor runs a find or aggregate query, find queries are limited to one document more
+// than the max rows which tells if the result was truncated.
+func openCursor(ctx context.Context, db *mongo.Database, queryType *mongoutils.MongoQuery, config *queryconfig.QueryConfig) (*mongo.Cursor, error) {
+	if queryType.QueryType == mongoutils.QUERY_AGGREGATE {
+		return db.Collection(queryType.CollectionName).
+			Aggregate(ctx, queryType.Args[0], &options.AggregateOptions{MaxTime: maxTimeOption(config)})
+	}
+	findOptions := &options.FindOptions{Limit: queryType.Limit, Skip: queryType.Skip, Sort: queryType.Sort, MaxTime: maxTimeOption(config)}
+	if config.MaxRows > 0 && (findOptions.Limit == nil || *findOptions.Limit == 0 || *findOptions.Limit > int64(config.MaxRows)) {
+		limit := int64(config.MaxRows) + 1
+		findOptions.Limit = &limit
+	}
+	return db.Collection(queryType.CollectionName).
+		Find(ctx, queryType.Args[0], findOptions)
+}
+
+func maxTimeOption(config *queryconfig.QueryConfig) *time.Duration {
+	if config.MaxExecutionTime > 0 {
+		return &config.MaxExecutionTime
+	}
+	return nil
+}
+
 func (mqe *MongoQueryEngine) TestConnection(dbConn *models.DBConnection, config *queryconfig.QueryConfig) bool {
 	query := "db.runCommand({ping: 1})"
 	data, err := mqe.RunQuery(dbConn, query, config)
@@ -257,14 +324,44 @@ func (mqe *MongoQueryEngine) TestConnection(dbConn *models.DBConnection, config
 	return test == 1
 }
 
-func (mqe *MongoQueryEngine) GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+func (mqe *MongoQueryEngine) GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBDataModel, error) {
 	query := "db.getCollectionNames()"
 	data, err := mqe.RunQuery(dbConn, query, config)
 	if err != nil {
 		return nil, err
 	}
-	rdata := data["data"].([]map[string]interface{})
-	return rdata, nil
+	dataModels := []*qemodels.DBDataModel{}
+	for _, collection := range data["data"].([]map[string]interface{}) {
+		dataModels = append(dataModels, buildDBDataModel(collection))
+	}
+	return dataModels, nil
+}
+
+func (mqe *MongoQueryEngine) GetSingleDataModel(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (*qemodels.DBDataModel, error) {
+	fieldsData, err := mqe.GetSingleDataModelFields(dbConn, name, config)
+	if err != nil {
+		return nil, err
+	}
+	indexesData, err := mqe.GetSingleDataModelIndexes(dbConn, name, config)
+	if err != nil {
+		return nil, err
+	}
+	allFields := []qemodels.DBDataModelField{}
+	for _, field := range fieldsData {
+		allFields = append(allFields, *buildDBDataModelField(field))
+	}
+	allIndexes := []qemodels.DBDataModelIndex{}
+	for _, index := range indexesData {
+		allIndexes = append(allIndexes, *buildDBDataModelIndex(index))
+	}
+	dataModel := qemodels.DBDataModel{
+		Name:        name,
+		Kind:        qemodels.DATA_MODEL_KIND_COLLECTION,
+		Fields:      allFields,
+		Indexes:     allIndexes,
+		ForeignKeys: []qemodels.DBDataModelForeignKey{},
+	}
+	return &dataModel, nil
 }
 
 func (mqe *MongoQueryEngine) GetSingleDataModelFields(dbConn *models.DBConnection, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
@@ -288,11 +385,11 @@ func (mqe *MongoQueryEngine) GetSingleDataModelIndexes(dbConn *models.DBConnecti
 	return mongoutils.GetCollectionIndexes(returnedData), err
 }
 
-func (mqe *MongoQueryEngine) AddSingleDataModelKey(dbConn *models.DBConnection, schema, name, columnName, dataType string) (map[string]interface{}, error) {
+func (mqe *MongoQueryEngine) AddSingleDataModelField(dbConn *models.DBConnection, schema, name, columnName, dataType string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
 	return nil, errors.New("not supported yet")
 }
 
-func (mqe *MongoQueryEngine) DeleteSingleDataModelKey(dbConn *models.DBConnection, schema, name, columnName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+func (mqe *MongoQueryEngine) DeleteSingleDataModelField(dbConn *models.DBConnection, schema, name, columnName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
 	query := fmt.Sprintf(`db.%s.updateMany({}, {$unset: {%s: ""}})`, name, columnName)
 	data, err := mqe.RunQuery(dbConn, query, config)
 	if err != nil {
@@ -301,31 +398,241 @@ func (mqe *MongoQueryEngine) DeleteSingleDataModelKey(dbConn *models.DBConnectio
 	return data, err
 }
 
-func (mqe *MongoQueryEngine) GetData(dbConn *models.DBConnection, name string, limit int, offset int64, fetchCount bool, filter []string, sort []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	query := fmt.Sprintf(`db.%s.find().limit(%d).skip(%d)`, name, limit, offset)
-	countQuery := fmt.Sprintf(`db.%s.count()`, name)
-	if len(filter) == 1 && strings.HasPrefix(filter[0], "{") && strings.HasSuffix(filter[0], "}") {
-		query = fmt.Sprintf(`db.%s.find(%s).limit(%d).skip(%d)`, name, filter[0], limit, offset)
-		countQuery = fmt.Sprintf(`db.%s.count(%s)`, name, filter[0])
+// CreateIndex creates the index on the collection, the query log has the equivalent shell command.
+func (mqe *MongoQueryEngine) CreateIndex(dbConn *models.DBConnection, schema, name string, index *qemodels.IndexSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	indexDocument, err := mongoutils.IndexDocument(index)
+	if err != nil {
+		return nil, err
 	}
-	if len(sort) == 1 {
-		query = query + fmt.Sprintf(`.sort(%s)`, sort[0])
+	command, err := mongoutils.CreateIndexCommand(name, indexDocument)
+	if err != nil {
+		return nil, err
 	}
-	data, err := mqe.RunQuery(dbConn, query, config)
+	return mqe.runWriteCommands(dbConn, false, []bson.D{
+		{{Key: "createIndexes", Value: name}, {Key: "indexes", Value: bson.A{indexDocument}}},
+	}, command, config)
+}
+
+func (mqe *MongoQueryEngine) DropIndex(dbConn *models.DBConnection, schema, name, indexName string, concurrently bool, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	collectionName, _ := json.Marshal(name)
+	quotedIndexName, _ := json.Marshal(indexName)
+	command := fmt.Sprintf("db.getCollection(%s).dropIndex(%s)", collectionName, quotedIndexName)
+	return mqe.runWriteCommands(dbConn, false, []bson.D{
+		{{Key: "dropIndexes", Value: name}, {Key: "index", Value: indexName}},
+	}, command, config)
+}
+
+// CreateDataModel creates the collection with a $jsonSchema validator of its fields, if it has any,
+// and the unique indexes of its unique fields.
+func (mqe *MongoQueryEngine) CreateDataModel(dbConn *models.DBConnection, schema string, spec *qemodels.DataModelSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	createCommand := bson.D{{Key: "create", Value: spec.Name}}
+	collectionOptions := bson.D{}
+	if validator := mongoutils.CollectionValidator(spec); validator != nil {
+		createCommand = append(createCommand, bson.E{Key: "validator", Value: validator})
+		collectionOptions = append(collectionOptions, bson.E{Key: "validator", Value: validator})
+	}
+	commands := []bson.D{createCommand}
+	indexes := []bson.D{}
+	for _, field := range spec.Fields {
+		if field.IsUnique {
+			indexDocument, err := mongoutils.IndexDocument(&qemodels.IndexSpec{Fields: []qemodels.IndexField{{Name: field.Name}}, Unique: true})
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, indexDocument)
+		}
+	}
+	if len(indexes) > 0 {
+		indexesA := bson.A{}
+		for _, index := range indexes {
+			indexesA = append(indexesA, index)
+		}
+		commands = append(commands, bson.D{{Key: "createIndexes", Value: spec.Name}, {Key: "indexes", Value: indexesA}})
+	}
+	script, err := mongoutils.CreateCollectionScript(spec.Name, collectionOptions, indexes)
+	if err != nil {
+		return nil, err
+	}
+	return mqe.runWriteCommands(dbConn, false, commands, script, config)
+}
+
+// RenameDataModel renames the collection, which stays in its database.
+func (mqe *MongoQueryEngine) RenameDataModel(dbConn *models.DBConnection, schema, name, newName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	collectionName, _ := json.Marshal(name)
+	quotedNewName, _ := json.Marshal(newName)
+	command := fmt.Sprintf("db.getCollection(%s).renameCollection(%s)", collectionName, quotedNewName)
+	dbName := string(dbConn.DBName)
+	return mqe.runWriteCommands(dbConn, true, []bson.D{
+		{{Key: "renameCollection", Value: dbName + "." + name}, {Key: "to", Value: dbName + "." + newName}},
+	}, command, config)
+}
+
+// TruncateDataModel deletes all the documents of the collection, keeping its indexes and validator.
+func (mqe *MongoQueryEngine) TruncateDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	collectionName, _ := json.Marshal(name)
+	command := fmt.Sprintf("db.getCollection(%s).deleteMany({})", collectionName)
+	return mqe.runWriteCommands(dbConn, false, []bson.D{
+		{{Key: "delete", Value: name}, {Key: "deletes", Value: bson.A{bson.D{{Key: "q", Value: bson.D{}}, {Key: "limit", Value: 0}}}}},
+	}, command, config)
+}
+
+func (mqe *MongoQueryEngine) DropDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	collectionName, _ := json.Marshal(name)
+	command := fmt.Sprintf("db.getCollection(%s).drop()", collectionName)
+	return mqe.runWriteCommands(dbConn, false, []bson.D{{{Key: "drop", Value: name}}}, command, config)
+}
+
+// runWriteCommands runs the commands in order on the database, or on the admin database for the commands
+// which need it. They are not allowed for read only roles and are logged as the equivalent shell command.
+// The result is the one of the last command.
+func (mqe *MongoQueryEngine) runWriteCommands(dbConn *models.DBConnection, admin bool, commands []bson.D, shellCommand string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	if config.ReadOnly {
+		return nil, errors.New("not allowed run this query")
+	}
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	if admin {
+		db = db.Client().Database("admin")
+	}
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+	var keys []string
+	var data []map[string]interface{}
+	for _, command := range commands {
+		result := db.RunCommand(ctx, command)
+		if result.Err() != nil {
+			return nil, result.Err()
+		}
+		keys, data = mongoutils.MongoSingleResultToJson(result)
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(shellCommand)
+	}
+	return map[string]interface{}{
+		"keys": keys,
+		"data": data,
+	}, nil
+}
+
+func (mqe *MongoQueryEngine) GetData(dbConn *models.DBConnection, schema string, name string, limit int, offset int64, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	bsonFilter := bson.D{}
+	if filter != nil {
+		bsonFilter = mongoutils.FilterToBson(filter)
+	}
+	limit64 := int64(limit)
+	findOptions := &options.FindOptions{Limit: &limit64, Skip: &offset}
+	if len(sort) > 0 {
+		findOptions.Sort = mongoutils.SortToBson(sort)
+	}
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+	cursor, err := db.Collection(name).Find(ctx, bsonFilter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	keys, rowsData, _, err := mongoutils.MongoCursorToJson(ctx, cursor, 0)
 	if err != nil {
 		return nil, err
 	}
+	if config.CreateLogFn != nil {
+		filterJson, _ := bson.MarshalExtJSON(bsonFilter, false, false)
+		config.CreateLogFn(fmt.Sprintf(`db.%s.find(%s).limit(%d).skip(%d)`, name, string(filterJson), limit, offset))
+	}
+	data := map[string]interface{}{
+		"keys": keys,
+		"data": rowsData,
+	}
 	if fetchCount {
-		countData, err := mqe.RunQuery(dbConn, countQuery, config)
+		count, err := db.Collection(name).CountDocuments(ctx, bsonFilter)
 		if err != nil {
 			return nil, err
 		}
-		data["count"] = countData["data"].([]map[string]interface{})[0]["count"]
+		data["count"] = count
 	}
 	return data, err
 }
 
-func (mqe *MongoQueryEngine) UpdateSingleData(dbConn *models.DBConnection, name string, underscoreID string, documentData string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+// GetDataByCursor gets the page of documents after the cursor, ordered by the sort fields and _id.
+// The cursor of the next page is returned as nextCursor, it is empty on the last page.
+func (mqe *MongoQueryEngine) GetDataByCursor(dbConn *models.DBConnection, schema string, name string, limit int, cursor string, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	keys := qemodels.KeysetFields(sort, []string{"_id"})
+	keyNames := []string{}
+	for _, key := range keys {
+		keyNames = append(keyNames, key.Field)
+	}
+	bsonFilter := bson.D{}
+	if filter != nil {
+		bsonFilter = mongoutils.FilterToBson(filter)
+	}
+	findFilter := bsonFilter
+	if cursor != "" {
+		decodedCursor, err := qemodels.DecodeCursor(cursor, keyNames)
+		if err != nil {
+			return nil, err
+		}
+		values := bson.D{}
+		if err := bson.UnmarshalExtJSON([]byte(decodedCursor.Values), true, &values); err != nil || len(values) != len(keys) {
+			return nil, errors.New("invalid cursor")
+		}
+		findFilter = bson.D{{Key: "$and", Value: bson.A{bsonFilter, mongoutils.KeysetToBson(keys, values)}}}
+	}
+	limit64 := int64(limit)
+	findOptions := &options.FindOptions{Limit: &limit64, Sort: mongoutils.SortToBson(keys)}
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+	mCursor, err := db.Collection(name).Find(ctx, findFilter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer mCursor.Close(ctx)
+	documentKeys, rowsData, _, err := mongoutils.MongoCursorToJson(ctx, mCursor, 0)
+	if err != nil {
+		return nil, err
+	}
+	if config.CreateLogFn != nil {
+		filterJson, _ := bson.MarshalExtJSON(findFilter, false, false)
+		config.CreateLogFn(fmt.Sprintf(`db.%s.find(%s).limit(%d)`, name, string(filterJson), limit))
+	}
+	nextCursor := ""
+	if limit > 0 && len(rowsData) == limit {
+		lastDocument := rowsData[len(rowsData)-1]
+		values := bson.D{}
+		for _, key := range keys {
+			values = append(values, bson.E{Key: key.Field, Value: mongoutils.DocumentFieldValue(lastDocument, key.Field)})
+		}
+		valuesJson, err := bson.MarshalExtJSON(values, true, false)
+		if err != nil {
+			return nil, err
+		}
+		nextCursor = (&qemodels.Cursor{Keys: keyNames, Values: string(valuesJson)}).Encode()
+	}
+	data := map[string]interface{}{
+		"keys":       documentKeys,
+		"data":       rowsData,
+		"nextCursor": nextCursor,
+	}
+	if fetchCount {
+		count, err := db.Collection(name).CountDocuments(ctx, bsonFilter)
+		if err != nil {
+			return nil, err
+		}
+		data["count"] = count
+	}
+	return data, err
+}
+
+func (mqe *MongoQueryEngine) UpdateSingleData(dbConn *models.DBConnection, schema string, name string, underscoreID string, columnName string, documentData string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
 	query := fmt.Sprintf(`db.%s.updateOne({_id: ObjectId("%s")}, {$set: %s } )`, name, underscoreID, documentData)
 	data, err := mqe.RunQuery(dbConn, query, config)
 	if err != nil {
@@ -338,7 +645,7 @@ func (mqe *MongoQueryEngine) UpdateSingleData(dbConn *models.DBConnection, name
 	return data, err
 }
 
-func (mqe *MongoQueryEngine) AddData(dbConn *models.DBConnection, schema string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+func (mqe *MongoQueryEngine) AddData(dbConn *models.DBConnection, schema string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (*qemodels.AddDataResponse, error) {
 	dataStr, err := json.Marshal(data)
 	if err != nil {
 		return nil, err
@@ -348,14 +655,11 @@ func (mqe *MongoQueryEngine) AddData(dbConn *models.DBConnection, schema string,
 	if err != nil {
 		return nil, err
 	}
-	insertedID := rData["data"].([]map[string]interface{})[0]["insertedId"]
-	rData = map[string]interface{}{
-		"insertedId": insertedID,
-	}
-	return rData, err
+	insertedID := rData["data"].([]map[string]interface{})[0]["insertedId"].(primitive.ObjectID)
+	return &qemodels.AddDataResponse{NewID: insertedID.Hex()}, err
 }
 
-func (mqe *MongoQueryEngine) DeleteData(dbConn *models.DBConnection, name string, underscoreIds []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+func (mqe *MongoQueryEngine) DeleteData(dbConn *models.DBConnection, schema string, name string, underscoreIds []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
 	for i, id := range underscoreIds {
 		underscoreIds[i] = fmt.Sprintf(`ObjectId("%s")`, id)
 	}
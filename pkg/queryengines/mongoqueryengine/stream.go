@@ -0,0 +1,65 @@
+package mongoqueryengine
+
+import (
+	"context"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/mongoqueryengine/mongoutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// StreamQuery runs a find or aggregate query writing the documents to the stream as they are read
+// from the cursor, other queries are run with RunQuery. It returns the keys of the documents,
+// the row count and if the documents were truncated.
+func (mqe *MongoQueryEngine) StreamQuery(dbConn *models.DBConnection, query string, stream qemodels.ResultStream, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	queryType := mongoutils.GetMongoQueryType(query)
+	if queryType.QueryType != mongoutils.QUERY_FIND && queryType.QueryType != mongoutils.QUERY_AGGREGATE {
+		return mqe.RunQuery(dbConn, query, config)
+	}
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+	if config.QueryID != "" {
+		var removeRunningQuery func()
+		ctx, removeRunningQuery, err = mqe.addRunningQuery(ctx, dbConn, db, config.QueryID)
+		if err != nil {
+			return nil, err
+		}
+		defer removeRunningQuery()
+	}
+
+	cursor, err := openCursor(ctx, db, queryType, config)
+	if err != nil {
+		return nil, err
+	}
+	// the cursor is killed on the server when the stream fails or the documents are truncated
+	defer cursor.Close(context.Background())
+	keysMap := map[string]bool{}
+	rowCount := 0
+	truncated, err := mongoutils.MongoCursorEach(ctx, cursor, config.MaxRows, func(row map[string]interface{}) error {
+		for key := range row {
+			keysMap[key] = true
+		}
+		rowCount++
+		return stream.WriteRow(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(query)
+	}
+	keys := []string{}
+	for key := range keysMap {
+		keys = append(keys, key)
+	}
+	return map[string]interface{}{
+		"keys":      keys,
+		"rowCount":  rowCount,
+		"truncated": truncated,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package mongoqueryengine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// mongoTransactionInstance is a transaction open across requests, it runs in a client session.
+type mongoTransactionInstance struct {
+	dbConnectionId string
+	userID         string
+	db             *mongo.Database
+	session        mongo.Session
+	idleTimer      *time.Timer
+	mutex          sync.Mutex
+}
+
+func (mqe *MongoQueryEngine) BeginTransaction(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (string, error) {
+	db, err := mqe.getDatabase(dbConn)
+	if err != nil {
+		return "", err
+	}
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return "", err
+	}
+	if err := session.StartTransaction(); err != nil {
+		session.EndSession(context.Background())
+		return "", err
+	}
+	transactionID := uuid.NewString()
+	instance := &mongoTransactionInstance{
+		dbConnectionId: dbConn.ID,
+		userID:         config.UserID,
+		db:             db,
+		session:        session,
+	}
+	instance.idleTimer = time.AfterFunc(qemodels.TRANSACTION_IDLE_TIMEOUT, func() {
+		mqe.RollbackTransaction(dbConn, transactionID, config)
+	})
+	mqe.transactionsMutex.Lock()
+	mqe.openTransactions[transactionID] = instance
+	mqe.transactionsMutex.Unlock()
+	if config.CreateLogFn != nil {
+		config.CreateLogFn("session.startTransaction()")
+	}
+	return transactionID, nil
+}
+
+func (mqe *MongoQueryEngine) RunTransactionQuery(dbConn *models.DBConnection, transactionID string, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	instance, err := mqe.getTransaction(dbConn, transactionID, false, config)
+	if err != nil {
+		return nil, err
+	}
+	defer instance.mutex.Unlock()
+	// the idle time starts again after the query
+	instance.idleTimer.Stop()
+	defer instance.idleTimer.Reset(qemodels.TRANSACTION_IDLE_TIMEOUT)
+	return runQuery(mongo.NewSessionContext(config.GetContext(), instance.session), instance.db, query, config)
+}
+
+func (mqe *MongoQueryEngine) CommitTransaction(dbConn *models.DBConnection, transactionID string, config *queryconfig.QueryConfig) error {
+	instance, err := mqe.getTransaction(dbConn, transactionID, true, config)
+	if err != nil {
+		return err
+	}
+	defer instance.close()
+	if err := instance.session.CommitTransaction(context.Background()); err != nil {
+		return err
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn("session.commitTransaction()")
+	}
+	return nil
+}
+
+func (mqe *MongoQueryEngine) RollbackTransaction(dbConn *models.DBConnection, transactionID string, config *queryconfig.QueryConfig) error {
+	instance, err := mqe.getTransaction(dbConn, transactionID, true, config)
+	if err != nil {
+		return err
+	}
+	defer instance.close()
+	if err := instance.session.AbortTransaction(context.Background()); err != nil {
+		return err
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn("session.abortTransaction()")
+	}
+	return nil
+}
+
+// getTransaction returns the locked transaction instance, removing it from the open transactions if remove is true.
+// Only the user who began the transaction can use it.
+func (mqe *MongoQueryEngine) getTransaction(dbConn *models.DBConnection, transactionID string, remove bool, config *queryconfig.QueryConfig) (*mongoTransactionInstance, error) {
+	mqe.transactionsMutex.Lock()
+	instance, exists := mqe.openTransactions[transactionID]
+	if !exists || instance.dbConnectionId != dbConn.ID || instance.userID != config.UserID {
+		mqe.transactionsMutex.Unlock()
+		return nil, errors.New("transaction not found, it may have been rolled back after being idle")
+	}
+	if remove {
+		delete(mqe.openTransactions, transactionID)
+	}
+	mqe.transactionsMutex.Unlock()
+	instance.mutex.Lock()
+	return instance, nil
+}
+
+func (instance *mongoTransactionInstance) close() {
+	instance.idleTimer.Stop()
+	instance.session.EndSession(context.Background())
+	instance.mutex.Unlock()
+}
@@ -0,0 +1,62 @@
+package mysqlqueryengine
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type mysqlDBInstance struct {
+	mysqlDBInstance *sql.DB
+	LastUsed        time.Time
+}
+
+func (myEngine *MysqlQueryEngine) getConnection(dbConnectionId, host string, port uint16, database, user, password string) (c *sql.DB, err error) {
+	if conn, exists := myEngine.openConnections[dbConnectionId]; exists {
+		myEngine.openConnections[dbConnectionId] = mysqlDBInstance{
+			mysqlDBInstance: conn.mysqlDBInstance,
+			LastUsed:        time.Now(),
+		}
+		return conn.mysqlDBInstance, nil
+	}
+	config := mysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = host + ":" + strconv.Itoa(int(port))
+	config.DBName = database
+	config.User = user
+	config.Passwd = password
+	db, err := sql.Open("mysql", config.FormatDSN())
+	if err != nil {
+		err = fmt.Errorf("unable to connect to database: %v", err)
+		return
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		err = fmt.Errorf("unable to connect to database: %v", err)
+		return
+	}
+	if dbConnectionId != "" {
+		myEngine.openConnections[dbConnectionId] = mysqlDBInstance{
+			mysqlDBInstance: db,
+			LastUsed:        time.Now(),
+		}
+	}
+	return db, err
+}
+
+func (myEngine *MysqlQueryEngine) RemoveUnusedConnections() {
+	for {
+		time.Sleep(time.Minute * time.Duration(5))
+		for dbConnID, instance := range myEngine.openConnections {
+			now := time.Now()
+			diff := now.Sub(instance.LastUsed)
+			if diff.Minutes() > 20 {
+				delete(myEngine.openConnections, dbConnID)
+				go instance.mysqlDBInstance.Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package mysqlqueryengine
+
+import (
+	"fmt"
+
+	"slashbase.com/backend/pkg/queryengines/mysqlqueryengine/mysqlutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+func buildDBDataModel(tableData map[string]interface{}) *qemodels.DBDataModel {
+	view := qemodels.DBDataModel{
+		Name:       tableData["0"].(string),
+		SchemaName: tableData["1"].(string),
+		Kind:       qemodels.DATA_MODEL_KIND_TABLE,
+	}
+	return &view
+}
+
+func buildDBDataModelField(fieldData map[string]interface{}) *qemodels.DBDataModelField {
+	view := qemodels.DBDataModelField{
+		Name:       fieldData["name"].(string),
+		Type:       fieldData["type"].(string),
+		IsNullable: fieldData["isNullable"].(bool),
+		IsPrimary:  fieldData["isPrimary"].(bool),
+		Tags:       fieldData["tags"].([]string),
+	}
+	if defaultValue, ok := fieldData["default"].(string); ok {
+		view.Default = &defaultValue
+	}
+	return &view
+}
+
+func buildDBDataModelIndex(tableName string, indexData map[string]interface{}) *qemodels.DBDataModelIndex {
+	indexName := indexData["0"].(string)
+	unique := ""
+	if fmt.Sprint(indexData["1"]) == "0" {
+		unique = "UNIQUE "
+	}
+	view := qemodels.DBDataModelIndex{
+		Name: indexName,
+		IndexDef: fmt.Sprintf("CREATE %sINDEX %s ON %s USING %s (%s)", unique, mysqlutils.QuoteIdentifier(indexName),
+			mysqlutils.QuoteIdentifier(tableName), indexData["2"], indexData["3"]),
+	}
+	return &view
+}
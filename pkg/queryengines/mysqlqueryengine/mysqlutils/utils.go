@@ -0,0 +1,320 @@
+package mysqlutils
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"slashbase.com/backend/internal/utils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+// MySqlColumnsMetadata returns the type and, when the driver knows it, the nullability of the columns of the rows.
+func MySqlColumnsMetadata(rows *sql.Rows) ([]*qemodels.ColumnMetadata, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	columns := []*qemodels.ColumnMetadata{}
+	for _, columnType := range columnTypes {
+		column := &qemodels.ColumnMetadata{
+			Name: columnType.Name(),
+			Type: columnType.DatabaseTypeName(),
+		}
+		if nullable, ok := columnType.Nullable(); ok {
+			column.Nullable = &nullable
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// MySqlRowsToJson reads the rows as json, stopping after maxRows rows (0 for no limit).
+// truncated is true when there were more rows.
+func MySqlRowsToJson(rows *sql.Rows, maxRows int) ([]string, []map[string]interface{}, bool, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	columns := []string{}
+	for _, col := range columnTypes {
+		columns = append(columns, col.Name())
+	}
+
+	count := len(columns)
+	tableData := make([]map[string]interface{}, 0)
+
+	values := make([]interface{}, count)
+	valuePtrs := make([]interface{}, count)
+	for rows.Next() {
+		if maxRows > 0 && len(tableData) == maxRows {
+			return columns, tableData, true, nil
+		}
+		for i := 0; i < count; i++ {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, nil, false, err
+		}
+		entry := make(map[string]interface{})
+		for i := range columns {
+			entry[strconv.Itoa(i)] = convertValue(columnTypes[i].DatabaseTypeName(), values[i])
+		}
+		tableData = append(tableData, entry)
+	}
+	return columns, tableData, false, rows.Err()
+}
+
+// convertValue converts the raw value returned by the mysql driver to a json friendly value.
+// The text protocol returns every value as []byte, so numbers are parsed using the column type.
+func convertValue(dbTypeName string, val interface{}) interface{} {
+	b, ok := val.([]byte)
+	if !ok {
+		return val
+	}
+	str := string(b)
+	switch dbTypeName {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR":
+		if number, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return number
+		}
+	case "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT":
+		if number, err := strconv.ParseUint(str, 10, 64); err == nil {
+			return number
+		}
+	case "FLOAT", "DOUBLE":
+		if number, err := strconv.ParseFloat(str, 64); err == nil {
+			return number
+		}
+	}
+	return str
+}
+
+// QuoteIdentifier quotes a table, column or schema name using backticks.
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// FilterQuery compiles the filter tree to a where condition with ? placeholders for the values.
+func FilterQuery(filter *qemodels.Filter) (string, []interface{}) {
+	args := []interface{}{}
+	if filter.IsGroup() {
+		conditions := []string{}
+		for _, condition := range filter.Conditions {
+			query, conditionArgs := FilterQuery(condition)
+			conditions = append(conditions, query)
+			args = append(args, conditionArgs...)
+		}
+		return "(" + strings.Join(conditions, " "+filter.Group+" ") + ")", args
+	}
+	column := QuoteIdentifier(filter.Field)
+	switch filter.Operator {
+	case qemodels.FILTER_OP_IS_NULL, qemodels.FILTER_OP_IS_NOT_NULL:
+		return fmt.Sprintf(`%s %s`, column, filter.Operator), args
+	case qemodels.FILTER_OP_IN, qemodels.FILTER_OP_NOT_IN:
+		placeholders := []string{}
+		for _, value := range filter.Values {
+			placeholders = append(placeholders, "?")
+			args = append(args, value)
+		}
+		return fmt.Sprintf(`%s %s (%s)`, column, filter.Operator, strings.Join(placeholders, ", ")), args
+	case qemodels.FILTER_OP_BETWEEN:
+		args = append(args, filter.Values[0], filter.Values[1])
+		return fmt.Sprintf(`%s BETWEEN ? AND ?`, column), args
+	case qemodels.FILTER_OP_ILIKE:
+		args = append(args, filter.Value)
+		return fmt.Sprintf(`LOWER(%s) LIKE LOWER(?)`, column), args
+	case qemodels.FILTER_OP_NOT_ILIKE:
+		args = append(args, filter.Value)
+		return fmt.Sprintf(`LOWER(%s) NOT LIKE LOWER(?)`, column), args
+	case qemodels.FILTER_OP_REGEX:
+		args = append(args, filter.Value)
+		return fmt.Sprintf(`%s REGEXP ?`, column), args
+	}
+	args = append(args, filter.Value)
+	return fmt.Sprintf(`%s %s ?`, column, filter.Operator), args
+}
+
+// SortQuery builds the order by clause for the sort fields.
+func SortQuery(sort []qemodels.SortField) string {
+	if len(sort) == 0 {
+		return ""
+	}
+	sortColumns := []string{}
+	for _, field := range sort {
+		sortColumns = append(sortColumns, QuoteIdentifier(field.Field)+" "+field.Direction)
+	}
+	return " ORDER BY " + strings.Join(sortColumns, ", ")
+}
+
+// QuoteString quotes a string literal with single quotes.
+func QuoteString(str string) string {
+	str = strings.ReplaceAll(str, `\`, `\\`)
+	return "'" + strings.ReplaceAll(str, "'", "''") + "'"
+}
+
+const (
+	QUERY_READ          = iota
+	QUERY_WRITE         = iota
+	QUERY_MODIFY_SCHEMA = iota
+	QUERY_UNKOWN        = -1
+)
+
+var readKeywords = []string{"SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "WITH", "TABLE", "VALUES"}
+var writeKeywords = []string{"INSERT", "UPDATE", "DELETE", "REPLACE", "LOAD", "CALL", "DO", "HANDLER", "SET", "LOCK", "UNLOCK"}
+var modifySchemaKeywords = []string{"CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "GRANT", "REVOKE"}
+
+func GetMySQLQueryType(query string) (queryType int, isReturningRows bool) {
+	keywords, calls := queryKeywords(query)
+	if len(keywords) == 0 {
+		return QUERY_UNKOWN, false
+	}
+	first := keywords[0]
+	if utils.ContainsString(modifySchemaKeywords, first) {
+		return QUERY_MODIFY_SCHEMA, false
+	}
+	if utils.ContainsString(writeKeywords, first) {
+		return QUERY_WRITE, first == "CALL"
+	}
+	if utils.ContainsString(readKeywords, first) {
+		// CTEs and selects can still write data, e.g. WITH ... DELETE or SELECT ... INTO OUTFILE,
+		// but not with the INSERT and REPLACE string functions
+		for i, keyword := range keywords {
+			if utils.ContainsString([]string{"INSERT", "UPDATE", "DELETE", "REPLACE"}, keyword) && !calls[i] {
+				return QUERY_WRITE, first != "WITH"
+			}
+			if keyword == "INTO" && i+1 < len(keywords) && (keywords[i+1] == "OUTFILE" || keywords[i+1] == "DUMPFILE") {
+				return QUERY_WRITE, false
+			}
+		}
+		return QUERY_READ, true
+	}
+	return QUERY_UNKOWN, false
+}
+
+// queryKeywords returns the upper cased bare words in the query, skipping comments, string literals
+// and quoted identifiers, and whether each word is called like a function, followed by a parenthesis.
+func queryKeywords(query string) ([]string, []bool) {
+	keywords := []string{}
+	calls := []bool{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '#' || (r == '-' && i+2 < len(runes) && runes[i+1] == '-' && unicode.IsSpace(runes[i+2])):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i++
+		case r == '\'' || r == '"' || r == '`':
+			quote := r
+			i++
+			for i < len(runes) {
+				if runes[i] == '\\' && quote != '`' {
+					i += 2
+					continue
+				}
+				if runes[i] == quote {
+					if i+1 < len(runes) && runes[i+1] == quote {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			keywords = append(keywords, strings.ToUpper(string(runes[start:i])))
+			next := i
+			for next < len(runes) && unicode.IsSpace(runes[next]) {
+				next++
+			}
+			calls = append(calls, next < len(runes) && runes[next] == '(')
+			i--
+		}
+	}
+	return keywords, calls
+}
+
+func QueryToDataModel(fieldQueryData []map[string]interface{}, constraintsQueryData []map[string]interface{}) []map[string]interface{} {
+	fields := []map[string]interface{}{}
+
+	constraintMap := map[string][]map[string]interface{}{}
+	for _, constraint := range constraintsQueryData {
+		columnName := constraint["2"].(string)
+		constraintMap[columnName] = append(constraintMap[columnName], constraint)
+	}
+
+	for _, fieldData := range fieldQueryData {
+		name := fieldData["1"].(string)
+		field := map[string]interface{}{
+			"name":       name,
+			"type":       fieldData["2"].(string),
+			"isNullable": fieldData["3"].(string) == "YES",
+			"isPrimary":  false,
+		}
+		tags := []string{}
+		for _, constraint := range constraintMap[name] {
+			switch constraint["1"].(string) {
+			case "PRIMARY KEY":
+				field["isPrimary"] = true
+			case "UNIQUE":
+				tags = append(tags, "Unique")
+			case "FOREIGN KEY":
+				tags = append(tags, "Foreign Key: "+constraint["0"].(string))
+			case "CHECK":
+				tags = append(tags, "Check: "+constraint["0"].(string))
+			}
+		}
+		if fieldData["4"] != nil {
+			field["default"] = fieldData["4"]
+			tags = append(tags, "Default: "+fieldData["4"].(string))
+		}
+		if fieldData["5"] != nil {
+			tags = append(tags, "Max Length: "+fmt.Sprint(fieldData["5"]))
+		}
+		if extra, ok := fieldData["6"].(string); ok && extra != "" {
+			tags = append(tags, "Extra: "+extra)
+		}
+		field["tags"] = tags
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// QueryToForeignKeys converts the rows of the foreign keys query, one per column of a foreign key ordered by
+// constraint name and position. The columns are the constraint name, the column, the referenced schema,
+// table and column, and the delete and update rules.
+func QueryToForeignKeys(foreignKeysQueryData []map[string]interface{}) []qemodels.DBDataModelForeignKey {
+	foreignKeys := []qemodels.DBDataModelForeignKey{}
+	for _, foreignKeyData := range foreignKeysQueryData {
+		name := foreignKeyData["0"].(string)
+		if len(foreignKeys) == 0 || foreignKeys[len(foreignKeys)-1].Name != name {
+			foreignKeys = append(foreignKeys, qemodels.DBDataModelForeignKey{
+				Name:                 name,
+				Columns:              []string{},
+				ReferencedSchemaName: foreignKeyData["2"].(string),
+				ReferencedName:       foreignKeyData["3"].(string),
+				ReferencedColumns:    []string{},
+				OnDelete:             foreignKeyData["5"].(string),
+				OnUpdate:             foreignKeyData["6"].(string),
+			})
+		}
+		foreignKey := &foreignKeys[len(foreignKeys)-1]
+		foreignKey.Columns = append(foreignKey.Columns, foreignKeyData["1"].(string))
+		foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, foreignKeyData["4"].(string))
+	}
+	return foreignKeys
+}
@@ -0,0 +1,108 @@
+package mysqlutils
+
+import (
+	"testing"
+
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+func TestReadMySQLType(t *testing.T) {
+	sql := `SELECT * FROM users WHERE bio = 'UPDATE'`
+	stype, isReturningRows := GetMySQLQueryType(sql)
+	if stype != QUERY_READ || !isReturningRows {
+		t.Error("stype:", stype)
+	}
+}
+
+func TestWriteMySQLType(t *testing.T) {
+	sql := "UPDATE `users` SET bio = 'SELECT' WHERE name = 'ALTER'"
+	stype, _ := GetMySQLQueryType(sql)
+	if stype != QUERY_WRITE {
+		t.Error("stype:", stype)
+	}
+}
+
+func TestModifySchemaMySQLType(t *testing.T) {
+	sql := `-- create the users table
+	CREATE TABLE users(
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) UNIQUE NOT NULL
+	);`
+	stype, _ := GetMySQLQueryType(sql)
+	if stype != QUERY_MODIFY_SCHEMA {
+		t.Error("stype:", stype)
+	}
+}
+
+func TestWriteInsideReadMySQLType(t *testing.T) {
+	sql := `WITH old AS (SELECT id FROM users) DELETE FROM users WHERE id IN (SELECT id FROM old)`
+	stype, _ := GetMySQLQueryType(sql)
+	if stype != QUERY_WRITE {
+		t.Error("stype:", stype)
+	}
+	sql = `SELECT * FROM users INTO OUTFILE '/tmp/users.txt'`
+	stype, _ = GetMySQLQueryType(sql)
+	if stype != QUERY_WRITE {
+		t.Error("stype:", stype)
+	}
+}
+
+func TestStringFunctionsMySQLType(t *testing.T) {
+	queries := []string{
+		`SELECT REPLACE(name, 'a', 'b') FROM users`,
+		`SELECT INSERT(name, 1, 2, 'x') FROM users`,
+		`WITH x AS (SELECT name FROM users) SELECT REPLACE (name, 'a', 'b') FROM x`,
+	}
+	for _, sql := range queries {
+		stype, isReturningRows := GetMySQLQueryType(sql)
+		if stype != QUERY_READ || !isReturningRows {
+			t.Error("stype:", stype, sql)
+		}
+	}
+	sql := `WITH x AS (SELECT REPLACE(name, 'a', 'b') AS name FROM users) UPDATE users SET name = 'x'`
+	stype, _ := GetMySQLQueryType(sql)
+	if stype != QUERY_WRITE {
+		t.Error("stype:", stype)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	if quoted := QuoteIdentifier("my`table"); quoted != "`my``table`" {
+		t.Error("quoted:", quoted)
+	}
+}
+
+func TestFilterQuery(t *testing.T) {
+	filter := &qemodels.Filter{
+		Group: qemodels.FILTER_GROUP_AND,
+		Conditions: []*qemodels.Filter{
+			{Field: "select", Operator: qemodels.FILTER_OP_NOT_ILIKE, Value: "%it's%"},
+			{Field: "id", Operator: qemodels.FILTER_OP_NOT_IN, Values: []interface{}{1.0, 2.0}},
+			{Field: "email", Operator: qemodels.FILTER_OP_REGEX, Value: "@example\\.com$"},
+		},
+	}
+	condition, args := FilterQuery(filter)
+	if condition != "(LOWER(`select`) NOT LIKE LOWER(?) AND `id` NOT IN (?, ?) AND `email` REGEXP ?)" {
+		t.Error("condition:", condition)
+	}
+	if len(args) != 4 || args[0] != "%it's%" {
+		t.Error("args:", args)
+	}
+}
+
+func TestQueryToForeignKeys(t *testing.T) {
+	foreignKeys := QueryToForeignKeys([]map[string]interface{}{
+		{"0": "fk_team", "1": "team_id", "2": "app", "3": "teams", "4": "id", "5": "CASCADE", "6": "RESTRICT"},
+		{"0": "fk_team", "1": "org_id", "2": "app", "3": "teams", "4": "org_id", "5": "CASCADE", "6": "RESTRICT"},
+		{"0": "fk_manager", "1": "manager_id", "2": "app", "3": "users", "4": "id", "5": "SET NULL", "6": "NO ACTION"},
+	})
+	if len(foreignKeys) != 2 {
+		t.Fatal("foreignKeys:", foreignKeys)
+	}
+	if team := foreignKeys[0]; team.Name != "fk_team" || len(team.Columns) != 2 || team.ReferencedColumns[1] != "org_id" || team.OnDelete != "CASCADE" || team.OnUpdate != "RESTRICT" {
+		t.Error("team:", team)
+	}
+	if manager := foreignKeys[1]; manager.ReferencedName != "users" || len(manager.Columns) != 1 || manager.OnDelete != "SET NULL" {
+		t.Error("manager:", manager)
+	}
+}
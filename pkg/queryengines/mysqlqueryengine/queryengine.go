@@ -0,0 +1,415 @@
+package mysqlqueryengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/mysqlqueryengine/mysqlutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+	"slashbase.com/backend/pkg/sbsql"
+	"slashbase.com/backend/pkg/sshtunnel"
+)
+
+// ROW_ID_COLUMN is the column returned by GetData with the primary key values of the row as json,
+// it is used as the row id for UpdateSingleData and DeleteData.
+const ROW_ID_COLUMN = "_rowid"
+
+type MysqlQueryEngine struct {
+	openConnections map[string]mysqlDBInstance
+}
+
+func InitMysqlQueryEngine() *MysqlQueryEngine {
+	return &MysqlQueryEngine{
+		openConnections: map[string]mysqlDBInstance{},
+	}
+}
+
+func (myqe *MysqlQueryEngine) RunQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	return myqe.runQuery(dbConn, query, nil, config)
+}
+
+func (myqe *MysqlQueryEngine) runQuery(dbConn *models.DBConnection, query string, args []interface{}, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	port, _ := strconv.Atoi(string(dbConn.DBPort))
+	if dbConn.UseSSH != models.DBUSESSH_NONE {
+		remoteHost := string(dbConn.DBHost)
+		if remoteHost == "" {
+			remoteHost = "localhost"
+		}
+		sshTun := sshtunnel.GetSSHTunnel(dbConn.ID, dbConn.UseSSH,
+			string(dbConn.SSHHost), remoteHost, port, string(dbConn.SSHUser),
+			string(dbConn.SSHPassword), string(dbConn.SSHKeyFile),
+		)
+		dbConn.DBHost = sbsql.CryptedData("localhost")
+		dbConn.DBPort = sbsql.CryptedData(fmt.Sprintf("%d", sshTun.GetLocalEndpoint().Port))
+	}
+	port, _ = strconv.Atoi(string(dbConn.DBPort))
+	conn, err := myqe.getConnection(dbConn.ID, string(dbConn.DBHost), uint16(port), string(dbConn.DBName), string(dbConn.DBUser), string(dbConn.DBPassword))
+	if err != nil {
+		return nil, err
+	}
+
+	queryType, isReturningRows := mysqlutils.GetMySQLQueryType(query)
+
+	if queryType != mysqlutils.QUERY_READ && config.ReadOnly {
+		return nil, errors.New("not allowed run this query")
+	}
+
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+
+	if isReturningRows {
+		rows, err := conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		columnsMetadata, err := mysqlutils.MySqlColumnsMetadata(rows)
+		if err != nil {
+			return nil, err
+		}
+		columns, rowsData, truncated, err := mysqlutils.MySqlRowsToJson(rows, config.MaxRows)
+		if err != nil {
+			return nil, err
+		}
+		if config.CreateLogFn != nil {
+			config.CreateLogFn(query)
+		}
+		return map[string]interface{}{
+			"columns":         columns,
+			"columnsMetadata": columnsMetadata,
+			"rows":            rowsData,
+			"truncated":       truncated,
+		}, nil
+	}
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(query)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	lastInsertId, _ := result.LastInsertId()
+	return map[string]interface{}{
+		"message":      fmt.Sprintf("%d rows affected", rowsAffected),
+		"lastInsertId": lastInsertId,
+	}, nil
+}
+
+func (myqe *MysqlQueryEngine) TestConnection(dbConn *models.DBConnection, config *queryconfig.QueryConfig) bool {
+	query := "SELECT 1 AS test;"
+	data, err := myqe.RunQuery(dbConn, query, config)
+	if err != nil {
+		return false
+	}
+	test := data["rows"].([]map[string]interface{})[0]["0"].(int64)
+	return test == 1
+}
+
+func (myqe *MysqlQueryEngine) GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBDataModel, error) {
+	data, err := myqe.RunQuery(dbConn, "SELECT table_name, table_schema FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name;", config)
+	if err != nil {
+		return nil, err
+	}
+	dataModels := []*qemodels.DBDataModel{}
+	for _, table := range data["rows"].([]map[string]interface{}) {
+		dataModels = append(dataModels, buildDBDataModel(table))
+	}
+	return dataModels, nil
+}
+
+func (myqe *MysqlQueryEngine) GetSingleDataModel(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (*qemodels.DBDataModel, error) {
+	fieldsData, err := myqe.GetSingleDataModelFields(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	indexesData, err := myqe.GetSingleDataModelIndexes(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeysData, err := myqe.GetSingleDataModelForeignKeys(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	allFields := []qemodels.DBDataModelField{}
+	for _, field := range fieldsData {
+		allFields = append(allFields, *buildDBDataModelField(field))
+	}
+	allIndexes := []qemodels.DBDataModelIndex{}
+	for _, index := range indexesData {
+		allIndexes = append(allIndexes, *buildDBDataModelIndex(name, index))
+	}
+	dataModel := qemodels.DBDataModel{
+		SchemaName:  schema,
+		Name:        name,
+		Kind:        qemodels.DATA_MODEL_KIND_TABLE,
+		Fields:      allFields,
+		Indexes:     allIndexes,
+		ForeignKeys: mysqlutils.QueryToForeignKeys(foreignKeysData),
+	}
+	return &dataModel, nil
+}
+
+func (myqe *MysqlQueryEngine) GetSingleDataModelFields(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	// get fields
+	query := `SELECT ordinal_position, column_name, column_type, is_nullable, column_default, character_maximum_length, extra
+		FROM information_schema.columns
+		WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?
+		ORDER BY ordinal_position;`
+	data, err := myqe.runQuery(dbConn, query, []interface{}{schema, name}, config)
+	if err != nil {
+		return nil, err
+	}
+	fieldsData := data["rows"].([]map[string]interface{})
+	// get constraints
+	query = `SELECT tc.constraint_name, tc.constraint_type, kcu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON tc.constraint_schema = kcu.constraint_schema AND tc.table_name = kcu.table_name AND tc.constraint_name = kcu.constraint_name
+		WHERE tc.table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND tc.table_name = ?;`
+	data, err = myqe.runQuery(dbConn, query, []interface{}{schema, name}, config)
+	if err != nil {
+		return nil, err
+	}
+	constraintsData := data["rows"].([]map[string]interface{})
+	return mysqlutils.QueryToDataModel(fieldsData, constraintsData), err
+}
+
+func (myqe *MysqlQueryEngine) GetSingleDataModelIndexes(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	query := "SELECT index_name, non_unique, index_type, GROUP_CONCAT(CONCAT('`', REPLACE(column_name, '`', '``'), '`') ORDER BY seq_in_index SEPARATOR ', ') " +
+		`FROM information_schema.statistics
+		WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?
+		GROUP BY index_name, non_unique, index_type;`
+	data, err := myqe.runQuery(dbConn, query, []interface{}{schema, name}, config)
+	if err != nil {
+		return nil, err
+	}
+	returnedData := data["rows"].([]map[string]interface{})
+	return returnedData, err
+}
+
+func (myqe *MysqlQueryEngine) GetSingleDataModelForeignKeys(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	query := `SELECT kcu.constraint_name, kcu.column_name, kcu.referenced_table_schema, kcu.referenced_table_name, kcu.referenced_column_name,
+			rc.delete_rule, rc.update_rule
+		FROM information_schema.key_column_usage kcu
+		JOIN information_schema.referential_constraints rc
+			ON rc.constraint_schema = kcu.constraint_schema AND rc.table_name = kcu.table_name AND rc.constraint_name = kcu.constraint_name
+		WHERE kcu.table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND kcu.table_name = ?
+		ORDER BY kcu.constraint_name, kcu.ordinal_position;`
+	data, err := myqe.runQuery(dbConn, query, []interface{}{schema, name}, config)
+	if err != nil {
+		return nil, err
+	}
+	returnedData := data["rows"].([]map[string]interface{})
+	return returnedData, err
+}
+
+func (myqe *MysqlQueryEngine) getPrimaryKeys(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]string, error) {
+	query := `SELECT column_name FROM information_schema.key_column_usage
+		WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ? AND constraint_name = 'PRIMARY'
+		ORDER BY ordinal_position;`
+	data, err := myqe.runQuery(dbConn, query, []interface{}{schema, name}, config)
+	if err != nil {
+		return nil, err
+	}
+	primaryKeys := []string{}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		primaryKeys = append(primaryKeys, row["0"].(string))
+	}
+	return primaryKeys, nil
+}
+
+func (myqe *MysqlQueryEngine) AddSingleDataModelField(dbConn *models.DBConnection, schema, name, columnName, dataType string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s;`, tableIdentifier(schema, name), mysqlutils.QuoteIdentifier(columnName), dataType)
+	return myqe.RunQuery(dbConn, query, config)
+}
+
+func (myqe *MysqlQueryEngine) DeleteSingleDataModelField(dbConn *models.DBConnection, schema, name, columnName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf(`ALTER TABLE %s DROP COLUMN %s;`, tableIdentifier(schema, name), mysqlutils.QuoteIdentifier(columnName))
+	return myqe.RunQuery(dbConn, query, config)
+}
+
+func (myqe *MysqlQueryEngine) CreateDataModel(dbConn *models.DBConnection, schema string, spec *qemodels.DataModelSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := spec.CreateTableQuery(tableIdentifier(schema, spec.Name), mysqlutils.QuoteIdentifier)
+	return myqe.runQuery(dbConn, query, nil, config)
+}
+
+func (myqe *MysqlQueryEngine) RenameDataModel(dbConn *models.DBConnection, schema, name, newName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("RENAME TABLE %s TO %s;", tableIdentifier(schema, name), tableIdentifier(schema, newName))
+	return myqe.runQuery(dbConn, query, nil, config)
+}
+
+func (myqe *MysqlQueryEngine) TruncateDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("TRUNCATE TABLE %s;", tableIdentifier(schema, name))
+	return myqe.runQuery(dbConn, query, nil, config)
+}
+
+func (myqe *MysqlQueryEngine) DropDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("DROP TABLE %s;", tableIdentifier(schema, name))
+	return myqe.runQuery(dbConn, query, nil, config)
+}
+
+func (myqe *MysqlQueryEngine) GetData(dbConn *models.DBConnection, schema string, name string, limit int, offset int64, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	primaryKeys, err := myqe.getPrimaryKeys(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	selectQuery := "*"
+	if len(primaryKeys) > 0 {
+		rowIDArgs := []string{}
+		for _, key := range primaryKeys {
+			rowIDArgs = append(rowIDArgs, fmt.Sprintf(`%s, %s`, mysqlutils.QuoteString(key), mysqlutils.QuoteIdentifier(key)))
+		}
+		selectQuery = fmt.Sprintf(`JSON_OBJECT(%s) AS %s, t.*`, strings.Join(rowIDArgs, ", "), mysqlutils.QuoteIdentifier(ROW_ID_COLUMN))
+	}
+	whereQuery := ""
+	args := []interface{}{}
+	if filter != nil {
+		condition, filterArgs := mysqlutils.FilterQuery(filter)
+		whereQuery = " WHERE " + condition
+		args = append(args, filterArgs...)
+	}
+	sortQuery := mysqlutils.SortQuery(sort)
+	query := fmt.Sprintf(`SELECT %s FROM %s AS t%s%s LIMIT ? OFFSET ?;`, selectQuery, tableIdentifier(schema, name), whereQuery, sortQuery)
+	data, err := myqe.runQuery(dbConn, query, append(args, limit, offset), config)
+	if err != nil {
+		return nil, err
+	}
+	if fetchCount {
+		countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s AS t%s;`, tableIdentifier(schema, name), whereQuery)
+		countData, err := myqe.runQuery(dbConn, countQuery, args, config)
+		if err != nil {
+			return nil, err
+		}
+		data["count"] = countData["rows"].([]map[string]interface{})[0]["0"]
+	}
+	// the rows of the tables without a primary key have no row id to be edited by
+	data["editable"] = len(primaryKeys) > 0
+	return data, err
+}
+
+func (myqe *MysqlQueryEngine) UpdateSingleData(dbConn *models.DBConnection, schema string, name string, rowID string, columnName string, value string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	primaryKeys, err := myqe.getPrimaryKeys(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	rowIDValues, whereQuery, whereArgs, err := rowIDToCondition(primaryKeys, rowID)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE %s LIMIT 1;`, tableIdentifier(schema, name), mysqlutils.QuoteIdentifier(columnName), whereQuery)
+	_, err = myqe.runQuery(dbConn, query, append([]interface{}{value}, whereArgs...), config)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := rowIDValues[columnName]; exists {
+		rowIDValues[columnName] = value
+	}
+	newRowID, err := json.Marshal(rowIDValues)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{
+		"rowId": string(newRowID),
+	}
+	return data, err
+}
+
+func (myqe *MysqlQueryEngine) AddData(dbConn *models.DBConnection, schema string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (*qemodels.AddDataResponse, error) {
+	primaryKeys, err := myqe.getPrimaryKeys(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	placeholders := []string{}
+	values := []interface{}{}
+	for key, value := range data {
+		keys = append(keys, mysqlutils.QuoteIdentifier(key))
+		placeholders = append(placeholders, "?")
+		values = append(values, value)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s(%s) VALUES(%s);`, tableIdentifier(schema, name), strings.Join(keys, ", "), strings.Join(placeholders, ", "))
+	rData, err := myqe.runQuery(dbConn, query, values, config)
+	if err != nil {
+		return nil, err
+	}
+	rowIDValues := map[string]interface{}{}
+	for _, key := range primaryKeys {
+		if value, exists := data[key]; exists {
+			rowIDValues[key] = value
+		} else if lastInsertId := rData["lastInsertId"].(int64); lastInsertId > 0 {
+			rowIDValues[key] = lastInsertId
+		}
+	}
+	newRowID, err := json.Marshal(rowIDValues)
+	if err != nil {
+		return nil, err
+	}
+	return &qemodels.AddDataResponse{NewID: string(newRowID)}, err
+}
+
+func (myqe *MysqlQueryEngine) DeleteData(dbConn *models.DBConnection, schema string, name string, rowIDs []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	if len(rowIDs) == 0 {
+		return nil, errors.New("no rows to delete")
+	}
+	primaryKeys, err := myqe.getPrimaryKeys(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	conditions := []string{}
+	args := []interface{}{}
+	for _, rowID := range rowIDs {
+		_, whereQuery, whereArgs, err := rowIDToCondition(primaryKeys, rowID)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "("+whereQuery+")")
+		args = append(args, whereArgs...)
+	}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s;`, tableIdentifier(schema, name), strings.Join(conditions, " OR "))
+	return myqe.runQuery(dbConn, query, args, config)
+}
+
+func tableIdentifier(schema, name string) string {
+	if schema == "" {
+		return mysqlutils.QuoteIdentifier(name)
+	}
+	return mysqlutils.QuoteIdentifier(schema) + "." + mysqlutils.QuoteIdentifier(name)
+}
+
+// rowIDToCondition parses the json row id returned by GetData
+// and returns the where condition to select the row with its arguments.
+func rowIDToCondition(primaryKeys []string, rowID string) (map[string]interface{}, string, []interface{}, error) {
+	if len(primaryKeys) == 0 {
+		return nil, "", nil, errors.New("table does not have a primary key")
+	}
+	rowIDValues := map[string]interface{}{}
+	// numbers are decoded as json.Number, a float64 loses the precision of big integer keys
+	decoder := json.NewDecoder(strings.NewReader(rowID))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rowIDValues); err != nil {
+		return nil, "", nil, errors.New("invalid row id")
+	}
+	if len(rowIDValues) != len(primaryKeys) {
+		return nil, "", nil, errors.New("invalid row id")
+	}
+	conditions := []string{}
+	args := []interface{}{}
+	for _, key := range primaryKeys {
+		value, exists := rowIDValues[key]
+		if !exists {
+			return nil, "", nil, errors.New("invalid row id")
+		}
+		if number, isNumber := value.(json.Number); isNumber {
+			value = number.String()
+		}
+		conditions = append(conditions, mysqlutils.QuoteIdentifier(key)+" = ?")
+		args = append(args, value)
+	}
+	return rowIDValues, strings.Join(conditions, " AND "), args, nil
+}
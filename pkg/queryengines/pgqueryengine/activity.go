@@ -0,0 +1,129 @@
+package pgqueryengine
+
+import (
+	"errors"
+	"fmt"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// GetDBSessions returns the sessions of the clients connected to the server from pg_stat_activity, the longest
+// running first. The session running this query is not returned and the queries of the sessions of other
+// users are only visible to superusers and members of pg_read_all_stats.
+func (pgqe *PostgresQueryEngine) GetDBSessions(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBSession, error) {
+	query := `SELECT pid, datname, usename, application_name, host(client_addr), state, wait_event_type, wait_event, query,
+			(extract(epoch FROM clock_timestamp() - CASE WHEN state = 'active' THEN query_start ELSE state_change END) * 1000)::bigint
+		FROM pg_catalog.pg_stat_activity
+		WHERE backend_type = 'client backend' AND pid <> pg_backend_pid()
+		ORDER BY 10 DESC NULLS LAST;`
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	toString := func(value interface{}) string {
+		text, _ := value.(string)
+		return text
+	}
+	sessions := []*qemodels.DBSession{}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		session := qemodels.DBSession{
+			PID:             row["0"].(int32),
+			DatabaseName:    toString(row["1"]),
+			User:            toString(row["2"]),
+			ApplicationName: toString(row["3"]),
+			ClientAddress:   toString(row["4"]),
+			State:           toString(row["5"]),
+			WaitEventType:   toString(row["6"]),
+			WaitEvent:       toString(row["7"]),
+			Query:           toString(row["8"]),
+		}
+		if duration, ok := row["9"].(int64); ok {
+			session.Duration = &duration
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, nil
+}
+
+// CancelDBSession cancels the query of the session with pg_cancel_backend, or terminates
+// the session with pg_terminate_backend.
+func (pgqe *PostgresQueryEngine) CancelDBSession(dbConn *models.DBConnection, pid int32, terminate bool, config *queryconfig.QueryConfig) error {
+	function := "pg_cancel_backend"
+	if terminate {
+		function = "pg_terminate_backend"
+	}
+	data, err := pgqe.runQuery(dbConn, fmt.Sprintf("SELECT %s(%d);", function, pid), nil, config)
+	if err != nil {
+		return err
+	}
+	if signalled, _ := data["rows"].([]map[string]interface{})[0]["0"].(bool); !signalled {
+		return errors.New("session not found, it may have already ended")
+	}
+	return nil
+}
+
+// GetDBLocks returns the sessions which block or are blocked by other sessions with their locks from pg_locks,
+// and the trees of their blocking chains from pg_blocking_pids. The names of the locked relations are only
+// resolved in the database of the connection.
+func (pgqe *PostgresQueryEngine) GetDBLocks(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (*qemodels.DBLocks, error) {
+	query := `WITH blocking AS (
+			SELECT pid, pg_blocking_pids(pid) AS blocked_by
+			FROM pg_catalog.pg_stat_activity
+			WHERE pid <> pg_backend_pid()
+		)
+		SELECT a.pid, a.datname, a.usename, a.application_name, host(a.client_addr), a.state, a.wait_event_type, a.wait_event, a.query,
+			(extract(epoch FROM clock_timestamp() - CASE WHEN a.state = 'active' THEN a.query_start ELSE a.state_change END) * 1000)::bigint,
+			b.blocked_by, l.locktype, l.mode, l.granted,
+			CASE WHEN l.database = (SELECT oid FROM pg_catalog.pg_database WHERE datname = current_database()) THEN l.relation::regclass::text ELSE l.relation::text END
+		FROM blocking b
+		JOIN pg_catalog.pg_stat_activity a ON a.pid = b.pid
+		JOIN pg_catalog.pg_locks l ON l.pid = b.pid
+		WHERE cardinality(b.blocked_by) > 0 OR b.pid IN (SELECT unnest(blocked_by) FROM blocking)
+		ORDER BY a.pid, l.granted, l.locktype, l.mode;`
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	toString := func(value interface{}) string {
+		text, _ := value.(string)
+		return text
+	}
+	sessions := []*qemodels.DBLockedSession{}
+	var session *qemodels.DBLockedSession
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		if pid := row["0"].(int32); session == nil || session.PID != pid {
+			session = &qemodels.DBLockedSession{
+				DBSession: qemodels.DBSession{
+					PID:             pid,
+					DatabaseName:    toString(row["1"]),
+					User:            toString(row["2"]),
+					ApplicationName: toString(row["3"]),
+					ClientAddress:   toString(row["4"]),
+					State:           toString(row["5"]),
+					WaitEventType:   toString(row["6"]),
+					WaitEvent:       toString(row["7"]),
+					Query:           toString(row["8"]),
+				},
+				Locks:     []qemodels.DBLock{},
+				BlockedBy: []int32{},
+			}
+			if duration, ok := row["9"].(int64); ok {
+				session.Duration = &duration
+			}
+			blockedBy, _ := row["10"].([]interface{})
+			for _, blockingPID := range blockedBy {
+				session.BlockedBy = append(session.BlockedBy, blockingPID.(int32))
+			}
+			sessions = append(sessions, session)
+		}
+		session.Locks = append(session.Locks, qemodels.DBLock{
+			LockType: toString(row["11"]),
+			Mode:     toString(row["12"]),
+			Granted:  row["13"].(bool),
+			Relation: toString(row["14"]),
+		})
+	}
+	return qemodels.NewDBLocks(sessions), nil
+}
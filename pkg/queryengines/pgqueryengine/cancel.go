@@ -0,0 +1,96 @@
+package pgqueryengine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// pgxRunningQuery is a query started with a query id, it pins one connection of the pool
+// so that it can be cancelled with the pid of its backend.
+type pgxRunningQuery struct {
+	dbConnectionId string
+	pid            uint32
+	finished       bool
+	mutex          sync.Mutex
+}
+
+func (pgqe *PostgresQueryEngine) runCancellableQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	pool, err := pgqe.getPool(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pool.Acquire(config.GetContext())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	removeRunningQuery, err := pgqe.addRunningQuery(dbConn, conn, config.QueryID)
+	if err != nil {
+		return nil, err
+	}
+	defer removeRunningQuery()
+
+	return runQueryOn(conn, query, nil, config)
+}
+
+// addRunningQuery registers the query running on the connection, the returned function removes it
+// and must be called before the connection is released.
+func (pgqe *PostgresQueryEngine) addRunningQuery(dbConn *models.DBConnection, conn *pgxpool.Conn, queryID string) (func(), error) {
+	running := &pgxRunningQuery{
+		dbConnectionId: dbConn.ID,
+		pid:            conn.Conn().PgConn().PID(),
+	}
+	pgqe.runningQueriesMutex.Lock()
+	if _, exists := pgqe.runningQueries[queryID]; exists {
+		pgqe.runningQueriesMutex.Unlock()
+		return nil, errors.New("query id is already in use")
+	}
+	pgqe.runningQueries[queryID] = running
+	pgqe.runningQueriesMutex.Unlock()
+	return func() {
+		pgqe.runningQueriesMutex.Lock()
+		delete(pgqe.runningQueries, queryID)
+		pgqe.runningQueriesMutex.Unlock()
+		// wait for a cancel in progress, the connection must not be reused before it is done
+		running.mutex.Lock()
+		running.finished = true
+		running.mutex.Unlock()
+	}, nil
+}
+
+// CancelQuery cancels the running query with pg_cancel_backend.
+func (pgqe *PostgresQueryEngine) CancelQuery(dbConn *models.DBConnection, queryID string, config *queryconfig.QueryConfig) error {
+	pgqe.runningQueriesMutex.Lock()
+	running, exists := pgqe.runningQueries[queryID]
+	pgqe.runningQueriesMutex.Unlock()
+	if !exists || running.dbConnectionId != dbConn.ID {
+		return errors.New("query not found, it may have already finished")
+	}
+	running.mutex.Lock()
+	defer running.mutex.Unlock()
+	if running.finished {
+		return errors.New("query not found, it may have already finished")
+	}
+	pool, err := pgqe.getPool(dbConn)
+	if err != nil {
+		return err
+	}
+	var cancelled bool
+	err = pool.QueryRow(config.GetContext(), "SELECT pg_cancel_backend($1)", int32(running.pid)).Scan(&cancelled)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return errors.New("query could not be cancelled")
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(fmt.Sprintf("SELECT pg_cancel_backend(%d);", running.pid))
+	}
+	return nil
+}
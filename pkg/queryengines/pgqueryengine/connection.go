@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -23,7 +25,13 @@ func (pxEngine *PostgresQueryEngine) getConnection(dbConnectionId, host string,
 		return conn.pgxConnPoolInstance, nil
 	}
 	connString := fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s", host, strconv.Itoa(int(port)), database, user, password)
-	pool, err := pgxpool.Connect(context.Background(), connString)
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		err = fmt.Errorf("unable to connect to database: %v", err)
+		return
+	}
+	poolConfig.AfterConnect = registerExtensionTypes
+	pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
 	if err != nil {
 		err = fmt.Errorf("unable to connect to database: %v", err)
 		return
@@ -37,6 +45,24 @@ func (pxEngine *PostgresQueryEngine) getConnection(dbConnectionId, host string,
 	return pool, err
 }
 
+// registerExtensionTypes registers the types of extensions installed in the database,
+// which do not have a fixed oid, so that their values are decoded.
+func registerExtensionTypes(ctx context.Context, conn *pgx.Conn) error {
+	rows, err := conn.Query(ctx, "SELECT oid FROM pg_catalog.pg_type WHERE typname = 'hstore'")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var oid uint32
+		if err := rows.Scan(&oid); err != nil {
+			return err
+		}
+		conn.ConnInfo().RegisterDataType(pgtype.DataType{Value: &pgtype.Hstore{}, Name: "hstore", OID: oid})
+	}
+	return rows.Err()
+}
+
 func (pxEngine *PostgresQueryEngine) RemoveUnusedConnections() {
 	for {
 		time.Sleep(time.Minute * time.Duration(5))
@@ -0,0 +1,184 @@
+package pgqueryengine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// userSchemaCondition excludes the system schemas, n is the pg_namespace of the object.
+const userSchemaCondition = `n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%'`
+
+// notExtensionMemberCondition excludes the objects created by an extension, oid is the oid of the object.
+const notExtensionMemberCondition = `NOT EXISTS (SELECT 1 FROM pg_catalog.pg_depend dep WHERE dep.objid = %s AND dep.deptype = 'e')`
+
+type dbObjectDetail struct {
+	name       string
+	expression string
+}
+
+// dbObjectCatalogQuery selects the objects of a kind from the catalog, the expressions are
+// selected from the tables of from, which must end with a WHERE clause.
+type dbObjectCatalogQuery struct {
+	schema     string
+	name       string
+	definition string
+	details    []dbObjectDetail
+	from       string
+}
+
+var dbObjectCatalogQueries = map[string]dbObjectCatalogQuery{
+	qemodels.DB_OBJECT_KIND_FUNCTION: {
+		schema:     "n.nspname",
+		name:       "p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')'",
+		definition: "pg_get_functiondef(p.oid)",
+		details: []dbObjectDetail{
+			{"result", "pg_get_function_result(p.oid)"},
+			{"language", "l.lanname"},
+		},
+		from: `pg_catalog.pg_proc p
+			JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+			JOIN pg_catalog.pg_language l ON l.oid = p.prolang
+			WHERE p.prokind IN ('f', 'w') AND ` + userSchemaCondition + ` AND ` + fmt.Sprintf(notExtensionMemberCondition, "p.oid"),
+	},
+	qemodels.DB_OBJECT_KIND_PROCEDURE: {
+		schema:     "n.nspname",
+		name:       "p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')'",
+		definition: "pg_get_functiondef(p.oid)",
+		details: []dbObjectDetail{
+			{"language", "l.lanname"},
+		},
+		from: `pg_catalog.pg_proc p
+			JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+			JOIN pg_catalog.pg_language l ON l.oid = p.prolang
+			WHERE p.prokind = 'p' AND ` + userSchemaCondition + ` AND ` + fmt.Sprintf(notExtensionMemberCondition, "p.oid"),
+	},
+	qemodels.DB_OBJECT_KIND_SEQUENCE: {
+		schema: "s.schemaname",
+		name:   "s.sequencename",
+		definition: `format('CREATE SEQUENCE %I.%I AS %s INCREMENT BY %s MINVALUE %s MAXVALUE %s START WITH %s CACHE %s%s;',
+			s.schemaname, s.sequencename, s.data_type, s.increment_by, s.min_value, s.max_value, s.start_value, s.cache_size,
+			CASE WHEN s.cycle THEN ' CYCLE' ELSE ' NO CYCLE' END)`,
+		details: []dbObjectDetail{
+			{"dataType", "s.data_type::text"},
+			{"startValue", "s.start_value"},
+			{"incrementBy", "s.increment_by"},
+			{"minValue", "s.min_value"},
+			{"maxValue", "s.max_value"},
+			{"cycle", "s.cycle"},
+			{"lastValue", "s.last_value"},
+		},
+		from: `pg_catalog.pg_sequences s
+			JOIN pg_catalog.pg_namespace n ON n.nspname = s.schemaname
+			WHERE ` + userSchemaCondition,
+	},
+	qemodels.DB_OBJECT_KIND_ENUM: {
+		schema: "n.nspname",
+		name:   "t.typname",
+		definition: `format('CREATE TYPE %I.%I AS ENUM (%s);', n.nspname, t.typname,
+			(SELECT string_agg(quote_literal(e.enumlabel), ', ' ORDER BY e.enumsortorder) FROM pg_catalog.pg_enum e WHERE e.enumtypid = t.oid))`,
+		details: []dbObjectDetail{
+			{"values", "ARRAY(SELECT e.enumlabel::text FROM pg_catalog.pg_enum e WHERE e.enumtypid = t.oid ORDER BY e.enumsortorder)"},
+		},
+		from: `pg_catalog.pg_type t
+			JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+			WHERE t.typtype = 'e' AND ` + userSchemaCondition + ` AND ` + fmt.Sprintf(notExtensionMemberCondition, "t.oid"),
+	},
+	qemodels.DB_OBJECT_KIND_TRIGGER: {
+		schema:     "n.nspname",
+		name:       "tg.tgname || ' ON ' || c.relname",
+		definition: "pg_get_triggerdef(tg.oid, true) || ';'",
+		details: []dbObjectDetail{
+			{"table", "c.relname::text"},
+			{"function", "tg.tgfoid::regproc::text"},
+			{"enabled", "tg.tgenabled <> 'D'"},
+		},
+		from: `pg_catalog.pg_trigger tg
+			JOIN pg_catalog.pg_class c ON c.oid = tg.tgrelid
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE NOT tg.tgisinternal AND ` + userSchemaCondition,
+	},
+	qemodels.DB_OBJECT_KIND_EXTENSION: {
+		schema:     "n.nspname",
+		name:       "e.extname",
+		definition: "format('CREATE EXTENSION IF NOT EXISTS %I WITH SCHEMA %I VERSION %L;', e.extname, n.nspname, e.extversion)",
+		details: []dbObjectDetail{
+			{"version", "e.extversion"},
+			{"relocatable", "e.extrelocatable"},
+		},
+		from: `pg_catalog.pg_extension e
+			JOIN pg_catalog.pg_namespace n ON n.oid = e.extnamespace
+			WHERE true`,
+	},
+}
+
+// GetDBObjects returns the objects of the kind, without their definitions.
+// Functions and procedures are named with their arguments, as they can be overloaded,
+// and triggers with their table, like "name ON table".
+func (pgqe *PostgresQueryEngine) GetDBObjects(dbConn *models.DBConnection, kind string, config *queryconfig.QueryConfig) ([]*qemodels.DBObject, error) {
+	catalogQuery, exists := dbObjectCatalogQueries[kind]
+	if !exists {
+		return nil, errors.New("unknown object kind")
+	}
+	data, err := pgqe.runQuery(dbConn, catalogQuery.build(false), nil, config)
+	if err != nil {
+		return nil, err
+	}
+	dbObjects := []*qemodels.DBObject{}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		dbObjects = append(dbObjects, catalogQuery.buildDBObject(kind, row))
+	}
+	return dbObjects, nil
+}
+
+// GetSingleDBObject returns the object of the kind with its definition.
+func (pgqe *PostgresQueryEngine) GetSingleDBObject(dbConn *models.DBConnection, kind, schema, name string, config *queryconfig.QueryConfig) (*qemodels.DBObject, error) {
+	catalogQuery, exists := dbObjectCatalogQueries[kind]
+	if !exists {
+		return nil, errors.New("unknown object kind")
+	}
+	data, err := pgqe.runQuery(dbConn, catalogQuery.build(true), []interface{}{schema, name}, config)
+	if err != nil {
+		return nil, err
+	}
+	rows := data["rows"].([]map[string]interface{})
+	if len(rows) == 0 {
+		return nil, errors.New("object not found")
+	}
+	return catalogQuery.buildDBObject(kind, rows[0]), nil
+}
+
+// build returns the query selecting the schema, name, definition and details of the objects,
+// single selects the definition of the object with the schema and name given as parameters.
+func (catalogQuery dbObjectCatalogQuery) build(single bool) string {
+	columns := []string{catalogQuery.schema, catalogQuery.name, "NULL"}
+	condition := ""
+	if single {
+		columns[2] = catalogQuery.definition
+		condition = fmt.Sprintf(" AND %s = $1 AND %s = $2", catalogQuery.schema, catalogQuery.name)
+	}
+	for _, detail := range catalogQuery.details {
+		columns = append(columns, detail.expression)
+	}
+	return fmt.Sprintf("SELECT %s\n\t\tFROM %s%s\n\t\tORDER BY 1, 2;", strings.Join(columns, ", "), catalogQuery.from, condition)
+}
+
+func (catalogQuery dbObjectCatalogQuery) buildDBObject(kind string, row map[string]interface{}) *qemodels.DBObject {
+	dbObject := qemodels.DBObject{
+		Kind:       kind,
+		SchemaName: row["0"].(string),
+		Name:       row["1"].(string),
+		Details:    map[string]interface{}{},
+	}
+	if definition, ok := row["2"].(string); ok {
+		dbObject.Definition = definition
+	}
+	for i, detail := range catalogQuery.details {
+		dbObject.Details[detail.name] = row[fmt.Sprint(i+3)]
+	}
+	return &dbObject
+}
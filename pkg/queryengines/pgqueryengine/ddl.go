@@ -0,0 +1,38 @@
+package pgqueryengine
+
+import (
+	"errors"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/pgqueryengine/pgxutils"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// GetSingleDataModelDDL returns the script creating the table with its columns, constraints, indexes,
+// comments and owner.
+func (pgqe *PostgresQueryEngine) GetSingleDataModelDDL(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (string, error) {
+	query := `SELECT pg_get_userbyid(c.relowner), obj_description(c.oid, 'pg_class'), c.relpersistence::text,
+			CASE WHEN c.relkind = 'p' THEN pg_get_partkeydef(c.oid) END, c.relkind::text
+		FROM pg_catalog.pg_class c WHERE c.oid = $1::regclass;`
+	data, err := pgqe.runQuery(dbConn, query, []interface{}{pgxutils.QuoteIdentifier(schema, name)}, config)
+	if err != nil {
+		return "", err
+	}
+	rows := data["rows"].([]map[string]interface{})
+	if len(rows) == 0 {
+		return "", errors.New("data model not found")
+	}
+	tableData := rows[0]
+	if relkind := tableData["4"].(string); relkind != "r" && relkind != "p" {
+		return "", errors.New("DDL can only be generated for tables")
+	}
+	fieldsData, constraintsData, err := pgqe.getSingleDataModelFieldsRows(dbConn, schema, name, config)
+	if err != nil {
+		return "", err
+	}
+	indexesData, err := pgqe.GetSingleDataModelIndexes(dbConn, schema, name, config)
+	if err != nil {
+		return "", err
+	}
+	return pgxutils.CreateTableScript(schema, name, tableData, fieldsData, constraintsData, indexesData), nil
+}
@@ -0,0 +1,523 @@
+package pgqueryengine
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/auxten/postgresql-parser/pkg/sql/parser"
+	"github.com/jackc/pgproto3/v2"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+	"slashbase.com/backend/pkg/sbsql"
+)
+
+// fakePGResult is the result of a query sent by fakePGServer, the values of the columns are text.
+type fakePGResult struct {
+	columns []string
+	rows    [][]string
+	tag     string
+	err     string
+}
+
+// fakePGServer speaks enough of the wire protocol for pgx to run queries, so that the engine can be tested
+// without a database. Queries are answered by results, and logged with the protocol they were sent with.
+// Like postgres, it refuses to prepare a query of more than one statement.
+type fakePGServer struct {
+	listener       net.Listener
+	results        func(query string) fakePGResult
+	mutex          sync.Mutex
+	queries        []string
+	cancelRequests int
+}
+
+func newFakePGServer(t *testing.T, results func(query string) fakePGResult) *fakePGServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakePGServer{listener: listener, results: results}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+// dbConn returns a connection to the server, its id must be unique as the engine keeps a pool per id.
+func (server *fakePGServer) dbConn(id string) *models.DBConnection {
+	address := server.listener.Addr().(*net.TCPAddr)
+	return &models.DBConnection{
+		ID:         id,
+		Type:       models.DBTYPE_POSTGRES,
+		DBHost:     sbsql.CryptedData("127.0.0.1"),
+		DBPort:     sbsql.CryptedData(fmt.Sprint(address.Port)),
+		DBName:     sbsql.CryptedData("db"),
+		DBUser:     sbsql.CryptedData("user"),
+		DBPassword: sbsql.CryptedData("password"),
+		UseSSH:     models.DBUSESSH_NONE,
+	}
+}
+
+// loggedQueries returns the queries received, prefixed with "simple: " or "extended: ".
+func (server *fakePGServer) loggedQueries() []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]string{}, server.queries...)
+}
+
+func (server *fakePGServer) receivedCancelRequests() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.cancelRequests
+}
+
+func (server *fakePGServer) log(query string) {
+	server.mutex.Lock()
+	server.queries = append(server.queries, query)
+	server.mutex.Unlock()
+}
+
+// fakeNull is a NULL value of the rows of fakePGResult.
+const fakeNull = "\x00NULL"
+
+var placeholderRegexp = regexp.MustCompile(`(LIMIT |OFFSET )?\$\d+(::[a-z0-9]+\[\])?`)
+
+// arrayOIDs are the oids of the array types the placeholders are cast to, the placeholders of LIMIT
+// and OFFSET are int8 and the others are text.
+var arrayOIDs = map[string]uint32{"::int2[]": 1005, "::int4[]": 1007, "::int8[]": 1016}
+
+func (server *fakePGServer) serve(conn net.Conn) {
+	defer conn.Close()
+	backend := pgproto3.NewBackend(pgproto3.NewChunkReader(conn), conn)
+	for {
+		msg, err := backend.ReceiveStartupMessage()
+		if err != nil {
+			return
+		}
+		if _, ok := msg.(*pgproto3.SSLRequest); ok {
+			conn.Write([]byte("N"))
+			continue
+		}
+		if _, ok := msg.(*pgproto3.CancelRequest); ok {
+			server.mutex.Lock()
+			server.cancelRequests++
+			server.mutex.Unlock()
+			return
+		}
+		break
+	}
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.ParameterStatus{Name: "server_version", Value: "14.0"})
+	backend.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+	backend.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+	backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 1})
+	txStatus := byte('I')
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: txStatus})
+
+	statements := map[string]string{}
+	portals := map[string]string{}
+	failed := false
+	sendResult := func(result fakePGResult, describe bool) error {
+		if result.err != "" {
+			failed = true
+			return backend.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "XX000", Message: result.err})
+		}
+		if describe {
+			if err := backend.Send(rowDescription(result)); err != nil {
+				return err
+			}
+		}
+		for _, row := range result.rows {
+			values := [][]byte{}
+			for _, value := range row {
+				if value == fakeNull {
+					values = append(values, nil)
+				} else {
+					values = append(values, []byte(value))
+				}
+			}
+			if err := backend.Send(&pgproto3.DataRow{Values: values}); err != nil {
+				return err
+			}
+		}
+		return backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(result.tag)})
+	}
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			server.log("simple: " + msg.String)
+			for _, statement := range splitStatements(msg.String) {
+				result := server.result(statement)
+				if err := sendResult(result, len(result.columns) > 0); err != nil {
+					return
+				}
+				if result.err != "" {
+					break
+				}
+				switch strings.ToLower(strings.Fields(statement)[0]) {
+				case "begin":
+					txStatus = 'T'
+				case "commit", "rollback":
+					txStatus = 'I'
+				}
+			}
+			failed = false
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: txStatus})
+		case *pgproto3.Parse:
+			if failed {
+				continue
+			}
+			if len(splitStatements(msg.Query)) > 1 {
+				failed = true
+				backend.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "42601", Message: "cannot insert multiple commands into a prepared statement"})
+				continue
+			}
+			statements[msg.Name] = msg.Query
+			backend.Send(&pgproto3.ParseComplete{})
+		case *pgproto3.Describe:
+			if failed {
+				continue
+			}
+			query := statements[msg.Name]
+			if msg.ObjectType == 'P' {
+				query = portals[msg.Name]
+			} else {
+				parameterOIDs := []uint32{}
+				for _, match := range placeholderRegexp.FindAllStringSubmatch(query, -1) {
+					if oid, ok := arrayOIDs[match[2]]; ok {
+						parameterOIDs = append(parameterOIDs, oid)
+					} else if match[1] != "" {
+						parameterOIDs = append(parameterOIDs, 20)
+					} else {
+						parameterOIDs = append(parameterOIDs, 25)
+					}
+				}
+				backend.Send(&pgproto3.ParameterDescription{ParameterOIDs: parameterOIDs})
+			}
+			if result := server.result(query); len(result.columns) > 0 {
+				backend.Send(rowDescription(result))
+			} else {
+				backend.Send(&pgproto3.NoData{})
+			}
+		case *pgproto3.Bind:
+			if failed {
+				continue
+			}
+			portals[msg.DestinationPortal] = statements[msg.PreparedStatement]
+			backend.Send(&pgproto3.BindComplete{})
+		case *pgproto3.Execute:
+			if failed {
+				continue
+			}
+			query := portals[msg.Portal]
+			server.log("extended: " + query)
+			if err := sendResult(server.result(query), false); err != nil {
+				return
+			}
+		case *pgproto3.Sync:
+			failed = false
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: txStatus})
+		case *pgproto3.Close:
+			backend.Send(&pgproto3.CloseComplete{})
+		case *pgproto3.Terminate:
+			return
+		}
+	}
+}
+
+// result answers the queries of the transactions and the connection setup, then asks results.
+func (server *fakePGServer) result(query string) fakePGResult {
+	query = strings.TrimSpace(query)
+	switch strings.ToLower(query) {
+	case "begin", "begin isolation level read committed":
+		return fakePGResult{tag: "BEGIN"}
+	case "commit":
+		return fakePGResult{tag: "COMMIT"}
+	case "rollback":
+		return fakePGResult{tag: "ROLLBACK"}
+	}
+	if strings.Contains(query, "typname = 'hstore'") {
+		return fakePGResult{columns: []string{"oid"}, tag: "SELECT 0"}
+	}
+	return server.results(query)
+}
+
+func splitStatements(query string) []string {
+	statements := []string{}
+	for strings.TrimSpace(query) != "" {
+		pos, ok := parser.SplitFirstStatement(query)
+		if !ok {
+			pos = len(query)
+		}
+		statements = append(statements, strings.TrimSuffix(strings.TrimSpace(query[:pos]), ";"))
+		query = query[pos:]
+	}
+	return statements
+}
+
+func rowDescription(result fakePGResult) *pgproto3.RowDescription {
+	fields := []pgproto3.FieldDescription{}
+	for _, column := range result.columns {
+		fields = append(fields, pgproto3.FieldDescription{Name: []byte(column), DataTypeOID: 25, DataTypeSize: -1, TypeModifier: -1})
+	}
+	return &pgproto3.RowDescription{Fields: fields}
+}
+
+func TestRunScriptUnparsed(t *testing.T) {
+	server := newFakePGServer(t, func(query string) fakePGResult {
+		switch {
+		case strings.HasPrefix(query, "DO"):
+			return fakePGResult{tag: "DO"}
+		case strings.HasPrefix(query, "CREATE FUNCTION"):
+			return fakePGResult{tag: "CREATE FUNCTION"}
+		}
+		return fakePGResult{err: "unexpected query: " + query}
+	})
+	pgqe := InitPostgresQueryEngine()
+	script := "DO $$ BEGIN PERFORM 1; END $$;\nCREATE FUNCTION one() RETURNS int AS $$ SELECT 1 $$ LANGUAGE sql;"
+	results, err := pgqe.RunScript(server.dbConn("script"), script, false, queryconfig.NewQueryConfig(false, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Error != "" || results[0].Message != "CREATE FUNCTION" {
+		t.Fatalf("results: %+v", results[0])
+	}
+	queries := server.loggedQueries()
+	if queries[len(queries)-1] != "simple: "+script {
+		t.Error("script is not run with the simple protocol:", queries)
+	}
+
+	if _, err := pgqe.RunScript(server.dbConn("script"), script, false, queryconfig.NewQueryConfig(true, nil)); err == nil {
+		t.Error("unparsed script is run for read only")
+	}
+}
+
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
+
+func TestRunQueryTruncated(t *testing.T) {
+	rows := [][]string{}
+	for i := 0; i < 100000; i++ {
+		rows = append(rows, []string{strings.Repeat("x", 100)})
+	}
+	server := newFakePGServer(t, func(query string) fakePGResult {
+		return fakePGResult{columns: []string{"name"}, rows: rows, tag: fmt.Sprintf("SELECT %d", len(rows))}
+	})
+	pgqe := InitPostgresQueryEngine()
+	config := queryconfig.NewQueryConfig(false, nil)
+	config.MaxRows = 2
+	data, err := pgqe.runQuery(server.dbConn("truncated"), "SELECT name FROM users", nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data["rows"].([]map[string]interface{})) != 2 || data["truncated"] != true {
+		t.Fatalf("result is not truncated: %v", data["truncated"])
+	}
+	if !waitFor(func() bool { return server.receivedCancelRequests() > 0 }) {
+		t.Error("truncated query is not cancelled")
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	server := newFakePGServer(t, func(query string) fakePGResult {
+		if strings.HasPrefix(query, "SELECT name FROM users") {
+			return fakePGResult{columns: []string{"name"}, rows: [][]string{{"alice"}}, tag: "SELECT 1"}
+		}
+		return fakePGResult{err: "unexpected query: " + query}
+	})
+	pgqe := InitPostgresQueryEngine()
+	dbConn := server.dbConn("transaction")
+	config := queryconfig.NewQueryConfig(false, nil)
+	config.UserID = "alice"
+	otherConfig := queryconfig.NewQueryConfig(false, nil)
+	otherConfig.UserID = "bob"
+	endedWith := func(statement string) bool {
+		queries := server.loggedQueries()
+		return strings.ToLower(queries[len(queries)-1]) == "simple: "+statement
+	}
+
+	transactionID, err := pgqe.BeginTransaction(dbConn, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !endedWith("begin") {
+		t.Error("transaction is not begun:", server.loggedQueries())
+	}
+	data, err := pgqe.RunTransactionQuery(dbConn, transactionID, "SELECT name FROM users", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := data["rows"].([]map[string]interface{}); len(rows) != 1 || rows[0]["0"] != "alice" {
+		t.Error("rows:", rows)
+	}
+	if _, err := pgqe.RunTransactionQuery(dbConn, transactionID, "SELECT name FROM users", otherConfig); err == nil {
+		t.Error("transaction is used by another user")
+	}
+	if err := pgqe.CommitTransaction(dbConn, transactionID, otherConfig); err == nil {
+		t.Error("transaction is committed by another user")
+	}
+	if _, err := pgqe.RunTransactionQuery(server.dbConn("transaction-other"), transactionID, "SELECT name FROM users", config); err == nil {
+		t.Error("transaction is used on another db connection")
+	}
+	if err := pgqe.CommitTransaction(dbConn, transactionID, config); err != nil {
+		t.Fatal(err)
+	}
+	if !endedWith("commit") {
+		t.Error("transaction is not committed:", server.loggedQueries())
+	}
+	if _, err := pgqe.RunTransactionQuery(dbConn, transactionID, "SELECT name FROM users", config); err == nil {
+		t.Error("committed transaction is used")
+	}
+
+	transactionID, err = pgqe.BeginTransaction(dbConn, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pgqe.RollbackTransaction(dbConn, transactionID, otherConfig); err == nil {
+		t.Error("transaction is rolled back by another user")
+	}
+	if err := pgqe.RollbackTransaction(dbConn, transactionID, config); err != nil {
+		t.Fatal(err)
+	}
+	if !endedWith("rollback") {
+		t.Error("transaction is not rolled back:", server.loggedQueries())
+	}
+}
+
+func TestTransactionIdleTimeout(t *testing.T) {
+	server := newFakePGServer(t, func(query string) fakePGResult {
+		return fakePGResult{err: "unexpected query: " + query}
+	})
+	pgqe := InitPostgresQueryEngine()
+	dbConn := server.dbConn("transaction-idle")
+	config := queryconfig.NewQueryConfig(false, nil)
+	transactionID, err := pgqe.BeginTransaction(dbConn, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgqe.transactionsMutex.Lock()
+	pgqe.openTransactions[transactionID].idleTimer.Reset(time.Millisecond)
+	pgqe.transactionsMutex.Unlock()
+	rolledBack := waitFor(func() bool {
+		queries := server.loggedQueries()
+		return strings.ToLower(queries[len(queries)-1]) == "simple: rollback"
+	})
+	if !rolledBack {
+		t.Fatal("idle transaction is not rolled back:", server.loggedQueries())
+	}
+	if _, err := pgqe.RunTransactionQuery(dbConn, transactionID, "SELECT 1", config); err == nil {
+		t.Error("idle transaction is still open")
+	}
+}
+
+func TestRunQueryColumnsMetadataCache(t *testing.T) {
+	server := newFakePGServer(t, func(query string) fakePGResult {
+		switch {
+		case strings.HasPrefix(query, "SELECT format_type"):
+			return fakePGResult{
+				columns: []string{"format_type", "nspname", "relname", "attname", "attnotnull", "relkind"},
+				rows:    [][]string{{"text", fakeNull, fakeNull, fakeNull, fakeNull, fakeNull}},
+				tag:     "SELECT 1",
+			}
+		case strings.HasPrefix(query, "SELECT name"):
+			return fakePGResult{columns: []string{"name"}, rows: [][]string{{"alice"}}, tag: "SELECT 1"}
+		case strings.HasPrefix(query, "CREATE TABLE"):
+			return fakePGResult{tag: "CREATE TABLE"}
+		}
+		return fakePGResult{err: "unexpected query: " + query}
+	})
+	pgqe := InitPostgresQueryEngine()
+	dbConn := server.dbConn("metadata")
+	catalogQueries := func() int {
+		count := 0
+		for _, query := range server.loggedQueries() {
+			if strings.HasPrefix(query, "extended: SELECT format_type") {
+				count++
+			}
+		}
+		return count
+	}
+	runQuery := func(query string) map[string]interface{} {
+		data, err := pgqe.RunQuery(dbConn, query, queryconfig.NewQueryConfig(false, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	for i := 0; i < 2; i++ {
+		data := runQuery("SELECT name FROM users")
+		if columns := data["columnsMetadata"].([]*qemodels.ColumnMetadata); columns[0].Type != "text" {
+			t.Error("type:", columns[0].Type)
+		}
+	}
+	if count := catalogQueries(); count != 1 {
+		t.Error("catalog is read for each query:", count)
+	}
+	runQuery("CREATE TABLE posts (title text)")
+	runQuery("SELECT name FROM users")
+	if count := catalogQueries(); count != 2 {
+		t.Error("catalog is not read again after the tables are altered:", count)
+	}
+}
+
+func TestGetDataView(t *testing.T) {
+	server := newFakePGServer(t, func(query string) fakePGResult {
+		switch {
+		case strings.HasPrefix(query, "SELECT c.relkind"):
+			return fakePGResult{columns: []string{"relkind"}, rows: [][]string{{"v"}}, tag: "SELECT 1"}
+		case strings.Contains(query, "ctid") || strings.Contains(query, "pg_index"):
+			return fakePGResult{err: `column "ctid" does not exist`}
+		case strings.HasPrefix(query, "SELECT *"):
+			return fakePGResult{columns: []string{"name", "_cursor"}, rows: [][]string{{"alice", `["alice"]`}}, tag: "SELECT 1"}
+		}
+		return fakePGResult{err: "unexpected query: " + query}
+	})
+	pgqe := InitPostgresQueryEngine()
+	dbConn := server.dbConn("view")
+	config := queryconfig.NewQueryConfig(false, nil)
+
+	data, err := pgqe.GetData(dbConn, "public", "active_users", 200, 0, false, nil, nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["editable"] != false || data["rowIdIsCtid"] != false {
+		t.Error("the rows of a view are editable:", data["editable"], data["rowIdIsCtid"])
+	}
+	if columns := data["columns"].([]string); columns[0] != "name" {
+		t.Error("columns:", columns)
+	}
+	if _, err := pgqe.GetDataByCursor(dbConn, "public", "active_users", 200, "", false, nil, nil, config); err == nil {
+		t.Error("a view is paged by cursor without a sort")
+	}
+	sort := []qemodels.SortField{{Field: "name", Direction: "ASC"}}
+	if _, err := pgqe.GetDataByCursor(dbConn, "public", "active_users", 200, "", false, nil, sort, config); err != nil {
+		t.Error(err)
+	}
+	if _, err := pgqe.DeleteData(dbConn, "public", "active_users", []string{"(0,1)"}, config); err == nil {
+		t.Error("the rows of a view are deleted")
+	}
+}
@@ -0,0 +1,70 @@
+package pgqueryengine
+
+import (
+	"context"
+	"errors"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/pgqueryengine/pgxutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// ExplainQuery gets the plan of the query with EXPLAIN (FORMAT JSON). When analyze is true the query is run,
+// the changes of write statements are rolled back and read only roles can only analyze reads.
+func (pgqe *PostgresQueryEngine) ExplainQuery(dbConn *models.DBConnection, query string, analyze bool, config *queryconfig.QueryConfig) (*qemodels.QueryPlan, error) {
+	if !pgxutils.IsSingleStatement(query) {
+		return nil, errors.New("only a single statement can be explained")
+	}
+	queryType, _ := pgxutils.GetPSQLQueryType(query)
+	isWrite := queryType != pgxutils.QUERY_READ
+	if analyze && isWrite && config.ReadOnly {
+		return nil, errors.New("not allowed to analyze this query")
+	}
+
+	pool, err := pgqe.getPool(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pool.Acquire(config.GetContext())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	resetStatementTimeout, err := setStatementTimeout(conn, config)
+	if err != nil {
+		return nil, err
+	}
+	defer resetStatementTimeout()
+
+	var querier pgxQuerier = conn
+	if analyze && isWrite {
+		tx, err := conn.Begin(config.GetContext())
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback(context.Background())
+		querier = tx
+	}
+
+	explainQuery := pgxutils.ExplainQuery(query, analyze)
+	rows, err := querier.Query(config.GetContext(), explainQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	explainJson := ""
+	if rows.Next() {
+		if err := rows.Scan(&explainJson); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(explainQuery)
+	}
+	return pgxutils.ExplainToPlan(explainJson, analyze)
+}
@@ -0,0 +1,187 @@
+package pgqueryengine
+
+import (
+	"fmt"
+	"time"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/pgqueryengine/pgxutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// METADATA_CACHE_TTL is how long the catalog data of the results of a db connection is cached, the tables
+// can be altered by other clients meanwhile.
+const METADATA_CACHE_TTL = time.Minute
+
+// pgxMetadataCache is the catalog data of the results of a db connection, so that the results of the
+// same tables and types do not read the catalog again. Types are keyed by oid and modifier, and columns
+// by table oid and column number. A nil column is not a column of a table, like a system column.
+type pgxMetadataCache struct {
+	expiresAt    time.Time
+	typeNames    map[string]string
+	columns      map[string]*pgxColumnMetadata
+	rowIDColumns map[uint32][]string
+}
+
+type pgxColumnMetadata struct {
+	schema   string
+	table    string
+	column   string
+	nullable bool
+	// isTable is true for tables and partitioned tables, the relations whose rows can be edited
+	isTable bool
+}
+
+func typeKey(column *qemodels.ColumnMetadata) string {
+	return fmt.Sprintf("%d:%d", column.TypeOID, column.TypeModifier)
+}
+
+func tableColumnKey(column *qemodels.ColumnMetadata) string {
+	return fmt.Sprintf("%d:%d", column.TableOID, column.ColumnNumber)
+}
+
+// getMetadataCache returns the cache of the db connection, a new one if it expired.
+// The cache must only be used with the metadataCacheMutex locked.
+func (pgqe *PostgresQueryEngine) getMetadataCache(dbConn *models.DBConnection) *pgxMetadataCache {
+	cache, exists := pgqe.metadataCaches[dbConn.ID]
+	if !exists || time.Now().After(cache.expiresAt) {
+		cache = &pgxMetadataCache{
+			expiresAt:    time.Now().Add(METADATA_CACHE_TTL),
+			typeNames:    map[string]string{},
+			columns:      map[string]*pgxColumnMetadata{},
+			rowIDColumns: map[uint32][]string{},
+		}
+		pgqe.metadataCaches[dbConn.ID] = cache
+	}
+	return cache
+}
+
+// clearMetadataCache drops the catalog data of the db connection, after a query which may have altered it.
+func (pgqe *PostgresQueryEngine) clearMetadataCache(dbConn *models.DBConnection) {
+	pgqe.metadataCacheMutex.Lock()
+	delete(pgqe.metadataCaches, dbConn.ID)
+	pgqe.metadataCacheMutex.Unlock()
+}
+
+// resolveColumnsMetadata sets the type names, nullability and source columns of the columns of a result
+// from the catalog, and marks the columns of the tables whose row id columns are in the result as editable.
+// The catalog is only read for the types and columns which are not cached.
+// It is best effort, the columns are left as they are when the catalog cannot be read.
+func (pgqe *PostgresQueryEngine) resolveColumnsMetadata(dbConn *models.DBConnection, columns []*qemodels.ColumnMetadata, config *queryconfig.QueryConfig) {
+	if len(columns) == 0 {
+		return
+	}
+	// catalog queries are not logged and not limited like the query of the user
+	metadataConfig := &queryconfig.QueryConfig{Context: config.Context}
+
+	pgqe.metadataCacheMutex.Lock()
+	cache := pgqe.getMetadataCache(dbConn)
+	isCached := true
+	for _, column := range columns {
+		_, typeCached := cache.typeNames[typeKey(column)]
+		_, columnCached := cache.columns[tableColumnKey(column)]
+		if !typeCached || (column.TableOID != 0 && !columnCached) {
+			isCached = false
+			break
+		}
+	}
+	pgqe.metadataCacheMutex.Unlock()
+	if !isCached && !pgqe.readColumnsMetadata(dbConn, columns, metadataConfig) {
+		return
+	}
+
+	tables := map[uint32]*pgxColumnMetadata{}
+	pgqe.metadataCacheMutex.Lock()
+	cache = pgqe.getMetadataCache(dbConn)
+	for _, column := range columns {
+		if typeName := cache.typeNames[typeKey(column)]; typeName != "" {
+			column.Type = typeName
+		}
+		metadata := cache.columns[tableColumnKey(column)]
+		if column.TableOID == 0 || metadata == nil {
+			continue
+		}
+		column.Schema = metadata.schema
+		column.Table = metadata.table
+		column.Column = metadata.column
+		nullable := metadata.nullable
+		column.Nullable = &nullable
+		if metadata.isTable {
+			tables[column.TableOID] = metadata
+		}
+	}
+	pgqe.metadataCacheMutex.Unlock()
+
+	tableRowIDColumns := map[string][]string{}
+	for tableOID, metadata := range tables {
+		pgqe.metadataCacheMutex.Lock()
+		rowIDColumns, exists := cache.rowIDColumns[tableOID]
+		pgqe.metadataCacheMutex.Unlock()
+		if !exists {
+			var err error
+			rowIDColumns, err = pgqe.getRowIDColumns(dbConn, metadata.schema, metadata.table, metadataConfig)
+			if err != nil {
+				rowIDColumns = []string{}
+			} else {
+				pgqe.metadataCacheMutex.Lock()
+				cache.rowIDColumns[tableOID] = rowIDColumns
+				pgqe.metadataCacheMutex.Unlock()
+			}
+		}
+		tableRowIDColumns[pgxutils.QuoteIdentifier(metadata.schema, metadata.table)] = rowIDColumns
+	}
+	pgxutils.SetEditableColumns(columns, tableRowIDColumns)
+}
+
+// readColumnsMetadata reads the type names and source columns of the columns from the catalog into the cache,
+// it returns false if the catalog cannot be read.
+func (pgqe *PostgresQueryEngine) readColumnsMetadata(dbConn *models.DBConnection, columns []*qemodels.ColumnMetadata, config *queryconfig.QueryConfig) bool {
+	typeOIDs := []int64{}
+	typeModifiers := []int32{}
+	tableOIDs := []int64{}
+	columnNumbers := []int16{}
+	for _, column := range columns {
+		typeOIDs = append(typeOIDs, int64(column.TypeOID))
+		typeModifiers = append(typeModifiers, column.TypeModifier)
+		tableOIDs = append(tableOIDs, int64(column.TableOID))
+		columnNumbers = append(columnNumbers, int16(column.ColumnNumber))
+	}
+	query := `SELECT format_type(f.typid::oid, NULLIF(f.typmod, -1)), n.nspname, c.relname, a.attname, a.attnotnull, c.relkind
+		FROM unnest($1::int8[], $2::int4[], $3::int8[], $4::int2[]) WITH ORDINALITY AS f(typid, typmod, relid, attnum, i)
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = f.relid::oid AND a.attnum = f.attnum AND f.attnum > 0 AND NOT a.attisdropped
+		LEFT JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		ORDER BY f.i;`
+	data, err := pgqe.runQuery(dbConn, query, []interface{}{typeOIDs, typeModifiers, tableOIDs, columnNumbers}, config)
+	if err != nil {
+		return false
+	}
+	rows := data["rows"].([]map[string]interface{})
+	if len(rows) != len(columns) {
+		return false
+	}
+	pgqe.metadataCacheMutex.Lock()
+	defer pgqe.metadataCacheMutex.Unlock()
+	cache := pgqe.getMetadataCache(dbConn)
+	for i, row := range rows {
+		column := columns[i]
+		typeName, _ := row["0"].(string)
+		cache.typeNames[typeKey(column)] = typeName
+		if column.TableOID == 0 {
+			continue
+		}
+		if row["3"] == nil {
+			cache.columns[tableColumnKey(column)] = nil
+			continue
+		}
+		cache.columns[tableColumnKey(column)] = &pgxColumnMetadata{
+			schema:   row["1"].(string),
+			table:    row["2"].(string),
+			column:   row["3"].(string),
+			nullable: !row["4"].(bool),
+			isTable:  row["5"] == "r" || row["5"] == "p",
+		}
+	}
+	return true
+}
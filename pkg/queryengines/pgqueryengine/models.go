@@ -0,0 +1,60 @@
+package pgqueryengine
+
+import (
+	"fmt"
+
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+func buildDBDataModel(tableData map[string]interface{}) *qemodels.DBDataModel {
+	view := qemodels.DBDataModel{
+		Name:       tableData["0"].(string),
+		SchemaName: tableData["1"].(string),
+		Kind:       dataModelKind(tableData["2"].(string)),
+	}
+	return &view
+}
+
+// dataModelKind returns the kind of data model of a pg_class relkind.
+func dataModelKind(relkind string) string {
+	switch relkind {
+	case "v":
+		return qemodels.DATA_MODEL_KIND_VIEW
+	case "m":
+		return qemodels.DATA_MODEL_KIND_MATERIALIZED_VIEW
+	case "f":
+		return qemodels.DATA_MODEL_KIND_FOREIGN_TABLE
+	default:
+		return qemodels.DATA_MODEL_KIND_TABLE
+	}
+}
+
+// viewDefinition returns the statement creating the view from the query returned by pg_get_viewdef.
+func viewDefinition(kind, quotedName, viewDef string) string {
+	if kind == qemodels.DATA_MODEL_KIND_MATERIALIZED_VIEW {
+		return fmt.Sprintf("CREATE MATERIALIZED VIEW %s AS\n%s", quotedName, viewDef)
+	}
+	return fmt.Sprintf("CREATE OR REPLACE VIEW %s AS\n%s", quotedName, viewDef)
+}
+
+func buildDBDataModelField(fieldData map[string]interface{}) *qemodels.DBDataModelField {
+	view := qemodels.DBDataModelField{
+		Name:       fieldData["name"].(string),
+		Type:       fieldData["type"].(string),
+		IsNullable: fieldData["isNullable"].(bool),
+		IsPrimary:  fieldData["isPrimary"].(bool),
+		Tags:       fieldData["tags"].([]string),
+	}
+	if defaultValue, ok := fieldData["default"].(string); ok {
+		view.Default = &defaultValue
+	}
+	return &view
+}
+
+func buildDBDataModelIndex(indexData map[string]interface{}) *qemodels.DBDataModelIndex {
+	view := qemodels.DBDataModelIndex{
+		Name:     indexData["0"].(string),
+		IndexDef: indexData["1"].(string),
+	}
+	return &view
+}
@@ -0,0 +1,294 @@
+package pgxutils
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+const (
+	jsonDateLayout        = "2006-01-02"
+	jsonTimestampLayout   = "2006-01-02T15:04:05.999999"
+	jsonTimestamptzLayout = "2006-01-02T15:04:05.999999Z07:00"
+)
+
+var defaultConnInfo = pgtype.NewConnInfo()
+
+// FieldType returns the type to scan a column into, the pgtype value of the type of the column
+// or interface{} for the types which pgx does not know, which are read in the text format.
+func FieldType(fd pgproto3.FieldDescription) reflect.Type {
+	if dataType, ok := defaultConnInfo.DataTypeForOID(fd.DataTypeOID); ok {
+		return reflect.TypeOf(dataType.Value).Elem()
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+// PgSqlColumnsMetadata returns the metadata of the columns of the rows known from their field descriptions,
+// the type name is only set for the types known by pgx until it is resolved from the database.
+func PgSqlColumnsMetadata(rows pgx.Rows) []*qemodels.ColumnMetadata {
+	columns := []*qemodels.ColumnMetadata{}
+	for _, fd := range rows.FieldDescriptions() {
+		column := &qemodels.ColumnMetadata{
+			Name:         string(fd.Name),
+			TypeOID:      fd.DataTypeOID,
+			TypeModifier: fd.TypeModifier,
+			TableOID:     fd.TableOID,
+			ColumnNumber: fd.TableAttributeNumber,
+		}
+		if dataType, ok := defaultConnInfo.DataTypeForOID(fd.DataTypeOID); ok {
+			column.Type = dataType.Name
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// SetEditableColumns marks the columns of a table as editable when each of the row id columns of the table
+// is exactly once in the result. tableRowIDColumns has the row id columns of the tables, by quoted table name.
+func SetEditableColumns(columns []*qemodels.ColumnMetadata, tableRowIDColumns map[string][]string) {
+	isColumnOfTable := func(column *qemodels.ColumnMetadata, table string) bool {
+		return column.Table != "" && QuoteIdentifier(column.Schema, column.Table) == table
+	}
+	for table, rowIDColumns := range tableRowIDColumns {
+		indexes := []int{}
+		for _, rowIDColumn := range rowIDColumns {
+			matches := []int{}
+			for i, column := range columns {
+				if isColumnOfTable(column, table) && column.Column == rowIDColumn {
+					matches = append(matches, i)
+				}
+			}
+			if len(matches) != 1 {
+				indexes = []int{}
+				break
+			}
+			indexes = append(indexes, matches[0])
+		}
+		if len(indexes) == 0 {
+			continue
+		}
+		for _, column := range columns {
+			if isColumnOfTable(column, table) {
+				column.Editable = true
+				column.RowIDColumns = indexes
+			}
+		}
+	}
+}
+
+// ValueToJson converts a value scanned from a row to a json value without losing precision:
+// numeric and money are strings, times are ISO-8601, intervals are ISO-8601 durations, json is kept as is,
+// bytea is hex, arrays are nested lists, ranges are their bounds
+// and types without a json equivalent are their postgres text representation.
+func ValueToJson(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case pgtype.Value:
+		return pgValueToJson(v)
+	case map[string]pgtype.Text:
+		// hstore, when registered on the connection
+		hstore := map[string]interface{}{}
+		for key, text := range v {
+			hstore[key] = pgValueToJson(&text)
+		}
+		return hstore
+	case []byte:
+		return string(v)
+	default:
+		return v
+	}
+}
+
+func pgValueToJson(value pgtype.Value) interface{} {
+	status := reflect.ValueOf(value).Elem().FieldByName("Status")
+	if status.IsValid() && pgtype.Status(status.Uint()) != pgtype.Present {
+		return nil
+	}
+	switch v := value.(type) {
+	case *pgtype.Bool:
+		return v.Bool
+	case *pgtype.Int2:
+		return v.Int
+	case *pgtype.Int4:
+		return v.Int
+	case *pgtype.Int8:
+		return v.Int
+	case *pgtype.Float4:
+		return floatToJson(float64(v.Float))
+	case *pgtype.Float8:
+		return floatToJson(v.Float)
+	case *pgtype.Numeric:
+		return NumericToString(*v)
+	case *pgtype.Date:
+		return timeToJson(v.Time, v.InfinityModifier, jsonDateLayout)
+	case *pgtype.Timestamp:
+		return timeToJson(v.Time, v.InfinityModifier, jsonTimestampLayout)
+	case *pgtype.Timestamptz:
+		return timeToJson(v.Time.UTC(), v.InfinityModifier, jsonTimestamptzLayout)
+	case *pgtype.Interval:
+		return IntervalToISO8601(*v)
+	case *pgtype.JSON:
+		return json.RawMessage(v.Bytes)
+	case *pgtype.JSONB:
+		return json.RawMessage(v.Bytes)
+	case *pgtype.Bytea:
+		return "\\x" + hex.EncodeToString(v.Bytes)
+	case *pgtype.Hstore:
+		return ValueToJson(v.Map)
+	case *pgtype.Record:
+		fields := make([]interface{}, len(v.Fields))
+		for i, field := range v.Fields {
+			fields[i] = pgValueToJson(field)
+		}
+		return fields
+	}
+	if lowerType := reflect.ValueOf(value).Elem().FieldByName("LowerType"); lowerType.IsValid() {
+		return rangeToJson(reflect.ValueOf(value).Elem())
+	}
+	if elements := reflect.ValueOf(value).Elem().FieldByName("Elements"); elements.IsValid() {
+		dimensions := reflect.ValueOf(value).Elem().FieldByName("Dimensions").Interface().([]pgtype.ArrayDimension)
+		return arrayToJson(elements, dimensions)
+	}
+	if encoder, ok := value.(pgtype.TextEncoder); ok {
+		if text, err := encoder.EncodeText(defaultConnInfo, nil); err == nil {
+			return string(text)
+		}
+	}
+	return value.Get()
+}
+
+// arrayToJson converts the elements of an array to nested json arrays, one level per dimension.
+func arrayToJson(elements reflect.Value, dimensions []pgtype.ArrayDimension) []interface{} {
+	if len(dimensions) == 0 {
+		return []interface{}{}
+	}
+	offset := 0
+	var build func(dim int) []interface{}
+	build = func(dim int) []interface{} {
+		list := make([]interface{}, 0, dimensions[dim].Length)
+		for i := int32(0); i < dimensions[dim].Length; i++ {
+			if dim < len(dimensions)-1 {
+				list = append(list, build(dim+1))
+				continue
+			}
+			list = append(list, pgValueToJson(elements.Index(offset).Addr().Interface().(pgtype.Value)))
+			offset++
+		}
+		return list
+	}
+	return build(0)
+}
+
+// rangeToJson converts a range to its bounds, a bound is null when the range is unbounded on that side.
+func rangeToJson(rangeValue reflect.Value) map[string]interface{} {
+	lowerType := rangeValue.FieldByName("LowerType").Interface().(pgtype.BoundType)
+	upperType := rangeValue.FieldByName("UpperType").Interface().(pgtype.BoundType)
+	if lowerType == pgtype.Empty {
+		return map[string]interface{}{"empty": true}
+	}
+	bound := func(name string, boundType pgtype.BoundType) interface{} {
+		if boundType == pgtype.Unbounded {
+			return nil
+		}
+		return pgValueToJson(rangeValue.FieldByName(name).Addr().Interface().(pgtype.Value))
+	}
+	return map[string]interface{}{
+		"lower":          bound("Lower", lowerType),
+		"upper":          bound("Upper", upperType),
+		"lowerInclusive": lowerType == pgtype.Inclusive,
+		"upperInclusive": upperType == pgtype.Inclusive,
+	}
+}
+
+func floatToJson(value float64) interface{} {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+	return value
+}
+
+func timeToJson(value time.Time, infinity pgtype.InfinityModifier, layout string) string {
+	switch infinity {
+	case pgtype.Infinity:
+		return "infinity"
+	case pgtype.NegativeInfinity:
+		return "-infinity"
+	}
+	return value.Format(layout)
+}
+
+// NumericToString formats the numeric as a decimal string keeping all its digits, including trailing zeros of its scale.
+func NumericToString(numeric pgtype.Numeric) string {
+	if numeric.NaN {
+		return "NaN"
+	}
+	digits := numeric.Int.String()
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if numeric.Exp >= 0 {
+		if digits == "0" {
+			return "0"
+		}
+		return sign + digits + strings.Repeat("0", int(numeric.Exp))
+	}
+	scale := int(-numeric.Exp)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// IntervalToISO8601 formats the interval as an ISO-8601 duration, like postgres does with IntervalStyle iso_8601.
+func IntervalToISO8601(interval pgtype.Interval) string {
+	duration := strings.Builder{}
+	duration.WriteString("P")
+	years, months := interval.Months/12, interval.Months%12
+	if years != 0 {
+		duration.WriteString(strconv.Itoa(int(years)) + "Y")
+	}
+	if months != 0 {
+		duration.WriteString(strconv.Itoa(int(months)) + "M")
+	}
+	if interval.Days != 0 {
+		duration.WriteString(strconv.Itoa(int(interval.Days)) + "D")
+	}
+	microseconds := interval.Microseconds
+	if microseconds != 0 {
+		duration.WriteString("T")
+		hours := microseconds / int64(time.Hour/time.Microsecond)
+		microseconds -= hours * int64(time.Hour/time.Microsecond)
+		minutes := microseconds / int64(time.Minute/time.Microsecond)
+		microseconds -= minutes * int64(time.Minute/time.Microsecond)
+		if hours != 0 {
+			duration.WriteString(strconv.FormatInt(hours, 10) + "H")
+		}
+		if minutes != 0 {
+			duration.WriteString(strconv.FormatInt(minutes, 10) + "M")
+		}
+		if microseconds != 0 {
+			seconds := strconv.FormatFloat(float64(microseconds)/1e6, 'f', -1, 64)
+			duration.WriteString(seconds + "S")
+		}
+	}
+	if duration.Len() == 1 {
+		return "PT0S"
+	}
+	return duration.String()
+}
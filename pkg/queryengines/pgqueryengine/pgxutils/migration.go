@@ -0,0 +1,233 @@
+package pgxutils
+
+import (
+	"fmt"
+	"strings"
+
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+// MigrationScript returns the script bringing the target of the diff in line with its source, in a transaction.
+// Foreign keys, constraints and indexes which are removed or changed are dropped first, as they can depend on
+// the columns which change, and so are the removed views and the recreated views, which are created again after
+// the columns are changed. The tables which are removed are dropped last. Objects which are not data models,
+// like sequences used by defaults, are not part of the diff and must already exist.
+func MigrationScript(diff *qemodels.SchemaDiff) string {
+	drops := []string{}
+	creates := []string{}
+	columns := []string{}
+	views := []string{}
+	constraints := []string{}
+	foreignKeys := []string{}
+	tableDrops := []string{}
+
+	// views are dropped before the views they depend on, and created after them
+	droppedViews := append([]*qemodels.DBDataModel{}, diff.RecreatedViews...)
+	createdViews := append([]*qemodels.DBDataModel{}, diff.RecreatedViews...)
+	recreatedViews := map[string]bool{}
+	for _, view := range diff.RecreatedViews {
+		recreatedViews[QuoteIdentifier(view.SchemaName, view.Name)] = true
+		for _, index := range view.Indexes {
+			constraints = append(constraints, index.IndexDef+";")
+		}
+	}
+
+	for _, dataModel := range diff.RemovedDataModels {
+		name := QuoteIdentifier(dataModel.SchemaName, dataModel.Name)
+		switch dataModel.Kind {
+		case qemodels.DATA_MODEL_KIND_VIEW, qemodels.DATA_MODEL_KIND_MATERIALIZED_VIEW:
+			droppedViews = append(droppedViews, dataModel)
+		case qemodels.DATA_MODEL_KIND_FOREIGN_TABLE:
+			tableDrops = append(tableDrops, fmt.Sprintf("DROP FOREIGN TABLE %s;", name))
+		default:
+			tableDrops = append(tableDrops, fmt.Sprintf("DROP TABLE %s;", name))
+		}
+	}
+
+	for _, dataModel := range diff.AddedDataModels {
+		name := QuoteIdentifier(dataModel.SchemaName, dataModel.Name)
+		switch dataModel.Kind {
+		case qemodels.DATA_MODEL_KIND_VIEW, qemodels.DATA_MODEL_KIND_MATERIALIZED_VIEW:
+			createdViews = append(createdViews, dataModel)
+		case qemodels.DATA_MODEL_KIND_FOREIGN_TABLE:
+			creates = append(creates, fmt.Sprintf("-- foreign table %s must be created with its server", name))
+		default:
+			definitions := []string{}
+			for _, field := range dataModel.Fields {
+				definitions = append(definitions, fieldDefinition(field))
+			}
+			for _, constraint := range dataModel.Constraints {
+				definitions = append(definitions, fmt.Sprintf("CONSTRAINT %s %s", QuoteIdentifier(constraint.Name), constraint.Definition))
+			}
+			creates = append(creates, fmt.Sprintf("CREATE TABLE %s (\n    %s\n);", name, strings.Join(definitions, ",\n    ")))
+		}
+		for _, index := range dataModel.Indexes {
+			constraints = append(constraints, index.IndexDef+";")
+		}
+		for _, foreignKey := range dataModel.ForeignKeys {
+			foreignKeys = append(foreignKeys, addForeignKeyStatement(name, foreignKey))
+		}
+	}
+
+	for _, dataModel := range diff.ChangedDataModels {
+		name := QuoteIdentifier(dataModel.SchemaName, dataModel.Name)
+		for _, foreignKey := range dataModel.RemovedForeignKeys {
+			drops = append(drops, dropConstraintStatement(name, foreignKey.Name))
+		}
+		for _, change := range dataModel.ChangedForeignKeys {
+			drops = append(drops, dropConstraintStatement(name, change.Target.Name))
+			foreignKeys = append(foreignKeys, addForeignKeyStatement(name, change.Source))
+		}
+		for _, foreignKey := range dataModel.AddedForeignKeys {
+			foreignKeys = append(foreignKeys, addForeignKeyStatement(name, foreignKey))
+		}
+
+		for _, constraint := range dataModel.RemovedConstraints {
+			drops = append(drops, dropConstraintStatement(name, constraint.Name))
+		}
+		for _, change := range dataModel.ChangedConstraints {
+			drops = append(drops, dropConstraintStatement(name, change.Target.Name))
+			constraints = append(constraints, addConstraintStatement(name, change.Source))
+		}
+		for _, constraint := range dataModel.AddedConstraints {
+			constraints = append(constraints, addConstraintStatement(name, constraint))
+		}
+
+		if recreatedViews[name] {
+			// the indexes of a materialized view are dropped with it and created again
+			continue
+		}
+		for _, index := range dataModel.RemovedIndexes {
+			drops = append(drops, fmt.Sprintf("DROP INDEX %s;", QuoteIdentifier(dataModel.SchemaName, index.Name)))
+		}
+		for _, change := range dataModel.ChangedIndexes {
+			drops = append(drops, fmt.Sprintf("DROP INDEX %s;", QuoteIdentifier(dataModel.SchemaName, change.Target.Name)))
+			constraints = append(constraints, change.Source.IndexDef+";")
+		}
+		for _, index := range dataModel.AddedIndexes {
+			constraints = append(constraints, index.IndexDef+";")
+		}
+
+		if dataModel.Kind != qemodels.DATA_MODEL_KIND_TABLE {
+			continue
+		}
+		for _, field := range dataModel.AddedFields {
+			columns = append(columns, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", name, fieldDefinition(field)))
+		}
+		for _, change := range dataModel.ChangedFields {
+			columns = append(columns, alterColumnStatements(name, change)...)
+		}
+		for _, field := range dataModel.RemovedFields {
+			columns = append(columns, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", name, QuoteIdentifier(field.Name)))
+		}
+	}
+
+	sortedDroppedViews := qemodels.SortViews(droppedViews)
+	viewDrops := []string{}
+	for i := len(sortedDroppedViews) - 1; i >= 0; i-- {
+		view := sortedDroppedViews[i]
+		name := QuoteIdentifier(view.SchemaName, view.Name)
+		if view.Kind == qemodels.DATA_MODEL_KIND_MATERIALIZED_VIEW {
+			viewDrops = append(viewDrops, fmt.Sprintf("DROP MATERIALIZED VIEW %s;", name))
+		} else {
+			viewDrops = append(viewDrops, fmt.Sprintf("DROP VIEW %s;", name))
+		}
+	}
+	drops = append(viewDrops, drops...)
+	for _, view := range qemodels.SortViews(createdViews) {
+		views = append(views, viewStatement(view.Definition))
+	}
+
+	statements := []string{}
+	for _, section := range [][]string{drops, creates, columns, views, constraints, foreignKeys, tableDrops} {
+		if len(section) > 0 {
+			statements = append(statements, strings.Join(section, "\n"))
+		}
+	}
+	if len(statements) == 0 {
+		return ""
+	}
+	return "BEGIN;\n\n" + strings.Join(statements, "\n\n") + "\n\nCOMMIT;\n"
+}
+
+// fieldDefinition returns the definition of the field in a CREATE TABLE or ADD COLUMN.
+func fieldDefinition(field qemodels.DBDataModelField) string {
+	definition := QuoteIdentifier(field.Name) + " " + field.Type
+	if field.Default != nil {
+		definition += " DEFAULT " + *field.Default
+	}
+	if !field.IsNullable {
+		definition += " NOT NULL"
+	}
+	return definition
+}
+
+// alterColumnStatements returns the statements changing the type, default and nullability of the target
+// field to the ones of the source field. Primary keys are changed by their constraints.
+func alterColumnStatements(name string, change qemodels.FieldChange) []string {
+	statements := []string{}
+	column := QuoteIdentifier(change.Source.Name)
+	alterColumn := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", name, column)
+	if change.Source.Type != change.Target.Type {
+		statements = append(statements, fmt.Sprintf("%s TYPE %s USING %s::%s;", alterColumn, change.Source.Type, column, change.Source.Type))
+	}
+	sourceDefault, targetDefault := "", ""
+	if change.Source.Default != nil {
+		sourceDefault = *change.Source.Default
+	}
+	if change.Target.Default != nil {
+		targetDefault = *change.Target.Default
+	}
+	if sourceDefault != targetDefault {
+		if change.Source.Default == nil {
+			statements = append(statements, alterColumn+" DROP DEFAULT;")
+		} else {
+			statements = append(statements, fmt.Sprintf("%s SET DEFAULT %s;", alterColumn, sourceDefault))
+		}
+	}
+	if change.Source.IsNullable != change.Target.IsNullable {
+		if change.Source.IsNullable {
+			statements = append(statements, alterColumn+" DROP NOT NULL;")
+		} else {
+			statements = append(statements, alterColumn+" SET NOT NULL;")
+		}
+	}
+	return statements
+}
+
+func dropConstraintStatement(name, constraintName string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", name, QuoteIdentifier(constraintName))
+}
+
+func addConstraintStatement(name string, constraint qemodels.DBDataModelConstraint) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", name, QuoteIdentifier(constraint.Name), constraint.Definition)
+}
+
+func addForeignKeyStatement(name string, foreignKey qemodels.DBDataModelForeignKey) string {
+	quoteColumns := func(columns []string) string {
+		quoted := []string{}
+		for _, column := range columns {
+			quoted = append(quoted, QuoteIdentifier(column))
+		}
+		return strings.Join(quoted, ", ")
+	}
+	statement := fmt.Sprintf("ALTER TABLE %s ADD", name)
+	if foreignKey.Name != "" {
+		statement += " CONSTRAINT " + QuoteIdentifier(foreignKey.Name)
+	}
+	statement += fmt.Sprintf(" FOREIGN KEY (%s) REFERENCES %s", quoteColumns(foreignKey.Columns), QuoteIdentifier(foreignKey.ReferencedSchemaName, foreignKey.ReferencedName))
+	if len(foreignKey.ReferencedColumns) > 0 {
+		statement += fmt.Sprintf(" (%s)", quoteColumns(foreignKey.ReferencedColumns))
+	}
+	return statement + fmt.Sprintf(" ON DELETE %s ON UPDATE %s;", foreignKey.OnDelete, foreignKey.OnUpdate)
+}
+
+// viewStatement returns the statement creating the view from its definition, which ends with the query of the view.
+// The view does not exist or was dropped, CREATE OR REPLACE cannot change the columns of a view.
+func viewStatement(definition string) string {
+	definition = strings.TrimSpace(definition)
+	if strings.HasPrefix(definition, "CREATE OR REPLACE VIEW ") {
+		definition = "CREATE VIEW " + strings.TrimPrefix(definition, "CREATE OR REPLACE VIEW ")
+	}
+	return strings.TrimSuffix(definition, ";") + ";"
+}
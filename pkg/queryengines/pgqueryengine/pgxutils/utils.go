@@ -1,219 +1,80 @@
 package pgxutils
 
 import (
-	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/auxten/postgresql-parser/pkg/sql/parser"
 	"github.com/auxten/postgresql-parser/pkg/sql/sem/tree"
 	"github.com/auxten/postgresql-parser/pkg/walk"
-	"github.com/jackc/pgproto3/v2"
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
+	"slashbase.com/backend/internal/utils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
 )
 
-func PgSqlRowsToJson(rows pgx.Rows) ([]string, []map[string]interface{}) {
-	fieldDescriptions := rows.FieldDescriptions()
+// PgSqlRowsToJson reads the rows as json, stopping after maxRows rows (0 for no limit).
+// truncated is true when there were more rows.
+func PgSqlRowsToJson(rows pgx.Rows, maxRows int) ([]string, []map[string]interface{}, bool) {
+	tableData := make([]map[string]interface{}, 0)
+	truncated, _ := PgSqlRowsEach(rows, maxRows, func(entry map[string]interface{}) error {
+		tableData = append(tableData, entry)
+		return nil
+	})
+	return PgSqlRowsColumns(rows), tableData, truncated
+}
+
+// PgSqlRowsColumns returns the names of the columns of the rows.
+func PgSqlRowsColumns(rows pgx.Rows) []string {
 	var columns []string
-	for _, col := range fieldDescriptions {
+	for _, col := range rows.FieldDescriptions() {
 		columns = append(columns, string(col.Name))
 	}
+	return columns
+}
+
+// PgSqlRowsEach calls fn with each row as json, stopping after maxRows rows (0 for no limit)
+// or when fn returns an error. truncated is true when there were more rows.
+func PgSqlRowsEach(rows pgx.Rows, maxRows int, fn func(map[string]interface{}) error) (bool, error) {
+	fieldDescriptions := rows.FieldDescriptions()
+	columns := PgSqlRowsColumns(rows)
 
 	count := len(columns)
-	tableData := make([]map[string]interface{}, 0)
+	rowCount := 0
 
 	valuePtrs := make([]interface{}, count)
 	for rows.Next() {
+		if maxRows > 0 && rowCount == maxRows {
+			return true, nil
+		}
 		for i := 0; i < count; i++ {
 			itype := FieldType(fieldDescriptions[i])
 			valuePtrs[i] = reflect.New(itype).Interface() // allocate pointer to type
 		}
-		rows.Scan(valuePtrs...)
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return false, err
+		}
 
 		entry := make(map[string]interface{})
 		for i := range columns {
-			iStr := strconv.Itoa(i)
-			var v interface{}
-			val := reflect.ValueOf(valuePtrs[i]).Elem().Interface() // dereference pointer
-			if str, ok := val.(sql.NullString); ok {
-				if str.Valid {
-					entry[iStr] = str.String
-				} else {
-					entry[iStr] = nil
-				}
-				continue
-			}
-			if bol, ok := val.(sql.NullBool); ok {
-				if bol.Valid {
-					entry[iStr] = bol.Bool
-				} else {
-					entry[iStr] = nil
-				}
-				continue
-			}
-			if float, ok := val.(sql.NullFloat64); ok {
-				if float.Valid {
-					entry[iStr] = float.Float64
-				} else {
-					entry[iStr] = nil
-				}
-				continue
-			}
-			if inte, ok := val.(sql.NullInt32); ok {
-				if inte.Valid {
-					entry[iStr] = inte.Int32
-				} else {
-					entry[iStr] = nil
-				}
-				continue
-			}
-			if inte, ok := val.(sql.NullInt64); ok {
-				if inte.Valid {
-					entry[iStr] = inte.Int64
-				} else {
-					entry[iStr] = nil
-				}
-				continue
-			}
-			if time, ok := val.(sql.NullTime); ok {
-				if time.Valid {
-					entry[iStr] = time.Time.String()
-				} else {
-					entry[iStr] = nil
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.TID); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = fmt.Sprintf("(%d,%d)", tid.BlockNumber, tid.OffsetNumber)
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.TextArray); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.VarcharArray); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.BoolArray); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.UUIDArray); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
+			if value, ok := valuePtrs[i].(pgtype.Value); ok {
+				entry[strconv.Itoa(i)] = ValueToJson(value)
 				continue
 			}
-			if tid, ok := val.(pgtype.DateArray); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.Int2Array); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.Int4Array); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.Int8Array); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.Float4Array); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			if tid, ok := val.(pgtype.Float8Array); ok {
-				if tid.Status == pgtype.Null || tid.Status == pgtype.Undefined {
-					entry[iStr] = nil
-				} else {
-					entry[iStr] = tid.Elements
-				}
-				continue
-			}
-			b, ok := val.([]byte)
-			if ok {
-				v = string(b)
-			} else {
-				v = val
-			}
-			entry[iStr] = v
+			entry[strconv.Itoa(i)] = ValueToJson(reflect.ValueOf(valuePtrs[i]).Elem().Interface()) // dereference pointer
 		}
-		tableData = append(tableData, entry)
-	}
-	// jsonData, _ := json.Marshal(tableData)
-	return columns, tableData
-}
-
-func FieldType(fd pgproto3.FieldDescription) reflect.Type {
-	switch fd.DataTypeOID {
-	case pgtype.Float8OID:
-		return reflect.TypeOf(sql.NullFloat64{})
-	case pgtype.Float4OID:
-		return reflect.TypeOf(sql.NullFloat64{})
-	case pgtype.Int8OID:
-		return reflect.TypeOf(sql.NullInt64{})
-	case pgtype.Int4OID:
-		return reflect.TypeOf(sql.NullInt32{})
-	case pgtype.Int2OID:
-		return reflect.TypeOf(sql.NullInt32{})
-	case pgtype.VarcharOID, pgtype.BPCharArrayOID, pgtype.TextOID, pgtype.BPCharOID, pgtype.UUIDOID, pgtype.NameOID, LtreeOID:
-		return reflect.TypeOf(sql.NullString{})
-	case pgtype.BoolOID:
-		return reflect.TypeOf(sql.NullBool{})
-	case pgtype.NumericOID:
-		return reflect.TypeOf(sql.NullFloat64{})
-	case pgtype.DateOID, pgtype.TimestampOID, pgtype.TimestamptzOID:
-		return reflect.TypeOf(sql.NullTime{})
-	case pgtype.ByteaOID:
-		return reflect.TypeOf([]byte(nil))
-	case pgtype.TIDOID:
-		return reflect.TypeOf(pgtype.TID{})
-	default:
-		return reflect.TypeOf(new(interface{})).Elem()
+		if err := fn(entry); err != nil {
+			return false, err
+		}
+		rowCount++
 	}
+	return false, nil
 }
 
 const (
@@ -265,14 +126,46 @@ func GetPSQLQueryType(query string) (queryType int, isReturningRows bool) {
 	return
 }
 
+// ScriptStatement is a single statement of a script,
+// Position is the character offset of the statement in the script.
+type ScriptStatement struct {
+	Query    string
+	Position int
+	// Unparsed is true when the script could not be split, Query is then the whole script
+	Unparsed bool
+}
+
+// SplitScript splits the script into its statements.
+func SplitScript(script string) ([]ScriptStatement, error) {
+	stmts, err := parser.Parse(script)
+	if err != nil {
+		return nil, err
+	}
+	statements := []ScriptStatement{}
+	offset := 0
+	for _, stmt := range stmts {
+		index := strings.Index(script[offset:], stmt.SQL)
+		if index < 0 {
+			return nil, errors.New("unable to split the script")
+		}
+		offset += index
+		statements = append(statements, ScriptStatement{
+			Query:    stmt.SQL,
+			Position: utf8.RuneCountInString(script[:offset]),
+		})
+		offset += len(stmt.SQL)
+	}
+	return statements, nil
+}
+
 func QueryToDataModel(fieldQueryData []map[string]interface{}, constraintsQueryData []map[string]interface{}) []map[string]interface{} {
 	fields := []map[string]interface{}{}
 
 	constraintMap := map[int32]map[string]interface{}{}
 	for _, constraint := range constraintsQueryData {
-		conkey := constraint["0"].([]pgtype.Int2)
+		conkey, _ := constraint["0"].([]interface{})
 		for _, colKey := range conkey {
-			constraintMap[int32(colKey.Int)] = constraint
+			constraintMap[int32(colKey.(int16))] = constraint
 		}
 	}
 
@@ -287,25 +180,27 @@ func QueryToDataModel(fieldQueryData []map[string]interface{}, constraintsQueryD
 		}
 		tags := []string{}
 		if constraint["2"] != nil {
-			field["isPrimary"] = rune(constraint["2"].(int8)) == 'p'
-			if rune(constraint["2"].(int8)) == 'u' {
+			contype := constraint["2"].(string)
+			field["isPrimary"] = contype == "p"
+			if contype == "u" {
 				tags = append(tags, "Unique")
 			}
-			if rune(constraint["2"].(int8)) == 'c' {
+			if contype == "c" {
 				tags = append(tags, "Check: "+constraint["1"].(string))
 			}
-			if rune(constraint["2"].(int8)) == 'f' {
+			if contype == "f" {
 				tags = append(tags, "Foreign Key: "+constraint["1"].(string))
 			}
-			if rune(constraint["2"].(int8)) == 't' {
+			if contype == "t" {
 				tags = append(tags, "Trigger: "+constraint["1"].(string))
 			}
-			if rune(constraint["2"].(int8)) == 'x' {
+			if contype == "x" {
 				tags = append(tags, "Exclusion: "+constraint["1"].(string))
 			}
 		}
 		if fieldData["4"] != nil {
 			coldef := fieldData["4"].(string)
+			field["default"] = fieldData["4"]
 			tags = append(tags, "Default: "+coldef)
 		}
 		if fieldData["5"] != nil {
@@ -318,3 +213,566 @@ func QueryToDataModel(fieldQueryData []map[string]interface{}, constraintsQueryD
 
 	return fields
 }
+
+// QueryToForeignKeys converts the rows of the foreign keys query, the columns are the name, the columns,
+// the referenced schema, table and columns, and the confdeltype and confupdtype of the constraint.
+func QueryToForeignKeys(foreignKeysQueryData []map[string]interface{}) []qemodels.DBDataModelForeignKey {
+	foreignKeys := []qemodels.DBDataModelForeignKey{}
+	for _, foreignKeyData := range foreignKeysQueryData {
+		foreignKeys = append(foreignKeys, qemodels.DBDataModelForeignKey{
+			Name:                 foreignKeyData["0"].(string),
+			Columns:              toStrings(foreignKeyData["1"]),
+			ReferencedSchemaName: foreignKeyData["2"].(string),
+			ReferencedName:       foreignKeyData["3"].(string),
+			ReferencedColumns:    toStrings(foreignKeyData["4"]),
+			OnDelete:             ForeignKeyAction(foreignKeyData["5"].(string)),
+			OnUpdate:             ForeignKeyAction(foreignKeyData["6"].(string)),
+		})
+	}
+	return foreignKeys
+}
+
+// ForeignKeyAction returns the action of a confdeltype or confupdtype code of pg_constraint.
+func ForeignKeyAction(code string) string {
+	switch code {
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	default:
+		return "NO ACTION"
+	}
+}
+
+func toStrings(list interface{}) []string {
+	strs := []string{}
+	items, _ := list.([]interface{})
+	for _, item := range items {
+		strs = append(strs, fmt.Sprint(item))
+	}
+	return strs
+}
+
+// serialTypes are the types of the serial pseudo-types, columns with a default from their owned sequence
+// are created with them so that the script also creates the sequence.
+var serialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+// CreateTableScript returns the script creating the table from the rows of the table, fields, constraints
+// and indexes queries of the engine. The indexes backing a constraint are created by the constraint.
+func CreateTableScript(schema, name string, tableData map[string]interface{}, fieldsQueryData, constraintsQueryData, indexesQueryData []map[string]interface{}) string {
+	table := QuoteIdentifier(schema, name)
+	definitions := []string{}
+	for _, fieldData := range fieldsQueryData {
+		definitions = append(definitions, columnDefinition(fieldData))
+	}
+	constraintNames := map[string]bool{}
+	for _, constraint := range constraintsQueryData {
+		contype := constraint["2"].(string)
+		if !utils.ContainsString([]string{"p", "u", "c", "x", "f"}, contype) {
+			continue
+		}
+		constraintNames[constraint["1"].(string)] = true
+		definitions = append(definitions, fmt.Sprintf("CONSTRAINT %s %s", QuoteIdentifier(constraint["1"].(string)), constraint["3"].(string)))
+	}
+
+	createTable := "CREATE TABLE"
+	if tableData["2"] == "u" {
+		createTable = "CREATE UNLOGGED TABLE"
+	}
+	script := fmt.Sprintf("%s %s (\n    %s\n)", createTable, table, strings.Join(definitions, ",\n    "))
+	if partitionKey, ok := tableData["3"].(string); ok {
+		script += " PARTITION BY " + partitionKey
+	}
+	script += ";\n"
+
+	indexes := []string{}
+	for _, index := range indexesQueryData {
+		if !constraintNames[index["0"].(string)] {
+			indexes = append(indexes, index["1"].(string)+";\n")
+		}
+	}
+	if len(indexes) > 0 {
+		script += "\n" + strings.Join(indexes, "")
+	}
+
+	comments := []string{}
+	if comment, ok := tableData["1"].(string); ok {
+		comments = append(comments, fmt.Sprintf("COMMENT ON TABLE %s IS %s;\n", table, QuoteString(comment)))
+	}
+	for _, fieldData := range fieldsQueryData {
+		if comment, ok := fieldData["9"].(string); ok {
+			comments = append(comments, fmt.Sprintf("COMMENT ON COLUMN %s IS %s;\n", QuoteIdentifier(schema, name, fieldData["1"].(string)), QuoteString(comment)))
+		}
+	}
+	if len(comments) > 0 {
+		script += "\n" + strings.Join(comments, "")
+	}
+
+	script += fmt.Sprintf("\nALTER TABLE %s OWNER TO %s;\n", table, QuoteIdentifier(tableData["0"].(string)))
+	return script
+}
+
+// columnDefinition returns the definition of the column in the CREATE TABLE script from its row of the fields query.
+func columnDefinition(fieldData map[string]interface{}) string {
+	dataType := fieldData["6"].(string)
+	defaultExpr, hasDefault := fieldData["4"].(string)
+	if serialType, ok := serialTypes[dataType]; ok && fieldData["10"] == true && hasDefault && strings.HasPrefix(defaultExpr, "nextval(") {
+		dataType = serialType
+		hasDefault = false
+	}
+	definition := QuoteIdentifier(fieldData["1"].(string)) + " " + dataType
+	if collation, ok := fieldData["11"].(string); ok {
+		definition += " COLLATE " + collation
+	}
+	switch {
+	case fieldData["8"] == "s":
+		definition += fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", defaultExpr)
+	case fieldData["7"] == "a":
+		definition += " GENERATED ALWAYS AS IDENTITY"
+	case fieldData["7"] == "d":
+		definition += " GENERATED BY DEFAULT AS IDENTITY"
+	case hasDefault:
+		definition += " DEFAULT " + defaultExpr
+	}
+	if fieldData["3"] == "NO" {
+		definition += " NOT NULL"
+	}
+	return definition
+}
+
+// QuoteIdentifier quotes and joins the parts of an identifier, like schema and table name.
+func QuoteIdentifier(parts ...string) string {
+	return pgx.Identifier(parts).Sanitize()
+}
+
+// FilterQuery compiles the filter tree to a where condition,
+// the values are passed as bind parameters starting from $argIndex.
+func FilterQuery(filter *qemodels.Filter, argIndex int) (string, []interface{}) {
+	args := []interface{}{}
+	if filter.IsGroup() {
+		conditions := []string{}
+		for _, condition := range filter.Conditions {
+			query, conditionArgs := FilterQuery(condition, argIndex+len(args))
+			conditions = append(conditions, query)
+			args = append(args, conditionArgs...)
+		}
+		return "(" + strings.Join(conditions, " "+filter.Group+" ") + ")", args
+	}
+	column := QuoteIdentifier(filter.Field)
+	switch filter.Operator {
+	case qemodels.FILTER_OP_IS_NULL, qemodels.FILTER_OP_IS_NOT_NULL:
+		return fmt.Sprintf(`%s %s`, column, filter.Operator), args
+	case qemodels.FILTER_OP_IN, qemodels.FILTER_OP_NOT_IN:
+		placeholders := []string{}
+		for i, value := range filter.Values {
+			placeholders = append(placeholders, fmt.Sprintf("$%d", argIndex+i))
+			args = append(args, paramValue(value))
+		}
+		return fmt.Sprintf(`%s %s (%s)`, column, filter.Operator, strings.Join(placeholders, ", ")), args
+	case qemodels.FILTER_OP_BETWEEN:
+		args = append(args, paramValue(filter.Values[0]), paramValue(filter.Values[1]))
+		return fmt.Sprintf(`%s BETWEEN $%d AND $%d`, column, argIndex, argIndex+1), args
+	case qemodels.FILTER_OP_LIKE, qemodels.FILTER_OP_NOT_LIKE, qemodels.FILTER_OP_ILIKE, qemodels.FILTER_OP_NOT_ILIKE:
+		args = append(args, filter.Value)
+		return fmt.Sprintf(`%s::text %s $%d`, column, filter.Operator, argIndex), args
+	case qemodels.FILTER_OP_REGEX:
+		args = append(args, filter.Value)
+		return fmt.Sprintf(`%s::text ~ $%d`, column, argIndex), args
+	}
+	args = append(args, paramValue(filter.Value))
+	return fmt.Sprintf(`%s %s $%d`, column, filter.Operator, argIndex), args
+}
+
+// paramValue converts json values to text, so that postgres parses them as the type of the column.
+func paramValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case nil, string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// SortQuery builds the order by clause for the sort fields.
+func SortQuery(sort []qemodels.SortField) string {
+	if len(sort) == 0 {
+		return ""
+	}
+	sortColumns := []string{}
+	for _, field := range sort {
+		sortColumns = append(sortColumns, QuoteIdentifier(field.Field)+" "+field.Direction)
+	}
+	return " ORDER BY " + strings.Join(sortColumns, ", ")
+}
+
+// KeysetCondition builds the condition selecting the rows after the cursor values of the keys,
+// for rows ordered with the default null ordering of postgres (NULLS LAST for ASC, NULLS FIRST for DESC).
+// The values are passed as bind parameters starting from $argIndex.
+func KeysetCondition(keys []qemodels.SortField, values []*string, notNullColumns []string, argIndex int) (string, []interface{}) {
+	args := []interface{}{}
+	uniform := true
+	for i, key := range keys {
+		if key.Direction != keys[0].Direction || values[i] == nil || !utils.ContainsString(notNullColumns, key.Field) {
+			uniform = false
+		}
+	}
+	if uniform {
+		// a row comparison can use the index on the keys
+		columns := []string{}
+		placeholders := []string{}
+		for i, key := range keys {
+			columns = append(columns, QuoteIdentifier(key.Field))
+			placeholders = append(placeholders, fmt.Sprintf("$%d", argIndex+i))
+			args = append(args, *values[i])
+		}
+		operator := ">"
+		if keys[0].Direction == "DESC" {
+			operator = "<"
+		}
+		return fmt.Sprintf(`(%s) %s (%s)`, strings.Join(columns, ", "), operator, strings.Join(placeholders, ", ")), args
+	}
+	terms := []string{}
+	equalConditions := []string{}
+	for i, key := range keys {
+		column := QuoteIdentifier(key.Field)
+		value := values[i]
+		after := ""
+		switch {
+		case value == nil && key.Direction == "DESC":
+			after = column + " IS NOT NULL"
+		case value == nil:
+			// nothing is after null in ascending order
+		case key.Direction == "DESC":
+			after = fmt.Sprintf(`%s < $%d`, column, argIndex+len(args))
+			args = append(args, *value)
+		case utils.ContainsString(notNullColumns, key.Field):
+			after = fmt.Sprintf(`%s > $%d`, column, argIndex+len(args))
+			args = append(args, *value)
+		default:
+			after = fmt.Sprintf(`(%s > $%d OR %s IS NULL)`, column, argIndex+len(args), column)
+			args = append(args, *value)
+		}
+		if after != "" {
+			term := append(append([]string{}, equalConditions...), after)
+			terms = append(terms, "("+strings.Join(term, " AND ")+")")
+		}
+		if i == len(keys)-1 {
+			break
+		}
+		if value == nil {
+			equalConditions = append(equalConditions, column+" IS NULL")
+		} else {
+			equalConditions = append(equalConditions, fmt.Sprintf(`%s = $%d`, column, argIndex+len(args)))
+			args = append(args, *value)
+		}
+	}
+	if len(terms) == 0 {
+		return "FALSE", args
+	}
+	return "(" + strings.Join(terms, " OR ") + ")", args
+}
+
+// KeysetCursorExpression returns the expression selecting the text values of the keys as a json array.
+func KeysetCursorExpression(keys []qemodels.SortField) string {
+	columns := []string{}
+	for _, key := range keys {
+		columns = append(columns, QuoteIdentifier(key.Field)+"::text")
+	}
+	return fmt.Sprintf(`json_build_array(%s)::text`, strings.Join(columns, ", "))
+}
+
+// InsertQuery builds the insert query for a single row returning the returning expression,
+// columns are sorted by name and values are passed as bind parameters in the same order.
+func InsertQuery(schema, name string, data map[string]interface{}, returning string) (string, []interface{}) {
+	keys := []string{}
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	columns := []string{}
+	placeholders := []string{}
+	args := []interface{}{}
+	for i, key := range keys {
+		columns = append(columns, QuoteIdentifier(key))
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+		args = append(args, data[key])
+	}
+	query := fmt.Sprintf(`INSERT INTO %s(%s) VALUES(%s) RETURNING %s;`,
+		QuoteIdentifier(schema, name), strings.Join(columns, ", "), strings.Join(placeholders, ", "), returning)
+	return query, args
+}
+
+// QuoteString quotes a string literal using single quotes.
+func QuoteString(value string) string {
+	value = strings.ReplaceAll(value, string([]byte{0}), "")
+	return `'` + strings.ReplaceAll(value, `'`, `''`) + `'`
+}
+
+// RowIDExpression returns the expression selecting the row id: a json object of the
+// key columns or ctid when the table does not have key columns.
+func RowIDExpression(keyColumns []string) string {
+	if len(keyColumns) == 0 {
+		return "ctid"
+	}
+	args := []string{}
+	for _, column := range keyColumns {
+		args = append(args, QuoteString(column)+", "+QuoteIdentifier(column))
+	}
+	return fmt.Sprintf(`json_build_object(%s)::text`, strings.Join(args, ", "))
+}
+
+// RowIDCondition parses the row id returned by RowIDExpression and returns the where condition
+// to select the row, the values are passed as bind parameters starting from $argIndex.
+func RowIDCondition(keyColumns []string, rowID string, argIndex int) (string, []interface{}, error) {
+	if len(keyColumns) == 0 {
+		return fmt.Sprintf(`ctid = $%d`, argIndex), []interface{}{rowID}, nil
+	}
+	rowIDValues := map[string]interface{}{}
+	decoder := json.NewDecoder(strings.NewReader(rowID))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rowIDValues); err != nil || len(rowIDValues) != len(keyColumns) {
+		return "", nil, errors.New("invalid row id")
+	}
+	conditions := []string{}
+	args := []interface{}{}
+	for i, column := range keyColumns {
+		value, exists := rowIDValues[column]
+		if !exists || value == nil {
+			return "", nil, errors.New("invalid row id")
+		}
+		if _, isString := value.(string); !isString {
+			// numbers and booleans are sent as text and parsed by the column type
+			value = fmt.Sprint(value)
+		}
+		conditions = append(conditions, fmt.Sprintf(`%s = $%d`, QuoteIdentifier(column), argIndex+i))
+		args = append(args, value)
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// IsSingleStatement reports if the query parses to exactly one statement.
+func IsSingleStatement(query string) bool {
+	stmts, err := parser.Parse(query)
+	return err == nil && len(stmts) == 1
+}
+
+// StatementsCount returns the number of statements of the query by the lexer, -1 if the query cannot be lexed.
+// Unlike IsSingleStatement it does not parse the query, so it works with the types unknown to the parser.
+func StatementsCount(query string) int {
+	tokens, ok := parser.Tokens(query)
+	if !ok {
+		return -1
+	}
+	count := 0
+	for i, token := range tokens {
+		if token.TokenID == ';' || i == len(tokens)-1 {
+			count++
+		}
+	}
+	return count
+}
+
+// ExplainQuery returns the EXPLAIN statement which gets the plan of the query as json.
+func ExplainQuery(query string, analyze bool) string {
+	options := "FORMAT JSON"
+	if analyze {
+		options = "ANALYZE, BUFFERS, FORMAT JSON"
+	}
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	return fmt.Sprintf("EXPLAIN (%s) %s", options, query)
+}
+
+// planNodeKeys are the keys of an EXPLAIN plan node which are normalised to the fields of qemodels.PlanNode.
+var planNodeKeys = []string{
+	"Node Type", "Relation Name", "Schema", "Index Name", "Startup Cost", "Total Cost",
+	"Plan Rows", "Actual Rows", "Actual Total Time", "Actual Loops", "Plans",
+}
+
+// ExplainToPlan normalises the output of EXPLAIN (FORMAT JSON) to a plan tree.
+func ExplainToPlan(explainJson string, analyze bool) (*qemodels.QueryPlan, error) {
+	var explain []map[string]interface{}
+	if err := json.Unmarshal([]byte(explainJson), &explain); err != nil || len(explain) == 0 {
+		return nil, errors.New("invalid explain output")
+	}
+	plan, ok := explain[0]["Plan"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid explain output")
+	}
+	return &qemodels.QueryPlan{
+		Plan:            explainPlanNode(plan),
+		Analyzed:        analyze,
+		PlanningTimeMs:  planFloat(explain[0], "Planning Time"),
+		ExecutionTimeMs: planFloat(explain[0], "Execution Time"),
+		Raw:             explain,
+	}, nil
+}
+
+func explainPlanNode(plan map[string]interface{}) *qemodels.PlanNode {
+	node := &qemodels.PlanNode{
+		Details:  map[string]interface{}{},
+		Children: []*qemodels.PlanNode{},
+	}
+	node.NodeType, _ = plan["Node Type"].(string)
+	node.Relation, _ = plan["Relation Name"].(string)
+	if schema, ok := plan["Schema"].(string); ok && node.Relation != "" {
+		node.Relation = schema + "." + node.Relation
+	}
+	node.Index, _ = plan["Index Name"].(string)
+	node.StartupCost = planFloat(plan, "Startup Cost")
+	node.TotalCost = planFloat(plan, "Total Cost")
+	node.EstimatedRows = planFloat(plan, "Plan Rows")
+	node.Loops = planFloat(plan, "Actual Loops")
+	if node.Loops != nil {
+		// postgres reports the actual rows and time as averages per loop
+		if rows := planFloat(plan, "Actual Rows"); rows != nil {
+			totalRows := *rows * *node.Loops
+			node.ActualRows = &totalRows
+		}
+		if time := planFloat(plan, "Actual Total Time"); time != nil {
+			totalTime := *time * *node.Loops
+			node.ActualTimeMs = &totalTime
+		}
+	}
+	if children, ok := plan["Plans"].([]interface{}); ok {
+		for _, child := range children {
+			if childPlan, ok := child.(map[string]interface{}); ok {
+				node.Children = append(node.Children, explainPlanNode(childPlan))
+			}
+		}
+	}
+	for key, value := range plan {
+		if !utils.ContainsString(planNodeKeys, key) {
+			node.Details[key] = value
+		}
+	}
+	return node
+}
+
+func planFloat(plan map[string]interface{}, key string) *float64 {
+	value, ok := plan[key].(float64)
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// indexMethods are the index access methods built in postgres.
+var indexMethods = []string{"btree", "hash", "gist", "spgist", "gin", "brin"}
+
+// CreateIndexQuery returns the CREATE INDEX statement of the index on the table, without a name
+// postgres names the index. The operator class of a field may be schema qualified, and the
+// predicate of a partial index must be a single condition.
+func CreateIndexQuery(schema, name string, index *qemodels.IndexSpec) (string, error) {
+	if len(index.Fields) == 0 {
+		return "", errors.New("index must have fields")
+	}
+	method := strings.ToLower(index.Method)
+	if method == "" {
+		method = "btree"
+	}
+	if !utils.ContainsString(indexMethods, method) {
+		return "", errors.New("invalid index method")
+	}
+	query := "CREATE"
+	if index.Unique {
+		query += " UNIQUE"
+	}
+	query += " INDEX"
+	if index.Concurrently {
+		query += " CONCURRENTLY"
+	}
+	if index.Name != "" {
+		query += " " + QuoteIdentifier(index.Name)
+	}
+	fields := []string{}
+	for _, field := range index.Fields {
+		fieldDef := QuoteIdentifier(field.Name)
+		if field.Type != "" {
+			fieldDef += " " + QuoteIdentifier(strings.Split(field.Type, ".")...)
+		}
+		if field.Descending {
+			fieldDef += " DESC"
+		}
+		fields = append(fields, fieldDef)
+	}
+	query += fmt.Sprintf(" ON %s USING %s (%s)", QuoteIdentifier(schema, name), method, strings.Join(fields, ", "))
+	if index.Where != "" {
+		if !qemodels.IsSingleExpression(index.Where) {
+			return "", errors.New("invalid index predicate")
+		}
+		query += " WHERE " + index.Where
+	}
+	query += ";"
+	if StatementsCount(query) != 1 {
+		return "", errors.New("invalid index predicate")
+	}
+	return query, nil
+}
+
+// DropIndexQuery returns the DROP INDEX statement of the index in the schema.
+func DropIndexQuery(schema, indexName string, concurrently bool) string {
+	if concurrently {
+		return fmt.Sprintf("DROP INDEX CONCURRENTLY %s;", QuoteIdentifier(schema, indexName))
+	}
+	return fmt.Sprintf("DROP INDEX %s;", QuoteIdentifier(schema, indexName))
+}
+
+// AlterFieldQuery returns the statements altering the column of the table, the column is renamed last
+// as RENAME cannot be combined with the other changes in an ALTER TABLE.
+func AlterFieldQuery(schema, name, fieldName string, alteration *qemodels.FieldAlteration) (string, error) {
+	table := QuoteIdentifier(schema, name)
+	alterColumn := "ALTER COLUMN " + QuoteIdentifier(fieldName)
+	changes := []string{}
+	if alteration.Type != "" {
+		change := fmt.Sprintf("%s TYPE %s", alterColumn, alteration.Type)
+		if alteration.Using != "" {
+			change += " USING " + alteration.Using
+		}
+		changes = append(changes, change)
+	}
+	if alteration.Default != nil {
+		changes = append(changes, fmt.Sprintf("%s SET DEFAULT %s", alterColumn, *alteration.Default))
+	} else if alteration.DropDefault {
+		changes = append(changes, alterColumn+" DROP DEFAULT")
+	}
+	if alteration.IsNullable != nil {
+		if *alteration.IsNullable {
+			changes = append(changes, alterColumn+" DROP NOT NULL")
+		} else {
+			changes = append(changes, alterColumn+" SET NOT NULL")
+		}
+	}
+	for _, part := range []string{alteration.Type, alteration.Using} {
+		if !qemodels.IsSingleExpression(part) {
+			return "", errors.New("invalid type or expression")
+		}
+	}
+	if alteration.Default != nil && !qemodels.IsSingleExpression(*alteration.Default) {
+		return "", errors.New("invalid default")
+	}
+	statements := []string{}
+	if len(changes) > 0 {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s %s;", table, strings.Join(changes, ", ")))
+	}
+	if alteration.NewName != "" && alteration.NewName != fieldName {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", table, QuoteIdentifier(fieldName), QuoteIdentifier(alteration.NewName)))
+	}
+	if len(statements) == 0 {
+		return "", errors.New("nothing to alter")
+	}
+	query := strings.Join(statements, "\n")
+	if StatementsCount(query) != len(statements) {
+		return "", errors.New("invalid type or expression")
+	}
+	return query, nil
+}
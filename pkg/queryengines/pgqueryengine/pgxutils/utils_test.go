@@ -1,7 +1,14 @@
 package pgxutils
 
 import (
+	"encoding/json"
+	"math/big"
+	"strings"
 	"testing"
+
+	"github.com/jackc/pgtype"
+
+	"slashbase.com/backend/pkg/queryengines/qemodels"
 )
 
 func TestReadPGSQLType(t *testing.T) {
@@ -39,3 +46,686 @@ func TestWriteReturningRowsPGSQLType(t *testing.T) {
 		t.Error("isReturningRows: ", isReturningRows)
 	}
 }
+
+func TestQuoteIdentifier(t *testing.T) {
+	if quoted := QuoteIdentifier("public", `my"table`); quoted != `"public"."my""table"` {
+		t.Error("quoted:", quoted)
+	}
+	if quoted := QuoteIdentifier("select"); quoted != `"select"` {
+		t.Error("quoted:", quoted)
+	}
+	if quoted := QuoteIdentifier("名前"); quoted != `"名前"` {
+		t.Error("quoted:", quoted)
+	}
+}
+
+func TestFilterQuery(t *testing.T) {
+	filter := &qemodels.Filter{
+		Group: qemodels.FILTER_GROUP_OR,
+		Conditions: []*qemodels.Filter{
+			{Field: "order", Operator: qemodels.FILTER_OP_ILIKE, Value: "O'Reilly 🚀%"},
+			{Group: qemodels.FILTER_GROUP_AND, Conditions: []*qemodels.Filter{
+				{Field: "名前", Operator: qemodels.FILTER_OP_IN, Values: []interface{}{"a", 2.5}},
+				{Field: "created", Operator: qemodels.FILTER_OP_BETWEEN, Values: []interface{}{"2020-01-01", "2021-01-01"}},
+				{Field: "deleted", Operator: qemodels.FILTER_OP_IS_NULL},
+			}},
+		},
+	}
+	if err := filter.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	condition, args := FilterQuery(filter, 2)
+	expected := `("order"::text ILIKE $2 OR ("名前" IN ($3, $4) AND "created" BETWEEN $5 AND $6 AND "deleted" IS NULL))`
+	if condition != expected {
+		t.Error("condition:", condition)
+	}
+	if len(args) != 5 || args[0] != "O'Reilly 🚀%" || args[2] != "2.5" {
+		t.Error("args:", args)
+	}
+	stype, isReturningRows := GetPSQLQueryType(`SELECT * FROM "public"."users" WHERE ` + condition + ` LIMIT $1;`)
+	if stype != QUERY_READ || !isReturningRows {
+		t.Error("stype:", stype, "isReturningRows:", isReturningRows)
+	}
+	invalid := &qemodels.Filter{Field: "id", Operator: "= 1 OR 1 =", Value: "1"}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected invalid filter operator error")
+	}
+}
+
+func TestSortQuery(t *testing.T) {
+	sort := []qemodels.SortField{{Field: `gro"up`, Direction: "desc"}, {Field: "id"}}
+	for i := range sort {
+		if err := sort[i].Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sortQuery := SortQuery(sort); sortQuery != ` ORDER BY "gro""up" DESC, "id" ASC` {
+		t.Error("sortQuery:", sortQuery)
+	}
+	invalid := qemodels.SortField{Field: "id", Direction: "ASC; DROP TABLE users"}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected invalid sort direction error")
+	}
+}
+
+func TestInsertQuery(t *testing.T) {
+	query, args := InsertQuery("public", "users", map[string]interface{}{
+		"table": "it's",
+		"名前":    "日本語",
+	}, "ctid")
+	if query != `INSERT INTO "public"."users"("table", "名前") VALUES($1, $2) RETURNING ctid;` {
+		t.Error("query:", query)
+	}
+	if len(args) != 2 || args[0] != "it's" || args[1] != "日本語" {
+		t.Error("args:", args)
+	}
+	stype, isReturningRows := GetPSQLQueryType(query)
+	if stype != QUERY_WRITE || !isReturningRows {
+		t.Error("stype:", stype, "isReturningRows:", isReturningRows)
+	}
+}
+
+func TestIsSingleStatement(t *testing.T) {
+	if !IsSingleStatement(`ALTER TABLE "public"."users" ADD COLUMN "select" varchar(255);`) {
+		t.Error("expected single statement")
+	}
+	if IsSingleStatement(`ALTER TABLE "public"."users" ADD COLUMN "bio" text; DROP TABLE users;`) {
+		t.Error("expected multiple statements")
+	}
+}
+
+func TestRowIDExpression(t *testing.T) {
+	if expr := RowIDExpression([]string{}); expr != "ctid" {
+		t.Error("expr:", expr)
+	}
+	expr := RowIDExpression([]string{"user", "it's"})
+	if expr != `json_build_object('user', "user", 'it''s', "it's")::text` {
+		t.Error("expr:", expr)
+	}
+	stype, isReturningRows := GetPSQLQueryType(`SELECT ` + expr + ` AS "_rowid", * FROM "public"."users" LIMIT $1 OFFSET $2;`)
+	if stype != QUERY_READ || !isReturningRows {
+		t.Error("stype:", stype, "isReturningRows:", isReturningRows)
+	}
+}
+
+func TestRowIDCondition(t *testing.T) {
+	condition, args, err := RowIDCondition([]string{"order", "名前"}, `{"order": 9007199254740993, "名前": "O'Reilly"}`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if condition != `"order" = $2 AND "名前" = $3` {
+		t.Error("condition:", condition)
+	}
+	if len(args) != 2 || args[0] != "9007199254740993" || args[1] != "O'Reilly" {
+		t.Error("args:", args)
+	}
+	condition, args, err = RowIDCondition([]string{}, "(0,1)", 1)
+	if err != nil || condition != "ctid = $1" || len(args) != 1 || args[0] != "(0,1)" {
+		t.Error("condition:", condition, "args:", args, "err:", err)
+	}
+	if _, _, err = RowIDCondition([]string{"id"}, `{"other": 1}`, 1); err == nil {
+		t.Error("expected invalid row id error")
+	}
+	if _, _, err = RowIDCondition([]string{"id"}, `(0,1)`, 1); err == nil {
+		t.Error("expected invalid row id error")
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	id1, id2 := "10", "20"
+	keys := []qemodels.SortField{{Field: "tenant", Direction: "ASC"}, {Field: "id", Direction: "ASC"}}
+	condition, args := KeysetCondition(keys, []*string{&id1, &id2}, []string{"tenant", "id"}, 1)
+	if condition != `("tenant", "id") > ($1, $2)` || len(args) != 2 {
+		t.Error("condition:", condition, "args:", args)
+	}
+
+	name := "O'Reilly"
+	keys = []qemodels.SortField{{Field: "name", Direction: "DESC"}, {Field: "order", Direction: "ASC"}, {Field: "id", Direction: "ASC"}}
+	condition, args = KeysetCondition(keys, []*string{&name, nil, &id1}, []string{"id"}, 3)
+	expected := `(("name" < $3) OR ("name" = $4 AND "order" IS NULL AND "id" > $5))`
+	if condition != expected {
+		t.Error("condition:", condition)
+	}
+	if len(args) != 3 || args[0] != name || args[2] != id1 {
+		t.Error("args:", args)
+	}
+	stype, isReturningRows := GetPSQLQueryType(`SELECT *, ` + KeysetCursorExpression(keys) + ` AS "_cursor" FROM "users" WHERE ` + condition + SortQuery(keys) + ` LIMIT $1;`)
+	if stype != QUERY_READ || !isReturningRows {
+		t.Error("stype:", stype, "isReturningRows:", isReturningRows)
+	}
+}
+
+func TestSplitScript(t *testing.T) {
+	script := `-- créer la table
+CREATE TABLE "naïve"(id serial PRIMARY KEY, "select" text);
+INSERT INTO "naïve"("select") VALUES ('a;b');
+
+SELECT * FROM "naïve"`
+	statements, err := SplitScript(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 3 {
+		t.Fatal("statements:", statements)
+	}
+	if statements[1].Query != `INSERT INTO "naïve"("select") VALUES ('a;b')` {
+		t.Error("query:", statements[1].Query)
+	}
+	runes := []rune(script)
+	for _, statement := range statements {
+		if !strings.HasPrefix(string(runes[statement.Position:]), statement.Query) {
+			t.Error("position:", statement.Position, "query:", statement.Query)
+		}
+	}
+}
+
+func TestExplainQuery(t *testing.T) {
+	if query := ExplainQuery(" SELECT 1; ", false); query != "EXPLAIN (FORMAT JSON) SELECT 1" {
+		t.Error("query:", query)
+	}
+	if query := ExplainQuery("SELECT 1", true); query != "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) SELECT 1" {
+		t.Error("query:", query)
+	}
+}
+
+func TestExplainToPlan(t *testing.T) {
+	explainJson := `[{"Plan": {"Node Type": "Nested Loop", "Join Type": "Inner", "Startup Cost": 0.29, "Total Cost": 16.6,
+		"Plan Rows": 2, "Actual Rows": 2, "Actual Total Time": 0.05, "Actual Loops": 1,
+		"Plans": [
+			{"Node Type": "Seq Scan", "Relation Name": "users", "Startup Cost": 0, "Total Cost": 1.02, "Plan Rows": 2,
+				"Actual Rows": 2, "Actual Total Time": 0.01, "Actual Loops": 1},
+			{"Node Type": "Index Scan", "Relation Name": "teams", "Index Name": "teams_pkey", "Startup Cost": 0.29,
+				"Total Cost": 7.7, "Plan Rows": 1, "Actual Rows": 1, "Actual Total Time": 0.015, "Actual Loops": 2}
+		]},
+		"Planning Time": 0.2, "Execution Time": 0.08}]`
+	plan, err := ExplainToPlan(explainJson, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Analyzed || *plan.PlanningTimeMs != 0.2 || *plan.ExecutionTimeMs != 0.08 {
+		t.Error("plan:", plan)
+	}
+	root := plan.Plan
+	if root.NodeType != "Nested Loop" || *root.TotalCost != 16.6 || root.Details["Join Type"] != "Inner" || len(root.Children) != 2 {
+		t.Fatal("root:", root)
+	}
+	if _, exists := root.Details["Plans"]; exists {
+		t.Error("details:", root.Details)
+	}
+	indexScan := root.Children[1]
+	if indexScan.Relation != "teams" || indexScan.Index != "teams_pkey" || *indexScan.ActualRows != 2 || *indexScan.ActualTimeMs != 0.03 {
+		t.Error("index scan:", indexScan)
+	}
+
+	plan, err = ExplainToPlan(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "users", "Schema": "public", "Total Cost": 1.02, "Plan Rows": 2}}]`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Plan.Relation != "public.users" || plan.Plan.ActualRows != nil || plan.Plan.Loops != nil || plan.ExecutionTimeMs != nil {
+		t.Error("plan:", plan.Plan)
+	}
+
+	if _, err := ExplainToPlan(`[]`, false); err == nil {
+		t.Error("expected error for empty explain")
+	}
+}
+
+func TestNumericToString(t *testing.T) {
+	cases := map[string]string{
+		"123456789012345678901234567890.123456789": "123456789012345678901234567890.123456789",
+		"1.50":   "1.50",
+		"-0.001": "-0.001",
+		"0":      "0",
+		"NaN":    "NaN",
+	}
+	for text, expected := range cases {
+		numeric := pgtype.Numeric{}
+		if err := numeric.DecodeText(nil, []byte(text)); err != nil {
+			t.Fatal(err)
+		}
+		if str := NumericToString(numeric); str != expected {
+			t.Error(text, "str:", str)
+		}
+	}
+	if str := NumericToString(pgtype.Numeric{Int: big.NewInt(-12), Exp: 3, Status: pgtype.Present}); str != "-12000" {
+		t.Error("str:", str)
+	}
+}
+
+func TestIntervalToISO8601(t *testing.T) {
+	cases := []struct {
+		interval pgtype.Interval
+		expected string
+	}{
+		{pgtype.Interval{Months: 14, Days: 3, Microseconds: 4*3600e6 + 5*60e6 + 6.5e6}, "P1Y2M3DT4H5M6.5S"},
+		{pgtype.Interval{Microseconds: -90e6}, "PT-1M-30S"},
+		{pgtype.Interval{}, "PT0S"},
+	}
+	for _, c := range cases {
+		if duration := IntervalToISO8601(c.interval); duration != c.expected {
+			t.Error("duration:", duration)
+		}
+	}
+}
+
+func TestValueToJson(t *testing.T) {
+	timestamptz := pgtype.Timestamptz{}
+	timestamptz.DecodeText(nil, []byte("2022-03-04 05:06:07.123456+05:30"))
+	timestamp := pgtype.Timestamp{}
+	timestamp.DecodeText(nil, []byte("2022-03-04 05:06:07"))
+	date := pgtype.Date{}
+	date.DecodeText(nil, []byte("infinity"))
+	float := pgtype.Float8{}
+	float.DecodeText(nil, []byte("NaN"))
+	jsonb := pgtype.JSONB{}
+	jsonb.DecodeText(nil, []byte(`{"big": 12345678901234567890}`))
+	bytea := pgtype.Bytea{}
+	bytea.DecodeText(nil, []byte(`\xdeadbeef`))
+	inet := pgtype.Inet{}
+	inet.DecodeText(nil, []byte("192.168.0.1/24"))
+	numrange := pgtype.Numrange{}
+	numrange.DecodeText(nil, []byte("[1.5,2.25)"))
+	array := pgtype.Int4Array{}
+	array.DecodeText(nil, []byte("{{1,2},{3,NULL}}"))
+	null := pgtype.Numeric{}
+	null.DecodeText(nil, nil)
+
+	row := map[string]interface{}{
+		"timestamptz": ValueToJson(&timestamptz),
+		"timestamp":   ValueToJson(&timestamp),
+		"date":        ValueToJson(&date),
+		"float":       ValueToJson(&float),
+		"jsonb":       ValueToJson(&jsonb),
+		"bytea":       ValueToJson(&bytea),
+		"inet":        ValueToJson(&inet),
+		"numrange":    ValueToJson(&numrange),
+		"array":       ValueToJson(&array),
+		"null":        ValueToJson(&null),
+		"hstore":      ValueToJson(map[string]pgtype.Text{"a": {String: "1", Status: pgtype.Present}, "b": {Status: pgtype.Null}}),
+		"enum":        ValueToJson("happy"),
+	}
+	rowJson, err := json.Marshal(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"array":[[1,2],[3,null]],"bytea":"\\xdeadbeef","date":"infinity","enum":"happy","float":"NaN",` +
+		`"hstore":{"a":"1","b":null},"inet":"192.168.0.1/24","jsonb":{"big":12345678901234567890},"null":null,` +
+		`"numrange":{"lower":"1.5","lowerInclusive":true,"upper":"2.25","upperInclusive":false},"timestamp":"2022-03-04T05:06:07","timestamptz":"2022-03-03T23:36:07.123456Z"}`
+	if string(rowJson) != expected {
+		t.Error("json:", string(rowJson))
+	}
+}
+
+func TestSetEditableColumns(t *testing.T) {
+	columns := []*qemodels.ColumnMetadata{
+		{Name: "name", Schema: "public", Table: "users", Column: "name"},
+		{Name: "id", Schema: "public", Table: "users", Column: "id"},
+		{Name: "team", Schema: "public", Table: "teams", Column: "name"},
+		{Name: "count"},
+	}
+	SetEditableColumns(columns, map[string][]string{
+		`"public"."users"`: {"id"},
+		`"public"."teams"`: {"id"},
+	})
+	for i, column := range columns[:2] {
+		if !column.Editable || len(column.RowIDColumns) != 1 || column.RowIDColumns[0] != 1 {
+			t.Error(i, "column:", column)
+		}
+	}
+	if columns[2].Editable || columns[3].Editable {
+		t.Error("columns:", columns[2], columns[3])
+	}
+
+	selfJoin := []*qemodels.ColumnMetadata{
+		{Name: "id", Schema: "public", Table: "users", Column: "id"},
+		{Name: "id", Schema: "public", Table: "users", Column: "id"},
+	}
+	SetEditableColumns(selfJoin, map[string][]string{`"public"."users"`: {"id"}})
+	if selfJoin[0].Editable {
+		t.Error("ambiguous row id is editable")
+	}
+}
+
+func TestQueryToForeignKeys(t *testing.T) {
+	foreignKeys := QueryToForeignKeys([]map[string]interface{}{
+		{"0": "users_team_fkey", "1": []interface{}{"team_id", "org_id"}, "2": "public", "3": "teams", "4": []interface{}{"id", "org_id"}, "5": "c", "6": "a"},
+	})
+	if len(foreignKeys) != 1 {
+		t.Fatal("foreignKeys:", foreignKeys)
+	}
+	foreignKey := foreignKeys[0]
+	if foreignKey.Name != "users_team_fkey" || strings.Join(foreignKey.Columns, ",") != "team_id,org_id" ||
+		foreignKey.ReferencedSchemaName != "public" || foreignKey.ReferencedName != "teams" || strings.Join(foreignKey.ReferencedColumns, ",") != "id,org_id" {
+		t.Error("foreignKey:", foreignKey)
+	}
+	if foreignKey.OnDelete != "CASCADE" || foreignKey.OnUpdate != "NO ACTION" {
+		t.Error("actions:", foreignKey.OnDelete, foreignKey.OnUpdate)
+	}
+}
+
+func TestCreateTableScript(t *testing.T) {
+	script := CreateTableScript("public", "users",
+		map[string]interface{}{"0": "app", "1": "All the users", "2": "p", "3": nil, "4": "r"},
+		[]map[string]interface{}{
+			{"0": int32(1), "1": "id", "3": "NO", "4": "nextval('users_id_seq'::regclass)", "6": "integer", "7": "", "8": "", "9": nil, "10": true, "11": nil},
+			{"0": int32(2), "1": "name", "3": "NO", "4": "''::character varying", "6": "character varying(255)", "7": "", "8": "", "9": "Display name", "10": false, "11": `"C"`},
+			{"0": int32(3), "1": "code", "3": "NO", "4": nil, "6": "bigint", "7": "d", "8": "", "9": nil, "10": false, "11": nil},
+			{"0": int32(4), "1": "name_length", "3": "YES", "4": "length((name)::text)", "6": "integer", "7": "", "8": "s", "9": nil, "10": false, "11": nil},
+		},
+		[]map[string]interface{}{
+			{"1": "users_pkey", "2": "p", "3": "PRIMARY KEY (id)"},
+			{"1": "users_name_check", "2": "c", "3": "CHECK (name::text <> ''::text)"},
+			{"1": "users_id_not_null", "2": "n", "3": "NOT NULL id"},
+		},
+		[]map[string]interface{}{
+			{"0": "users_pkey", "1": "CREATE UNIQUE INDEX users_pkey ON public.users USING btree (id)"},
+			{"0": "users_name_idx", "1": "CREATE INDEX users_name_idx ON public.users USING btree (name)"},
+		},
+	)
+	expected := `CREATE TABLE "public"."users" (
+    "id" serial NOT NULL,
+    "name" character varying(255) COLLATE "C" DEFAULT ''::character varying NOT NULL,
+    "code" bigint GENERATED BY DEFAULT AS IDENTITY NOT NULL,
+    "name_length" integer GENERATED ALWAYS AS (length((name)::text)) STORED,
+    CONSTRAINT "users_pkey" PRIMARY KEY (id),
+    CONSTRAINT "users_name_check" CHECK (name::text <> ''::text)
+);
+
+CREATE INDEX users_name_idx ON public.users USING btree (name);
+
+COMMENT ON TABLE "public"."users" IS 'All the users';
+COMMENT ON COLUMN "public"."users"."name" IS 'Display name';
+
+ALTER TABLE "public"."users" OWNER TO "app";
+`
+	if script != expected {
+		t.Error("script:", script)
+	}
+}
+
+func TestMigrationScript(t *testing.T) {
+	defaultName := "'anonymous'::text"
+	source := []*qemodels.DBDataModel{
+		{SchemaName: "public", Name: "users", Kind: qemodels.DATA_MODEL_KIND_TABLE,
+			Fields: []qemodels.DBDataModelField{
+				{Name: "id", Type: "bigint", IsPrimary: true},
+				{Name: "name", Type: "character varying(255)", Default: &defaultName},
+				{Name: "team_id", Type: "bigint", IsNullable: true},
+			},
+			Indexes:     []qemodels.DBDataModelIndex{{Name: "users_name_idx", IndexDef: "CREATE INDEX users_name_idx ON public.users USING btree (name)"}},
+			Constraints: []qemodels.DBDataModelConstraint{{Name: "users_pkey", Type: "PRIMARY KEY", Definition: "PRIMARY KEY (id)"}},
+			ForeignKeys: []qemodels.DBDataModelForeignKey{{Name: "users_team_id_fkey", Columns: []string{"team_id"}, ReferencedSchemaName: "public",
+				ReferencedName: "teams", ReferencedColumns: []string{"id"}, OnDelete: "CASCADE", OnUpdate: "NO ACTION"}},
+		},
+		{SchemaName: "public", Name: "teams", Kind: qemodels.DATA_MODEL_KIND_TABLE,
+			Fields:      []qemodels.DBDataModelField{{Name: "id", Type: "bigint", IsPrimary: true}},
+			Constraints: []qemodels.DBDataModelConstraint{{Name: "teams_pkey", Type: "PRIMARY KEY", Definition: "PRIMARY KEY (id)"}},
+		},
+	}
+	target := []*qemodels.DBDataModel{
+		{SchemaName: "public", Name: "users", Kind: qemodels.DATA_MODEL_KIND_TABLE,
+			Fields: []qemodels.DBDataModelField{
+				{Name: "id", Type: "bigint", IsPrimary: true},
+				{Name: "name", Type: "text", IsNullable: true},
+				{Name: "age", Type: "integer", IsNullable: true},
+			},
+			Indexes:     []qemodels.DBDataModelIndex{{Name: "users_name_idx", IndexDef: "CREATE INDEX users_name_idx ON public.users USING hash (name)"}},
+			Constraints: []qemodels.DBDataModelConstraint{{Name: "users_pkey", Type: "PRIMARY KEY", Definition: "PRIMARY KEY (id)"}},
+		},
+		{SchemaName: "public", Name: "old_users", Kind: qemodels.DATA_MODEL_KIND_VIEW, Definition: "CREATE OR REPLACE VIEW public.old_users AS\n SELECT 1;"},
+	}
+	diff := qemodels.DiffDataModels(source, target)
+	if len(diff.AddedDataModels) != 1 || len(diff.RemovedDataModels) != 1 || len(diff.ChangedDataModels) != 1 {
+		t.Fatal("diff:", diff)
+	}
+	usersDiff := diff.ChangedDataModels[0]
+	if len(usersDiff.AddedFields) != 1 || len(usersDiff.RemovedFields) != 1 || len(usersDiff.ChangedFields) != 1 ||
+		len(usersDiff.ChangedIndexes) != 1 || len(usersDiff.AddedForeignKeys) != 1 || len(usersDiff.ChangedConstraints) != 0 {
+		t.Fatal("usersDiff:", usersDiff)
+	}
+
+	expected := `BEGIN;
+
+DROP VIEW "public"."old_users";
+DROP INDEX "public"."users_name_idx";
+
+CREATE TABLE "public"."teams" (
+    "id" bigint NOT NULL,
+    CONSTRAINT "teams_pkey" PRIMARY KEY (id)
+);
+
+ALTER TABLE "public"."users" ADD COLUMN "team_id" bigint;
+ALTER TABLE "public"."users" ALTER COLUMN "name" TYPE character varying(255) USING "name"::character varying(255);
+ALTER TABLE "public"."users" ALTER COLUMN "name" SET DEFAULT 'anonymous'::text;
+ALTER TABLE "public"."users" ALTER COLUMN "name" SET NOT NULL;
+ALTER TABLE "public"."users" DROP COLUMN "age";
+
+CREATE INDEX users_name_idx ON public.users USING btree (name);
+
+ALTER TABLE "public"."users" ADD CONSTRAINT "users_team_id_fkey" FOREIGN KEY ("team_id") REFERENCES "public"."teams" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+COMMIT;
+`
+	if script := MigrationScript(diff); script != expected {
+		t.Error("script:", script)
+	}
+	if script := MigrationScript(qemodels.DiffDataModels(source, source)); script != "" {
+		t.Error("script of same schemas:", script)
+	}
+}
+
+func TestMigrationScriptViews(t *testing.T) {
+	products := func(priceType string) *qemodels.DBDataModel {
+		return &qemodels.DBDataModel{SchemaName: "public", Name: "products", Kind: qemodels.DATA_MODEL_KIND_TABLE,
+			Fields: []qemodels.DBDataModelField{{Name: "name", Type: "text"}, {Name: "price", Type: priceType}}}
+	}
+	views := func() []*qemodels.DBDataModel {
+		return []*qemodels.DBDataModel{
+			{SchemaName: "public", Name: "price_stats", Kind: qemodels.DATA_MODEL_KIND_MATERIALIZED_VIEW,
+				Definition:   "CREATE MATERIALIZED VIEW public.price_stats AS\n SELECT max(price) AS max FROM public.product_prices;",
+				Indexes:      []qemodels.DBDataModelIndex{{Name: "price_stats_idx", IndexDef: "CREATE INDEX price_stats_idx ON public.price_stats USING btree (max)"}},
+				Dependencies: []qemodels.DBDataModelDependency{{SchemaName: "public", Name: "product_prices", Column: "price"}}},
+			{SchemaName: "public", Name: "product_prices", Kind: qemodels.DATA_MODEL_KIND_VIEW,
+				Definition:   "CREATE OR REPLACE VIEW public.product_prices AS\n SELECT name, price FROM public.products;",
+				Dependencies: []qemodels.DBDataModelDependency{{SchemaName: "public", Name: "products", Column: "name"}, {SchemaName: "public", Name: "products", Column: "price"}}},
+			{SchemaName: "public", Name: "product_names", Kind: qemodels.DATA_MODEL_KIND_VIEW,
+				Definition:   "CREATE OR REPLACE VIEW public.product_names AS\n SELECT name FROM public.products;",
+				Dependencies: []qemodels.DBDataModelDependency{{SchemaName: "public", Name: "products", Column: "name"}}},
+		}
+	}
+	source := append([]*qemodels.DBDataModel{products("numeric(12,2)")}, views()...)
+	target := append([]*qemodels.DBDataModel{products("numeric(10,2)")}, views()...)
+	diff := qemodels.DiffDataModels(source, target)
+	if len(diff.RecreatedViews) != 2 {
+		t.Fatal("recreated views:", diff.RecreatedViews)
+	}
+
+	expected := `BEGIN;
+
+DROP MATERIALIZED VIEW "public"."price_stats";
+DROP VIEW "public"."product_prices";
+
+ALTER TABLE "public"."products" ALTER COLUMN "price" TYPE numeric(12,2) USING "price"::numeric(12,2);
+
+CREATE VIEW public.product_prices AS
+ SELECT name, price FROM public.products;
+CREATE MATERIALIZED VIEW public.price_stats AS
+ SELECT max(price) AS max FROM public.product_prices;
+
+CREATE INDEX price_stats_idx ON public.price_stats USING btree (max);
+
+COMMIT;
+`
+	if script := MigrationScript(diff); script != expected {
+		t.Error("script:", script)
+	}
+
+	// a changed view is dropped and created, as its columns can change
+	source[3] = &qemodels.DBDataModel{SchemaName: "public", Name: "product_names", Kind: qemodels.DATA_MODEL_KIND_VIEW,
+		Definition: "CREATE OR REPLACE VIEW public.product_names AS\n SELECT upper(name) AS upper_name FROM public.products;"}
+	source[0] = products("numeric(10,2)")
+	expected = `BEGIN;
+
+DROP VIEW "public"."product_names";
+
+CREATE VIEW public.product_names AS
+ SELECT upper(name) AS upper_name FROM public.products;
+
+COMMIT;
+`
+	if script := MigrationScript(qemodels.DiffDataModels(source, target)); script != expected {
+		t.Error("script:", script)
+	}
+}
+
+func TestCreateIndexQuery(t *testing.T) {
+	query, err := CreateIndexQuery("public", "users", &qemodels.IndexSpec{
+		Name:         "users_email_idx",
+		Fields:       []qemodels.IndexField{{Name: "email"}, {Name: "created_at", Descending: true}},
+		Unique:       true,
+		Concurrently: true,
+		Where:        "deleted_at IS NULL",
+	})
+	expected := `CREATE UNIQUE INDEX CONCURRENTLY "users_email_idx" ON "public"."users" USING btree ("email", "created_at" DESC) WHERE deleted_at IS NULL;`
+	if err != nil || query != expected {
+		t.Error("query:", query, err)
+	}
+	query, err = CreateIndexQuery("public", "users", &qemodels.IndexSpec{
+		Fields: []qemodels.IndexField{{Name: "data", Type: "jsonb_path_ops"}},
+		Method: "GIN",
+	})
+	if err != nil || query != `CREATE INDEX ON "public"."users" USING gin ("data" "jsonb_path_ops");` {
+		t.Error("query:", query, err)
+	}
+	if _, err := CreateIndexQuery("public", "users", &qemodels.IndexSpec{Fields: []qemodels.IndexField{{Name: "id"}}, Method: "btree; DROP TABLE users"}); err == nil {
+		t.Error("invalid method is allowed")
+	}
+	query, err = CreateIndexQuery("public", "users", &qemodels.IndexSpec{
+		Fields: []qemodels.IndexField{{Name: "name", Type: "public.gin_trgm_ops"}},
+		Method: "gin",
+		Where:  "status = 'active' AND (data->>'plan')::text IN ('pro', 'team')",
+	})
+	if err != nil || query != `CREATE INDEX ON "public"."users" USING gin ("name" "public"."gin_trgm_ops") WHERE status = 'active' AND (data->>'plan')::text IN ('pro', 'team');` {
+		t.Error("query:", query, err)
+	}
+	for _, where := range []string{"true; DROP TABLE users", "true -- comment", "true /* comment */", "$$;$$ = ''", "name = 'a''; DROP TABLE users", "name = 'a\\'"} {
+		if _, err := CreateIndexQuery("public", "users", &qemodels.IndexSpec{Fields: []qemodels.IndexField{{Name: "id"}}, Where: where}); err == nil {
+			t.Error("invalid predicate is allowed:", where)
+		}
+	}
+	if query := DropIndexQuery("public", "users_email_idx", true); query != `DROP INDEX CONCURRENTLY "public"."users_email_idx";` {
+		t.Error("drop query:", query)
+	}
+}
+
+func TestAlterFieldQuery(t *testing.T) {
+	defaultValue := "0"
+	isNullable := false
+	query, err := AlterFieldQuery("public", "users", "age", &qemodels.FieldAlteration{
+		NewName:    "age_years",
+		Type:       "integer",
+		Using:      "age::integer",
+		Default:    &defaultValue,
+		IsNullable: &isNullable,
+	})
+	expected := `ALTER TABLE "public"."users" ALTER COLUMN "age" TYPE integer USING age::integer, ALTER COLUMN "age" SET DEFAULT 0, ALTER COLUMN "age" SET NOT NULL;
+ALTER TABLE "public"."users" RENAME COLUMN "age" TO "age_years";`
+	if err != nil || query != expected {
+		t.Error("query:", query, err)
+	}
+	query, err = AlterFieldQuery("public", "users", "age", &qemodels.FieldAlteration{DropDefault: true})
+	if err != nil || query != `ALTER TABLE "public"."users" ALTER COLUMN "age" DROP DEFAULT;` {
+		t.Error("query:", query, err)
+	}
+	query, err = AlterFieldQuery("public", "users", "status", &qemodels.FieldAlteration{Type: "public.user_status", Using: "status::text::public.user_status"})
+	if err != nil || query != `ALTER TABLE "public"."users" ALTER COLUMN "status" TYPE public.user_status USING status::text::public.user_status;` {
+		t.Error("query:", query, err)
+	}
+	defaultValue = "'a;b'"
+	if _, err := AlterFieldQuery("public", "users", "age", &qemodels.FieldAlteration{Default: &defaultValue}); err != nil {
+		t.Error("quoted semicolon is not allowed:", err)
+	}
+	for _, injection := range []string{
+		"integer; DROP TABLE users",
+		"integer /* ; */; DROP TABLE users",
+		"integer -- ; DROP TABLE users",
+		"integer USING $$x$$; DROP TABLE users; SELECT $$",
+		"integer USING $tag$; DROP TABLE users; $tag$",
+		"integer USING E'\\'; DROP TABLE users; --'",
+	} {
+		if _, err := AlterFieldQuery("public", "users", "age", &qemodels.FieldAlteration{Type: injection}); err == nil {
+			t.Error("invalid type is allowed:", injection)
+		}
+		if _, err := AlterFieldQuery("public", "users", "age", &qemodels.FieldAlteration{Type: "integer", Using: injection}); err == nil {
+			t.Error("invalid using is allowed:", injection)
+		}
+		if _, err := AlterFieldQuery("public", "users", "age", &qemodels.FieldAlteration{Default: &injection}); err == nil {
+			t.Error("invalid default is allowed:", injection)
+		}
+	}
+	if _, err := AlterFieldQuery("public", "users", "age", &qemodels.FieldAlteration{NewName: "age"}); err == nil {
+		t.Error("empty alteration is allowed")
+	}
+}
+
+func TestCreateTableQuery(t *testing.T) {
+	defaultValue := "now()"
+	spec := qemodels.DataModelSpec{
+		Name: "posts",
+		Fields: []qemodels.FieldSpec{
+			{Name: "id", Type: "bigserial", IsPrimary: true},
+			{Name: "slug", Type: "text", IsUnique: true},
+			{Name: "created_at", Type: "timestamptz", Default: &defaultValue},
+			{Name: "body", Type: "text", IsNullable: true},
+		},
+		Constraints: []qemodels.DBDataModelConstraint{
+			{Name: "posts_slug_check", Definition: "CHECK (slug <> '')"},
+		},
+	}
+	if err := spec.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	query := spec.CreateTableQuery(QuoteIdentifier("public", spec.Name), func(name string) string {
+		return QuoteIdentifier(name)
+	})
+	expected := `CREATE TABLE "public"."posts" (
+    "id" bigserial NOT NULL,
+    "slug" text NOT NULL UNIQUE,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "body" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "posts_slug_check" CHECK (slug <> '')
+);`
+	if query != expected {
+		t.Error("query:", query)
+	}
+	if !IsSingleStatement(query) || StatementsCount(query) != 1 {
+		t.Error("query is not a single statement")
+	}
+	for _, fieldType := range []string{
+		"text); DROP TABLE users; --",
+		"text /* ; */",
+		"text DEFAULT $$a$$); DROP TABLE users; SELECT ($$",
+	} {
+		spec.Fields[1].Type = fieldType
+		if err := spec.Validate(); err == nil {
+			t.Error("invalid type is allowed:", fieldType)
+		}
+	}
+}
+
+func TestStatementsCount(t *testing.T) {
+	tests := map[string]int{
+		`CREATE TABLE "users" ("status" public.user_status);`: 1,
+		`SELECT ';' AS a, $$;$$ AS b /* ; */; -- ;`:           1,
+		`SELECT 1; SELECT 2`:                                  2,
+		`SELECT $tag$ $$; $tag$; DROP TABLE users;`:           2,
+		`SELECT 1 /* unterminated`:                            -1,
+		`SELECT $$ unterminated`:                              -1,
+	}
+	for query, expected := range tests {
+		if count := StatementsCount(query); count != expected {
+			t.Errorf("statements of %s: %d, expected %d", query, count, expected)
+		}
+	}
+}
@@ -2,29 +2,72 @@ package pgqueryengine
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"slashbase.com/backend/internal/models"
 	"slashbase.com/backend/pkg/queryengines/pgqueryengine/pgxutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
 	"slashbase.com/backend/pkg/queryengines/queryconfig"
 	"slashbase.com/backend/pkg/sbsql"
 	"slashbase.com/backend/pkg/sshtunnel"
 )
 
+// ROW_ID_COLUMN is the column returned by GetData with the values of the primary key
+// (or a unique not null index) of the row as json, it is used as the row id for UpdateSingleData
+// and DeleteData. Tables without such key return ctid instead.
+const ROW_ID_COLUMN = "_rowid"
+
+// CURSOR_COLUMN is the column selected by GetDataByCursor with the values of the keyset,
+// it is removed from the returned rows.
+const CURSOR_COLUMN = "_cursor"
+
 type PostgresQueryEngine struct {
-	openConnections map[string]pgxConnPoolInstance
+	openConnections     map[string]pgxConnPoolInstance
+	openTransactions    map[string]*pgxTransactionInstance
+	transactionsMutex   sync.Mutex
+	runningQueries      map[string]*pgxRunningQuery
+	runningQueriesMutex sync.Mutex
+	metadataCaches      map[string]*pgxMetadataCache
+	metadataCacheMutex  sync.Mutex
 }
 
 func InitPostgresQueryEngine() *PostgresQueryEngine {
 	return &PostgresQueryEngine{
-		openConnections: map[string]pgxConnPoolInstance{},
+		openConnections:  map[string]pgxConnPoolInstance{},
+		openTransactions: map[string]*pgxTransactionInstance{},
+		runningQueries:   map[string]*pgxRunningQuery{},
+		metadataCaches:   map[string]*pgxMetadataCache{},
 	}
 }
 
 func (pgqe *PostgresQueryEngine) RunQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	var err error
+	if config.QueryID != "" {
+		data, err = pgqe.runCancellableQuery(dbConn, query, config)
+	} else {
+		data, err = pgqe.runQuery(dbConn, query, nil, config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if columnsMetadata, ok := data["columnsMetadata"].([]*qemodels.ColumnMetadata); ok {
+		pgqe.resolveColumnsMetadata(dbConn, columnsMetadata, config)
+	} else {
+		// the query may have altered the tables
+		pgqe.clearMetadataCache(dbConn)
+	}
+	return data, nil
+}
+
+func (pgqe *PostgresQueryEngine) getPool(dbConn *models.DBConnection) (*pgxpool.Pool, error) {
 	port, _ := strconv.Atoi(string(dbConn.DBPort))
 	if dbConn.UseSSH != models.DBUSESSH_NONE {
 		remoteHost := string(dbConn.DBHost)
@@ -39,33 +82,81 @@ func (pgqe *PostgresQueryEngine) RunQuery(dbConn *models.DBConnection, query str
 		dbConn.DBPort = sbsql.CryptedData(fmt.Sprintf("%d", sshTun.GetLocalEndpoint().Port))
 	}
 	port, _ = strconv.Atoi(string(dbConn.DBPort))
-	conn, err := pgqe.getConnection(dbConn.ID, string(dbConn.DBHost), uint16(port), string(dbConn.DBName), string(dbConn.DBUser), string(dbConn.DBPassword))
+	return pgqe.getConnection(dbConn.ID, string(dbConn.DBHost), uint16(port), string(dbConn.DBName), string(dbConn.DBUser), string(dbConn.DBPassword))
+}
+
+func (pgqe *PostgresQueryEngine) runQuery(dbConn *models.DBConnection, query string, args []interface{}, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	pool, err := pgqe.getPool(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if config.MaxExecutionTime == 0 {
+		data, err = runQueryOn(pool, query, args, config)
+	} else {
+		// the statement timeout is set on the session, so the query needs a pinned connection
+		conn, acquireErr := pool.Acquire(config.GetContext())
+		if acquireErr != nil {
+			return nil, acquireErr
+		}
+		defer conn.Release()
+		data, err = runQueryOn(conn, query, args, config)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := data["columnsMetadata"]; !ok {
+		// the query may have altered the tables
+		pgqe.clearMetadataCache(dbConn)
+	}
+	return data, nil
+}
 
+// runQueryOn runs the query on the pool, a pinned connection or a transaction.
+// The pool can only be used when the config has no max execution time.
+func runQueryOn(conn pgxQuerier, query string, args []interface{}, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
 	queryType, isReturningRows := pgxutils.GetPSQLQueryType(query)
 
 	if queryType != pgxutils.QUERY_READ && config.ReadOnly {
 		return nil, errors.New("not allowed run this query")
 	}
 
+	resetStatementTimeout, err := setStatementTimeout(conn, config)
+	if err != nil {
+		return nil, err
+	}
+	defer resetStatementTimeout()
+
 	if isReturningRows {
-		rows, err := conn.Query(context.Background(), query)
+		// cancelling the context aborts the query when the rows are truncated, instead of reading the
+		// remaining rows. It closes the connection, so the rows of a transaction are still read.
+		ctx, cancel := context.WithCancel(config.GetContext())
+		defer cancel()
+		rows, err := conn.Query(ctx, query, args...)
 		if err != nil {
 			return nil, err
 		}
 		defer rows.Close()
-		columns, rowsData := pgxutils.PgSqlRowsToJson(rows)
+		columnsMetadata := pgxutils.PgSqlColumnsMetadata(rows)
+		columns, rowsData, truncated := pgxutils.PgSqlRowsToJson(rows, config.MaxRows)
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if _, isTx := conn.(pgx.Tx); truncated && !isTx {
+			cancel()
+		}
+		rows.Close()
 		if config.CreateLogFn != nil {
 			config.CreateLogFn(query)
 		}
 		return map[string]interface{}{
-			"columns": columns,
-			"rows":    rowsData,
+			"columns":         columns,
+			"columnsMetadata": columnsMetadata,
+			"rows":            rowsData,
+			"truncated":       truncated,
 		}, nil
 	}
-	cmdTag, err := conn.Exec(context.Background(), query)
+	cmdTag, err := conn.Exec(config.GetContext(), query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -77,9 +168,25 @@ func (pgqe *PostgresQueryEngine) RunQuery(dbConn *models.DBConnection, query str
 	}, nil
 }
 
+// setStatementTimeout sets the statement_timeout of the session to the max execution time of the config,
+// the returned function resets it before the connection goes back to the pool.
+func setStatementTimeout(conn pgxQuerier, config *queryconfig.QueryConfig) (func(), error) {
+	if config.MaxExecutionTime == 0 {
+		return func() {}, nil
+	}
+	timeout := strconv.FormatInt(config.MaxExecutionTime.Milliseconds(), 10)
+	if _, err := conn.Exec(config.GetContext(), "SELECT set_config('statement_timeout', $1, false)", timeout); err != nil {
+		return nil, err
+	}
+	return func() {
+		// fails if the transaction was aborted, the rollback undoes the setting then
+		conn.Exec(context.Background(), "RESET statement_timeout")
+	}, nil
+}
+
 func (pgqe *PostgresQueryEngine) TestConnection(dbConn *models.DBConnection, config *queryconfig.QueryConfig) bool {
 	query := "SELECT 1 AS test;"
-	data, err := pgqe.RunQuery(dbConn, query, config)
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
 	if err != nil {
 		return false
 	}
@@ -87,43 +194,136 @@ func (pgqe *PostgresQueryEngine) TestConnection(dbConn *models.DBConnection, con
 	return test == 1
 }
 
-func (pgqe *PostgresQueryEngine) GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
-	data, err := pgqe.RunQuery(dbConn, "SELECT tablename, schemaname FROM pg_catalog.pg_tables WHERE schemaname != 'pg_catalog' AND schemaname != 'information_schema' ORDER BY tablename;", config)
+func (pgqe *PostgresQueryEngine) GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBDataModel, error) {
+	query := `SELECT c.relname, n.nspname, c.relkind
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND ` + userSchemaCondition + `
+		ORDER BY c.relname;`
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
 	if err != nil {
 		return nil, err
 	}
-	rdata := data["rows"].([]map[string]interface{})
-	return rdata, nil
+	dataModels := []*qemodels.DBDataModel{}
+	for _, table := range data["rows"].([]map[string]interface{}) {
+		dataModels = append(dataModels, buildDBDataModel(table))
+	}
+	return dataModels, nil
+}
+
+func (pgqe *PostgresQueryEngine) GetSingleDataModel(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (*qemodels.DBDataModel, error) {
+	fieldsData, err := pgqe.GetSingleDataModelFields(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	indexesData, err := pgqe.GetSingleDataModelIndexes(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeysData, err := pgqe.GetSingleDataModelForeignKeys(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	allFields := []qemodels.DBDataModelField{}
+	for _, field := range fieldsData {
+		allFields = append(allFields, *buildDBDataModelField(field))
+	}
+	allIndexes := []qemodels.DBDataModelIndex{}
+	for _, index := range indexesData {
+		allIndexes = append(allIndexes, *buildDBDataModelIndex(index))
+	}
+	query := `SELECT c.relkind, CASE WHEN c.relkind IN ('v', 'm') THEN pg_get_viewdef(c.oid, true) END
+		FROM pg_catalog.pg_class c WHERE c.oid = $1::regclass;`
+	data, err := pgqe.runQuery(dbConn, query, []interface{}{pgxutils.QuoteIdentifier(schema, name)}, config)
+	if err != nil {
+		return nil, err
+	}
+	relation := data["rows"].([]map[string]interface{})[0]
+	dataModel := qemodels.DBDataModel{
+		SchemaName:  schema,
+		Name:        name,
+		Kind:        dataModelKind(relation["0"].(string)),
+		Fields:      allFields,
+		Indexes:     allIndexes,
+		ForeignKeys: pgxutils.QueryToForeignKeys(foreignKeysData),
+	}
+	if viewDef, ok := relation["1"].(string); ok {
+		dataModel.Definition = viewDefinition(dataModel.Kind, pgxutils.QuoteIdentifier(schema, name), viewDef)
+	}
+	return &dataModel, nil
 }
 
 func (pgqe *PostgresQueryEngine) GetSingleDataModelFields(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
-	// get fields
-	query := fmt.Sprintf(`
-		SELECT ordinal_position, column_name, data_type, is_nullable, column_default, character_maximum_length
-		FROM information_schema.columns
-		WHERE table_schema = '%s' AND table_name = '%s'
-		ORDER BY ordinal_position;`,
-		schema, name)
-	data, err := pgqe.RunQuery(dbConn, query, config)
+	fieldsData, constraintsData, err := pgqe.getSingleDataModelFieldsRows(dbConn, schema, name, config)
 	if err != nil {
 		return nil, err
 	}
+	return pgxutils.QueryToDataModel(fieldsData, constraintsData), err
+}
+
+// getSingleDataModelFieldsRows returns the rows of the fields and constraints queries of the data model,
+// the columns after the ones read by QueryToDataModel are used to generate the DDL of the table.
+func (pgqe *PostgresQueryEngine) getSingleDataModelFieldsRows(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, []map[string]interface{}, error) {
+	// get fields
+	// read from the catalog as information_schema.columns does not have the columns of materialized views
+	query := `
+		SELECT a.attnum::int4, a.attname, format_type(a.atttypid, NULL), CASE WHEN a.attnotnull THEN 'NO' ELSE 'YES' END,
+			pg_get_expr(d.adbin, d.adrelid),
+			CASE WHEN a.atttypid IN ('bpchar'::regtype, 'varchar'::regtype) AND a.atttypmod > 0 THEN a.atttypmod - 4 END,
+			format_type(a.atttypid, a.atttypmod), a.attidentity::text, a.attgenerated::text,
+			col_description(a.attrelid, a.attnum),
+			a.attidentity = '' AND pg_get_serial_sequence(a.attrelid::regclass::text, a.attname) IS NOT NULL,
+			(SELECT quote_ident(co.collname) FROM pg_catalog.pg_collation co JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+				WHERE co.oid = a.attcollation AND a.attcollation <> t.typcollation)
+		FROM pg_catalog.pg_attribute a
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum;`
+	data, err := pgqe.runQuery(dbConn, query, []interface{}{pgxutils.QuoteIdentifier(schema, name)}, config)
+	if err != nil {
+		return nil, nil, err
+	}
 	fieldsData := data["rows"].([]map[string]interface{})
 	// get constraints
-	query = fmt.Sprintf(`SELECT conkey, conname, contype
-		FROM pg_constraint WHERE conrelid = '"%s"."%s"'::regclass;`, schema, name)
-	data, err = pgqe.RunQuery(dbConn, query, config)
+	query = `SELECT conkey, conname, contype::text, pg_get_constraintdef(oid, true)
+		FROM pg_constraint WHERE conrelid = $1::regclass
+		ORDER BY array_position(ARRAY['p', 'u', 'c', 'x', 'f'], contype::text), conname;`
+	data, err = pgqe.runQuery(dbConn, query, []interface{}{pgxutils.QuoteIdentifier(schema, name)}, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fieldsData, data["rows"].([]map[string]interface{}), nil
+}
+func (pgqe *PostgresQueryEngine) GetSingleDataModelIndexes(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	query := `SELECT indexname, indexdef FROM pg_indexes
+	WHERE schemaname = $1 AND tablename = $2;`
+	data, err := pgqe.runQuery(dbConn, query, []interface{}{schema, name}, config)
 	if err != nil {
 		return nil, err
 	}
-	constraintsData := data["rows"].([]map[string]interface{})
-	return pgxutils.QueryToDataModel(fieldsData, constraintsData), err
+	returnedData := data["rows"].([]map[string]interface{})
+	return returnedData, err
 }
 
-func (pgqe *PostgresQueryEngine) GetSingleDataModelIndexes(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
-	query := fmt.Sprintf(`SELECT indexname, indexdef FROM pg_indexes
-	WHERE schemaname = '%s' AND tablename = '%s';`, schema, name)
-	data, err := pgqe.RunQuery(dbConn, query, config)
+// foreignKeysQuery selects the foreign keys in the columns expected by pgxutils.QueryToForeignKeys,
+// followed by the schema and name of their table, from pg_constraint con of the table c in the namespace n.
+const foreignKeysQuery = `SELECT con.conname,
+		ARRAY(SELECT a.attname::text FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, i)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum ORDER BY k.i),
+		rn.nspname, rc.relname,
+		ARRAY(SELECT a.attname::text FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, i)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum ORDER BY k.i),
+		con.confdeltype, con.confupdtype, n.nspname, c.relname
+	FROM pg_catalog.pg_constraint con
+	JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	JOIN pg_catalog.pg_class rc ON rc.oid = con.confrelid
+	JOIN pg_catalog.pg_namespace rn ON rn.oid = rc.relnamespace
+	WHERE con.contype = 'f'`
+
+func (pgqe *PostgresQueryEngine) GetSingleDataModelForeignKeys(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	query := foreignKeysQuery + " AND con.conrelid = $1::regclass ORDER BY con.conname;"
+	data, err := pgqe.runQuery(dbConn, query, []interface{}{pgxutils.QuoteIdentifier(schema, name)}, config)
 	if err != nil {
 		return nil, err
 	}
@@ -131,103 +331,399 @@ func (pgqe *PostgresQueryEngine) GetSingleDataModelIndexes(dbConn *models.DBConn
 	return returnedData, err
 }
 
-func (pgqe *PostgresQueryEngine) AddSingleDataModelColumn(dbConn *models.DBConnection, schema, name, columnName, dataType string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	query := fmt.Sprintf(`ALTER TABLE %s.%s ADD COLUMN %s %s;`, schema, name, columnName, dataType)
-	data, err := pgqe.RunQuery(dbConn, query, config)
+// GetDBGraph returns the data models of the database with their fields and foreign keys,
+// reading all of them with one query each instead of one per data model.
+func (pgqe *PostgresQueryEngine) GetDBGraph(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (*qemodels.DBGraph, error) {
+	dataModels, err := pgqe.GetDataModels(dbConn, config)
+	if err != nil {
+		return nil, err
+	}
+	dataModelsByName := map[string]*qemodels.DBDataModel{}
+	for _, dataModel := range dataModels {
+		dataModel.Fields = []qemodels.DBDataModelField{}
+		dataModel.Indexes = []qemodels.DBDataModelIndex{}
+		dataModel.ForeignKeys = []qemodels.DBDataModelForeignKey{}
+		dataModelsByName[pgxutils.QuoteIdentifier(dataModel.SchemaName, dataModel.Name)] = dataModel
+	}
+
+	query := `SELECT n.nspname, c.relname, a.attname, format_type(a.atttypid, NULL), a.attnotnull,
+			EXISTS (SELECT 1 FROM pg_catalog.pg_constraint pk WHERE pk.conrelid = c.oid AND pk.contype = 'p' AND a.attnum = ANY(pk.conkey))
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND a.attnum > 0 AND NOT a.attisdropped AND ` + userSchemaCondition + `
+		ORDER BY n.nspname, c.relname, a.attnum;`
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		dataModel, exists := dataModelsByName[pgxutils.QuoteIdentifier(row["0"].(string), row["1"].(string))]
+		if !exists {
+			continue
+		}
+		dataModel.Fields = append(dataModel.Fields, qemodels.DBDataModelField{
+			Name:       row["2"].(string),
+			Type:       row["3"].(string),
+			IsNullable: !row["4"].(bool),
+			IsPrimary:  row["5"].(bool),
+			Tags:       []string{},
+		})
+	}
+
+	query = foreignKeysQuery + " AND " + userSchemaCondition + " ORDER BY con.conname;"
+	data, err = pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeysData := data["rows"].([]map[string]interface{})
+	for i, foreignKey := range pgxutils.QueryToForeignKeys(foreignKeysData) {
+		dataModel, exists := dataModelsByName[pgxutils.QuoteIdentifier(foreignKeysData[i]["7"].(string), foreignKeysData[i]["8"].(string))]
+		if exists {
+			dataModel.ForeignKeys = append(dataModel.ForeignKeys, foreignKey)
+		}
+	}
+	return qemodels.NewDBGraph(dataModels), nil
+}
+
+// isTable returns whether the data model is a table or a partitioned table. Only the rows of tables have a row id
+// and can be edited, views have no ctid and the rows of views, materialized views and foreign tables cannot be edited.
+func (pgqe *PostgresQueryEngine) isTable(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (bool, error) {
+	query := `SELECT c.relkind::text FROM pg_catalog.pg_class c WHERE c.oid = $1::regclass;`
+	data, err := pgqe.runQuery(dbConn, query, []interface{}{pgxutils.QuoteIdentifier(schema, name)}, config)
+	if err != nil {
+		return false, err
+	}
+	rows := data["rows"].([]map[string]interface{})
+	if len(rows) == 0 {
+		return false, errors.New("data model not found")
+	}
+	relkind := rows[0]["0"].(string)
+	return relkind == "r" || relkind == "p", nil
+}
+
+// getRowIDColumns returns the columns of the primary key of the table,
+// or of its first unique index on not null columns if it does not have a primary key.
+func (pgqe *PostgresQueryEngine) getRowIDColumns(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]string, error) {
+	query := `SELECT i.indexrelid, a.attname, a.attnotnull
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::regclass AND i.indisunique AND i.indisvalid AND i.indpred IS NULL AND i.indexprs IS NULL
+		ORDER BY i.indisprimary DESC, i.indexrelid, a.attnum;`
+	data, err := pgqe.runQuery(dbConn, query, []interface{}{pgxutils.QuoteIdentifier(schema, name)}, config)
+	if err != nil {
+		return nil, err
+	}
+	indexOrder := []string{}
+	indexColumns := map[string][]string{}
+	nullableIndexes := map[string]bool{}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		indexID := fmt.Sprint(row["0"])
+		if _, exists := indexColumns[indexID]; !exists {
+			indexOrder = append(indexOrder, indexID)
+		}
+		indexColumns[indexID] = append(indexColumns[indexID], row["1"].(string))
+		if !row["2"].(bool) {
+			nullableIndexes[indexID] = true
+		}
+	}
+	for _, indexID := range indexOrder {
+		if !nullableIndexes[indexID] {
+			return indexColumns[indexID], nil
+		}
+	}
+	return []string{}, nil
+}
+
+// getEditableRowIDColumns returns the row id columns of the table, or an error when the data model is not a table.
+func (pgqe *PostgresQueryEngine) getEditableRowIDColumns(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]string, error) {
+	editable, err := pgqe.isTable(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	if !editable {
+		return nil, errors.New("the rows of the data model cannot be edited")
+	}
+	return pgqe.getRowIDColumns(dbConn, schema, name, config)
+}
+
+func (pgqe *PostgresQueryEngine) AddSingleDataModelField(dbConn *models.DBConnection, schema, name, columnName, dataType string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s;`, pgxutils.QuoteIdentifier(schema, name), pgxutils.QuoteIdentifier(columnName), dataType)
+	if !pgxutils.IsSingleStatement(query) {
+		return nil, errors.New("invalid data type")
+	}
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
 	if err != nil {
 		return nil, err
 	}
 	return data, err
 }
 
-func (pgqe *PostgresQueryEngine) DeleteSingleDataModelColumn(dbConn *models.DBConnection, schema, name, columnName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	query := fmt.Sprintf(`ALTER TABLE %s.%s DROP COLUMN %s;`, schema, name, columnName)
-	data, err := pgqe.RunQuery(dbConn, query, config)
+func (pgqe *PostgresQueryEngine) DeleteSingleDataModelField(dbConn *models.DBConnection, schema, name, columnName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf(`ALTER TABLE %s DROP COLUMN %s;`, pgxutils.QuoteIdentifier(schema, name), pgxutils.QuoteIdentifier(columnName))
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
 	if err != nil {
 		return nil, err
 	}
 	return data, err
 }
 
-func (pgqe *PostgresQueryEngine) GetData(dbConn *models.DBConnection, schema string, name string, limit int, offset int64, fetchCount bool, filter []string, sort []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	sortQuery := ""
-	if len(sort) == 2 {
-		sortQuery = fmt.Sprintf(` ORDER BY %s %s`, sort[0], sort[1])
+// AlterSingleDataModelField alters the column of the table, or only returns the query doing it when preview is true.
+// The statements run in a single implicit transaction.
+func (pgqe *PostgresQueryEngine) AlterSingleDataModelField(dbConn *models.DBConnection, schema, name, fieldName string, alteration *qemodels.FieldAlteration, preview bool, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query, err := pgxutils.AlterFieldQuery(schema, name, fieldName, alteration)
+	if err != nil {
+		return nil, err
+	}
+	if preview {
+		return map[string]interface{}{
+			"query": query,
+		}, nil
+	}
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	data["query"] = query
+	return data, nil
+}
+
+// CreateIndex creates the index on the table. An index created concurrently does not block the writes
+// to the table, it cannot be created in a transaction.
+func (pgqe *PostgresQueryEngine) CreateIndex(dbConn *models.DBConnection, schema, name string, index *qemodels.IndexSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query, err := pgxutils.CreateIndexQuery(schema, name, index)
+	if err != nil {
+		return nil, err
 	}
-	query := fmt.Sprintf(`SELECT ctid, * FROM "%s"."%s"%s LIMIT %d OFFSET %d;`, schema, name, sortQuery, limit, offset)
-	countQuery := fmt.Sprintf(`SELECT count(*) FROM "%s"."%s";`, schema, name)
-	if len(filter) > 1 {
-		filter2 := ""
-		if len(filter) == 3 {
-			filter2 = " '" + filter[2] + "'"
+	return pgqe.runQuery(dbConn, query, nil, config)
+}
+
+func (pgqe *PostgresQueryEngine) DropIndex(dbConn *models.DBConnection, schema, name, indexName string, concurrently bool, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	return pgqe.runQuery(dbConn, pgxutils.DropIndexQuery(schema, indexName, concurrently), nil, config)
+}
+
+func (pgqe *PostgresQueryEngine) CreateDataModel(dbConn *models.DBConnection, schema string, spec *qemodels.DataModelSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := spec.CreateTableQuery(pgxutils.QuoteIdentifier(schema, spec.Name), func(name string) string {
+		return pgxutils.QuoteIdentifier(name)
+	})
+	if pgxutils.StatementsCount(query) != 1 {
+		return nil, errors.New("invalid data model")
+	}
+	return pgqe.runQuery(dbConn, query, nil, config)
+}
+
+func (pgqe *PostgresQueryEngine) RenameDataModel(dbConn *models.DBConnection, schema, name, newName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", pgxutils.QuoteIdentifier(schema, name), pgxutils.QuoteIdentifier(newName))
+	return pgqe.runQuery(dbConn, query, nil, config)
+}
+
+func (pgqe *PostgresQueryEngine) TruncateDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("TRUNCATE TABLE %s;", pgxutils.QuoteIdentifier(schema, name))
+	return pgqe.runQuery(dbConn, query, nil, config)
+}
+
+func (pgqe *PostgresQueryEngine) DropDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("DROP TABLE %s;", pgxutils.QuoteIdentifier(schema, name))
+	return pgqe.runQuery(dbConn, query, nil, config)
+}
+
+// GetData gets the page of rows of the data model, the first column of the rows of a table is its row id.
+// The rows of other data models have no row id column and editable is false.
+func (pgqe *PostgresQueryEngine) GetData(dbConn *models.DBConnection, schema string, name string, limit int, offset int64, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	editable, err := pgqe.isTable(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	rowIDColumns := []string{}
+	rowIDQuery := ""
+	if editable {
+		rowIDColumns, err = pgqe.getRowIDColumns(dbConn, schema, name, config)
+		if err != nil {
+			return nil, err
 		}
-		query = fmt.Sprintf(`SELECT ctid, * FROM "%s"."%s" WHERE "%s" %s%s%s LIMIT %d OFFSET %d;`,
-			schema,
-			name,
-			filter[0],
-			filter[1],
-			filter2,
-			sortQuery,
-			limit,
-			offset)
-		countQuery = fmt.Sprintf(`SELECT count(*) FROM "%s"."%s" WHERE "%s" %s%s;`,
-			schema,
-			name,
-			filter[0],
-			filter[1],
-			filter2)
+		rowIDQuery = "ctid, "
+		if len(rowIDColumns) > 0 {
+			rowIDQuery = fmt.Sprintf(`%s AS %s, `, pgxutils.RowIDExpression(rowIDColumns), pgxutils.QuoteIdentifier(ROW_ID_COLUMN))
+		}
+	}
+	whereQuery := ""
+	args := []interface{}{}
+	if filter != nil {
+		condition, filterArgs := pgxutils.FilterQuery(filter, 1)
+		whereQuery = " WHERE " + condition
+		args = append(args, filterArgs...)
 	}
-	data, err := pgqe.RunQuery(dbConn, query, config)
+	sortQuery := pgxutils.SortQuery(sort)
+	query := fmt.Sprintf(`SELECT %s* FROM %s%s%s LIMIT $%d OFFSET $%d;`,
+		rowIDQuery, pgxutils.QuoteIdentifier(schema, name), whereQuery, sortQuery, len(args)+1, len(args)+2)
+	data, err := pgqe.runQuery(dbConn, query, append(args, limit, offset), config)
 	if err != nil {
 		return nil, err
 	}
 	if fetchCount {
-		countData, err := pgqe.RunQuery(dbConn, countQuery, config)
+		countQuery := fmt.Sprintf(`SELECT count(*) FROM %s%s;`, pgxutils.QuoteIdentifier(schema, name), whereQuery)
+		countData, err := pgqe.runQuery(dbConn, countQuery, args, config)
 		if err != nil {
 			return nil, err
 		}
 		data["count"] = countData["rows"].([]map[string]interface{})[0]["0"]
 	}
+	data["rowIdIsCtid"] = editable && len(rowIDColumns) == 0
+	data["editable"] = editable
 	return data, err
 }
 
-func (pgqe *PostgresQueryEngine) UpdateSingleData(dbConn *models.DBConnection, schema string, name string, ctid string, columnName string, value string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	query := fmt.Sprintf(`UPDATE "%s"."%s" SET "%s" = '%s' WHERE ctid = '%s' RETURNING ctid;`, schema, name, columnName, value, ctid)
-	data, err := pgqe.RunQuery(dbConn, query, config)
+// GetDataByCursor gets the page of rows after the cursor, ordered by the sort fields and the row identity.
+// The cursor of the next page is returned as nextCursor, it is empty on the last page.
+// Data models other than tables have no row identity, so their rows are only paged by the sort fields.
+func (pgqe *PostgresQueryEngine) GetDataByCursor(dbConn *models.DBConnection, schema string, name string, limit int, cursor string, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	editable, err := pgqe.isTable(dbConn, schema, name, config)
 	if err != nil {
 		return nil, err
 	}
-	ctID := data["rows"].([]map[string]interface{})[0]["0"]
-	data = map[string]interface{}{
-		"ctid": ctID,
+	if !editable && len(sort) == 0 {
+		return nil, errors.New("sort is required to page the rows of a view by cursor")
+	}
+	rowIDColumns := []string{}
+	rowIDQuery := ""
+	notNullColumns := []string{}
+	if editable {
+		rowIDColumns, err = pgqe.getRowIDColumns(dbConn, schema, name, config)
+		if err != nil {
+			return nil, err
+		}
+		rowIDQuery = "ctid, "
+		notNullColumns = []string{"ctid"}
+		if len(rowIDColumns) > 0 {
+			rowIDQuery = fmt.Sprintf(`%s AS %s, `, pgxutils.RowIDExpression(rowIDColumns), pgxutils.QuoteIdentifier(ROW_ID_COLUMN))
+			notNullColumns = rowIDColumns
+		}
+	}
+	keys := qemodels.KeysetFields(sort, notNullColumns)
+	keyNames := []string{}
+	for _, key := range keys {
+		keyNames = append(keyNames, key.Field)
+	}
+	conditions := []string{}
+	args := []interface{}{}
+	if filter != nil {
+		condition, filterArgs := pgxutils.FilterQuery(filter, 1)
+		conditions = append(conditions, condition)
+		args = append(args, filterArgs...)
+	}
+	filterArgsCount := len(args)
+	if cursor != "" {
+		decodedCursor, err := qemodels.DecodeCursor(cursor, keyNames)
+		if err != nil {
+			return nil, err
+		}
+		values := []*string{}
+		if err := json.Unmarshal([]byte(decodedCursor.Values), &values); err != nil || len(values) != len(keys) {
+			return nil, errors.New("invalid cursor")
+		}
+		condition, keysetArgs := pgxutils.KeysetCondition(keys, values, notNullColumns, len(args)+1)
+		conditions = append(conditions, condition)
+		args = append(args, keysetArgs...)
+	}
+	whereQuery := ""
+	if len(conditions) > 0 {
+		whereQuery = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query := fmt.Sprintf(`SELECT %s*, %s AS %s FROM %s%s%s LIMIT $%d;`,
+		rowIDQuery, pgxutils.KeysetCursorExpression(keys), pgxutils.QuoteIdentifier(CURSOR_COLUMN),
+		pgxutils.QuoteIdentifier(schema, name), whereQuery, pgxutils.SortQuery(keys), len(args)+1)
+	data, err := pgqe.runQuery(dbConn, query, append(args, limit), config)
+	if err != nil {
+		return nil, err
+	}
+	// remove the cursor column from the result
+	columns := data["columns"].([]string)
+	cursorIndex := strconv.Itoa(len(columns) - 1)
+	data["columns"] = columns[:len(columns)-1]
+	rows := data["rows"].([]map[string]interface{})
+	nextCursor := ""
+	for i, row := range rows {
+		if i == len(rows)-1 && len(rows) == limit {
+			nextCursor = (&qemodels.Cursor{Keys: keyNames, Values: row[cursorIndex].(string)}).Encode()
+		}
+		delete(row, cursorIndex)
+	}
+	data["nextCursor"] = nextCursor
+	if fetchCount {
+		countWhereQuery := ""
+		if filter != nil {
+			countWhereQuery = " WHERE " + conditions[0]
+		}
+		countQuery := fmt.Sprintf(`SELECT count(*) FROM %s%s;`, pgxutils.QuoteIdentifier(schema, name), countWhereQuery)
+		countData, err := pgqe.runQuery(dbConn, countQuery, args[:filterArgsCount], config)
+		if err != nil {
+			return nil, err
+		}
+		data["count"] = countData["rows"].([]map[string]interface{})[0]["0"]
 	}
+	data["rowIdIsCtid"] = editable && len(rowIDColumns) == 0
+	data["editable"] = editable
 	return data, err
 }
 
-func (pgqe *PostgresQueryEngine) AddData(dbConn *models.DBConnection, schema string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	keys := []string{}
-	values := []string{}
-	for key, value := range data {
-		keys = append(keys, key)
-		val := value.(string)
-		values = append(values, val)
+func (pgqe *PostgresQueryEngine) UpdateSingleData(dbConn *models.DBConnection, schema string, name string, rowID string, columnName string, value string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	rowIDColumns, err := pgqe.getEditableRowIDColumns(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	whereQuery, whereArgs, err := pgxutils.RowIDCondition(rowIDColumns, rowID, 2)
+	if err != nil {
+		return nil, err
 	}
-	keysStr := strings.Join(keys, ", ")
-	valuesStr := strings.Join(values, "','")
-	query := fmt.Sprintf(`INSERT INTO "%s"."%s"(%s) VALUES('%s') RETURNING ctid;`, schema, name, keysStr, valuesStr)
-	rData, err := pgqe.RunQuery(dbConn, query, config)
+	query := fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE %s RETURNING %s;`,
+		pgxutils.QuoteIdentifier(schema, name), pgxutils.QuoteIdentifier(columnName), whereQuery, pgxutils.RowIDExpression(rowIDColumns))
+	data, err := pgqe.runQuery(dbConn, query, append([]interface{}{value}, whereArgs...), config)
 	if err != nil {
 		return nil, err
 	}
-	ctID := rData["rows"].([]map[string]interface{})[0]["0"]
-	rData = map[string]interface{}{
-		"ctid": ctID,
+	rows := data["rows"].([]map[string]interface{})
+	if len(rows) == 0 {
+		return nil, errors.New("row not found")
+	}
+	data = map[string]interface{}{
+		"rowId":       rows[0]["0"],
+		"rowIdIsCtid": len(rowIDColumns) == 0,
 	}
-	return rData, err
+	return data, err
 }
 
-func (pgqe *PostgresQueryEngine) DeleteData(dbConn *models.DBConnection, schema string, name string, ctids []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	ctidsStr := strings.Join(ctids, "', '")
-	query := fmt.Sprintf(`DELETE FROM "%s"."%s" WHERE ctid IN ('%s');`, schema, name, ctidsStr)
-	return pgqe.RunQuery(dbConn, query, config)
+func (pgqe *PostgresQueryEngine) AddData(dbConn *models.DBConnection, schema string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (*qemodels.AddDataResponse, error) {
+	rowIDColumns, err := pgqe.getEditableRowIDColumns(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	query, args := pgxutils.InsertQuery(schema, name, data, pgxutils.RowIDExpression(rowIDColumns))
+	rData, err := pgqe.runQuery(dbConn, query, args, config)
+	if err != nil {
+		return nil, err
+	}
+	newRowID := rData["rows"].([]map[string]interface{})[0]["0"].(string)
+	return &qemodels.AddDataResponse{NewID: newRowID}, err
+}
+
+func (pgqe *PostgresQueryEngine) DeleteData(dbConn *models.DBConnection, schema string, name string, rowIDs []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	if len(rowIDs) == 0 {
+		return nil, errors.New("no rows to delete")
+	}
+	rowIDColumns, err := pgqe.getEditableRowIDColumns(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	conditions := []string{}
+	args := []interface{}{}
+	for _, rowID := range rowIDs {
+		whereQuery, whereArgs, err := pgxutils.RowIDCondition(rowIDColumns, rowID, len(args)+1)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "("+whereQuery+")")
+		args = append(args, whereArgs...)
+	}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s;`, pgxutils.QuoteIdentifier(schema, name), strings.Join(conditions, " OR "))
+	return pgqe.runQuery(dbConn, query, args, config)
 }
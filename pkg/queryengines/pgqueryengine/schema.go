@@ -0,0 +1,161 @@
+package pgqueryengine
+
+import (
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/pgqueryengine/pgxutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// GetDBSchema returns the data models of the database with their fields, indexes, constraints and
+// foreign keys, to diff the schemas of two databases. The types of the fields have their modifiers,
+// like varchar(255), and the indexes backing a constraint are part of the constraint.
+func (pgqe *PostgresQueryEngine) GetDBSchema(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBDataModel, error) {
+	dataModels, err := pgqe.GetDataModels(dbConn, config)
+	if err != nil {
+		return nil, err
+	}
+	dataModelsByName := map[string]*qemodels.DBDataModel{}
+	for _, dataModel := range dataModels {
+		dataModel.Fields = []qemodels.DBDataModelField{}
+		dataModel.Indexes = []qemodels.DBDataModelIndex{}
+		dataModel.ForeignKeys = []qemodels.DBDataModelForeignKey{}
+		dataModel.Constraints = []qemodels.DBDataModelConstraint{}
+		dataModelsByName[pgxutils.QuoteIdentifier(dataModel.SchemaName, dataModel.Name)] = dataModel
+	}
+	getDataModel := func(row map[string]interface{}) *qemodels.DBDataModel {
+		return dataModelsByName[pgxutils.QuoteIdentifier(row["0"].(string), row["1"].(string))]
+	}
+
+	query := `SELECT n.nspname, c.relname, pg_get_viewdef(c.oid, true)
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('v', 'm') AND ` + userSchemaCondition + `;`
+	data, err := pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		if dataModel := getDataModel(row); dataModel != nil {
+			dataModel.Definition = viewDefinition(dataModel.Kind, pgxutils.QuoteIdentifier(dataModel.SchemaName, dataModel.Name), row["2"].(string))
+		}
+	}
+
+	// the columns used by the query of a view are its dependencies, a whole row reference depends on the relation
+	query = `SELECT DISTINCT n.nspname, c.relname, rn.nspname, rc.relname, COALESCE(a.attname, '')
+		FROM pg_catalog.pg_depend d
+		JOIN pg_catalog.pg_rewrite r ON r.oid = d.objid
+		JOIN pg_catalog.pg_class c ON c.oid = r.ev_class
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_class rc ON rc.oid = d.refobjid
+		JOIN pg_catalog.pg_namespace rn ON rn.oid = rc.relnamespace
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid AND d.refobjsubid > 0
+		WHERE d.classid = 'pg_catalog.pg_rewrite'::regclass AND d.refclassid = 'pg_catalog.pg_class'::regclass
+			AND d.deptype = 'n' AND d.refobjid <> r.ev_class AND c.relkind IN ('v', 'm') AND ` + userSchemaCondition + `
+		ORDER BY 1, 2, 3, 4, 5;`
+	data, err = pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		if dataModel := getDataModel(row); dataModel != nil {
+			dataModel.Dependencies = append(dataModel.Dependencies, qemodels.DBDataModelDependency{
+				SchemaName: row["2"].(string),
+				Name:       row["3"].(string),
+				Column:     row["4"].(string),
+			})
+		}
+	}
+
+	query = `SELECT n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+			EXISTS (SELECT 1 FROM pg_catalog.pg_constraint pk WHERE pk.conrelid = c.oid AND pk.contype = 'p' AND a.attnum = ANY(pk.conkey)),
+			pg_get_expr(d.adbin, d.adrelid)
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND a.attnum > 0 AND NOT a.attisdropped AND ` + userSchemaCondition + `
+		ORDER BY n.nspname, c.relname, a.attnum;`
+	data, err = pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		dataModel := getDataModel(row)
+		if dataModel == nil {
+			continue
+		}
+		field := qemodels.DBDataModelField{
+			Name:       row["2"].(string),
+			Type:       row["3"].(string),
+			IsNullable: !row["4"].(bool),
+			IsPrimary:  row["5"].(bool),
+			Tags:       []string{},
+		}
+		if defaultValue, ok := row["6"].(string); ok {
+			field.Default = &defaultValue
+		}
+		dataModel.Fields = append(dataModel.Fields, field)
+	}
+
+	query = `SELECT n.nspname, c.relname, con.conname,
+			CASE con.contype WHEN 'p' THEN 'PRIMARY KEY' WHEN 'u' THEN 'UNIQUE' WHEN 'c' THEN 'CHECK' ELSE 'EXCLUDE' END,
+			pg_get_constraintdef(con.oid, true)
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE con.contype IN ('p', 'u', 'c', 'x') AND ` + userSchemaCondition + `
+		ORDER BY n.nspname, c.relname, con.conname;`
+	data, err = pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		if dataModel := getDataModel(row); dataModel != nil {
+			dataModel.Constraints = append(dataModel.Constraints, qemodels.DBDataModelConstraint{
+				Name:       row["2"].(string),
+				Type:       row["3"].(string),
+				Definition: row["4"].(string),
+			})
+		}
+	}
+
+	query = `SELECT n.nspname, c.relname, ic.relname, pg_get_indexdef(ix.indexrelid)
+		FROM pg_catalog.pg_index ix
+		JOIN pg_catalog.pg_class ic ON ic.oid = ix.indexrelid
+		JOIN pg_catalog.pg_class c ON c.oid = ix.indrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE ` + userSchemaCondition + `
+			AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_constraint con WHERE con.conindid = ix.indexrelid AND con.contype IN ('p', 'u', 'x'))
+		ORDER BY n.nspname, c.relname, ic.relname;`
+	data, err = pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		if dataModel := getDataModel(row); dataModel != nil {
+			dataModel.Indexes = append(dataModel.Indexes, qemodels.DBDataModelIndex{
+				Name:     row["2"].(string),
+				IndexDef: row["3"].(string),
+			})
+		}
+	}
+
+	query = foreignKeysQuery + " AND " + userSchemaCondition + " ORDER BY con.conname;"
+	data, err = pgqe.runQuery(dbConn, query, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeysData := data["rows"].([]map[string]interface{})
+	for i, foreignKey := range pgxutils.QueryToForeignKeys(foreignKeysData) {
+		if dataModel := dataModelsByName[pgxutils.QuoteIdentifier(foreignKeysData[i]["7"].(string), foreignKeysData[i]["8"].(string))]; dataModel != nil {
+			dataModel.ForeignKeys = append(dataModel.ForeignKeys, foreignKey)
+		}
+	}
+	return dataModels, nil
+}
+
+// GetMigrationScript returns the script bringing the target of the diff in line with its source.
+func (pgqe *PostgresQueryEngine) GetMigrationScript(diff *qemodels.SchemaDiff) string {
+	return pgxutils.MigrationScript(diff)
+}
@@ -0,0 +1,122 @@
+package pgqueryengine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/pgqueryengine/pgxutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// pgxQuerier is implemented by the pool, pooled connections and transactions.
+type pgxQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+// RunScript runs the statements of the script in order on a single connection, optionally inside one transaction.
+// It stops at the first failing statement, rolling back the transaction, and returns the result of each statement run.
+func (pgqe *PostgresQueryEngine) RunScript(dbConn *models.DBConnection, script string, inTransaction bool, config *queryconfig.QueryConfig) ([]*qemodels.StatementResult, error) {
+	statements, err := pgxutils.SplitScript(script)
+	if err != nil {
+		if config.ReadOnly {
+			return nil, errors.New("not allowed run this query")
+		}
+		// the parser does not support every postgres statement, run the script as is
+		statements = []pgxutils.ScriptStatement{{Query: script, Unparsed: true}}
+	}
+	if len(statements) == 0 {
+		return nil, errors.New("script is empty")
+	}
+	pool, err := pgqe.getPool(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pool.Acquire(config.GetContext())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	resetStatementTimeout, err := setStatementTimeout(conn, config)
+	if err != nil {
+		return nil, err
+	}
+	defer resetStatementTimeout()
+
+	var querier pgxQuerier = conn
+	var tx pgx.Tx
+	if inTransaction {
+		tx, err = conn.Begin(config.GetContext())
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback(context.Background())
+		querier = tx
+	}
+
+	results := []*qemodels.StatementResult{}
+	for _, statement := range statements {
+		result := runStatement(querier, statement, config)
+		results = append(results, result)
+		if result.Error != "" {
+			return results, nil
+		}
+	}
+	if tx != nil {
+		if err := tx.Commit(config.GetContext()); err != nil {
+			results[len(results)-1].Error = err.Error()
+		}
+	}
+	return results, nil
+}
+
+func runStatement(querier pgxQuerier, statement pgxutils.ScriptStatement, config *queryconfig.QueryConfig) *qemodels.StatementResult {
+	result := &qemodels.StatementResult{
+		Query:    statement.Query,
+		Position: statement.Position,
+	}
+	if queryType, _ := pgxutils.GetPSQLQueryType(statement.Query); queryType != pgxutils.QUERY_READ && config.ReadOnly {
+		result.Error = "not allowed run this query"
+		return result
+	}
+	start := time.Now()
+	if statement.Unparsed {
+		// only the simple protocol runs more than one statement, the command tag is the one of the last statement
+		cmdTag, err := querier.Exec(config.GetContext(), statement.Query)
+		result.Message = cmdTag.String()
+		return finishStatement(result, statement, start, err, config)
+	}
+	rows, err := querier.Query(config.GetContext(), statement.Query)
+	if err == nil {
+		if len(rows.FieldDescriptions()) > 0 {
+			result.Columns, result.Rows, result.Truncated = pgxutils.PgSqlRowsToJson(rows, config.MaxRows)
+		}
+		rows.Close()
+		err = rows.Err()
+		result.Message = rows.CommandTag().String()
+	}
+	return finishStatement(result, statement, start, err, config)
+}
+
+// finishStatement sets the duration and the error of the statement run, logging it when it succeeded.
+func finishStatement(result *qemodels.StatementResult, statement pgxutils.ScriptStatement, start time.Time, err error, config *queryconfig.QueryConfig) *qemodels.StatementResult {
+	result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		result.Columns, result.Rows, result.Message = nil, nil, ""
+		result.Error = err.Error()
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Position > 0 {
+			result.ErrorPosition = statement.Position + int(pgErr.Position)
+		}
+		return result
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(statement.Query)
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package pgqueryengine
+
+import (
+	"context"
+	"errors"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/pgqueryengine/pgxutils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// StreamQuery runs the query writing the rows to the stream as they are read, queries which
+// do not return rows are run with RunQuery. It returns the metadata of the columns, the row count
+// and if the rows were truncated.
+func (pgqe *PostgresQueryEngine) StreamQuery(dbConn *models.DBConnection, query string, stream qemodels.ResultStream, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	queryType, isReturningRows := pgxutils.GetPSQLQueryType(query)
+	if !isReturningRows {
+		return pgqe.RunQuery(dbConn, query, config)
+	}
+	if queryType != pgxutils.QUERY_READ && config.ReadOnly {
+		return nil, errors.New("not allowed run this query")
+	}
+
+	pool, err := pgqe.getPool(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pool.Acquire(config.GetContext())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if config.QueryID != "" {
+		removeRunningQuery, err := pgqe.addRunningQuery(dbConn, conn, config.QueryID)
+		if err != nil {
+			return nil, err
+		}
+		defer removeRunningQuery()
+	}
+
+	resetStatementTimeout, err := setStatementTimeout(conn, config)
+	if err != nil {
+		return nil, err
+	}
+	defer resetStatementTimeout()
+
+	// cancelling the context aborts the query when the stream fails or the rows are truncated,
+	// instead of reading the remaining rows
+	ctx, cancel := context.WithCancel(config.GetContext())
+	defer cancel()
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columnsMetadata := pgxutils.PgSqlColumnsMetadata(rows)
+	if err := stream.WriteColumns(pgxutils.PgSqlRowsColumns(rows)); err != nil {
+		cancel()
+		return nil, err
+	}
+	rowCount := 0
+	truncated, err := pgxutils.PgSqlRowsEach(rows, config.MaxRows, func(row map[string]interface{}) error {
+		rowCount++
+		return stream.WriteRow(row)
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if truncated {
+		cancel()
+	}
+	rows.Close()
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(query)
+	}
+	pgqe.resolveColumnsMetadata(dbConn, columnsMetadata, config)
+	return map[string]interface{}{
+		"columnsMetadata": columnsMetadata,
+		"rowCount":        rowCount,
+		"truncated":       truncated,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package pgqueryengine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+)
+
+// pgxTransactionInstance is a transaction open across requests, it pins one connection of the pool.
+type pgxTransactionInstance struct {
+	dbConnectionId string
+	userID         string
+	conn           *pgxpool.Conn
+	tx             pgx.Tx
+	idleTimer      *time.Timer
+	mutex          sync.Mutex
+}
+
+func (pgqe *PostgresQueryEngine) BeginTransaction(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (string, error) {
+	pool, err := pgqe.getPool(dbConn)
+	if err != nil {
+		return "", err
+	}
+	conn, err := pool.Acquire(context.Background())
+	if err != nil {
+		return "", err
+	}
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		conn.Release()
+		return "", err
+	}
+	transactionID := uuid.NewString()
+	instance := &pgxTransactionInstance{
+		dbConnectionId: dbConn.ID,
+		userID:         config.UserID,
+		conn:           conn,
+		tx:             tx,
+	}
+	instance.idleTimer = time.AfterFunc(qemodels.TRANSACTION_IDLE_TIMEOUT, func() {
+		pgqe.RollbackTransaction(dbConn, transactionID, config)
+	})
+	pgqe.transactionsMutex.Lock()
+	pgqe.openTransactions[transactionID] = instance
+	pgqe.transactionsMutex.Unlock()
+	if config.CreateLogFn != nil {
+		config.CreateLogFn("BEGIN;")
+	}
+	return transactionID, nil
+}
+
+func (pgqe *PostgresQueryEngine) RunTransactionQuery(dbConn *models.DBConnection, transactionID string, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	instance, err := pgqe.getTransaction(dbConn, transactionID, false, config)
+	if err != nil {
+		return nil, err
+	}
+	defer instance.mutex.Unlock()
+	// the idle time starts again after the query
+	instance.idleTimer.Stop()
+	defer instance.idleTimer.Reset(qemodels.TRANSACTION_IDLE_TIMEOUT)
+	return runQueryOn(instance.tx, query, nil, config)
+}
+
+func (pgqe *PostgresQueryEngine) CommitTransaction(dbConn *models.DBConnection, transactionID string, config *queryconfig.QueryConfig) error {
+	instance, err := pgqe.getTransaction(dbConn, transactionID, true, config)
+	if err != nil {
+		return err
+	}
+	defer instance.close()
+	if err := instance.tx.Commit(context.Background()); err != nil {
+		return err
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn("COMMIT;")
+	}
+	return nil
+}
+
+func (pgqe *PostgresQueryEngine) RollbackTransaction(dbConn *models.DBConnection, transactionID string, config *queryconfig.QueryConfig) error {
+	instance, err := pgqe.getTransaction(dbConn, transactionID, true, config)
+	if err != nil {
+		return err
+	}
+	defer instance.close()
+	if err := instance.tx.Rollback(context.Background()); err != nil {
+		return err
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn("ROLLBACK;")
+	}
+	return nil
+}
+
+// getTransaction returns the locked transaction instance, removing it from the open transactions if remove is true.
+// Only the user who began the transaction can use it.
+func (pgqe *PostgresQueryEngine) getTransaction(dbConn *models.DBConnection, transactionID string, remove bool, config *queryconfig.QueryConfig) (*pgxTransactionInstance, error) {
+	pgqe.transactionsMutex.Lock()
+	instance, exists := pgqe.openTransactions[transactionID]
+	if !exists || instance.dbConnectionId != dbConn.ID || instance.userID != config.UserID {
+		pgqe.transactionsMutex.Unlock()
+		return nil, errors.New("transaction not found, it may have been rolled back after being idle")
+	}
+	if remove {
+		delete(pgqe.openTransactions, transactionID)
+	}
+	pgqe.transactionsMutex.Unlock()
+	instance.mutex.Lock()
+	return instance, nil
+}
+
+func (instance *pgxTransactionInstance) close() {
+	instance.idleTimer.Stop()
+	instance.conn.Release()
+	instance.mutex.Unlock()
+}
@@ -0,0 +1,99 @@
+package qemodels
+
+// DBSession is a session connected to the database server. Duration is how long the session has been in
+// its state in milliseconds, which is how long its query has been running when it is active.
+type DBSession struct {
+	PID             int32  `json:"pid"`
+	DatabaseName    string `json:"databaseName"`
+	User            string `json:"user"`
+	ApplicationName string `json:"applicationName"`
+	ClientAddress   string `json:"clientAddress"`
+	State           string `json:"state"`
+	WaitEventType   string `json:"waitEventType"`
+	WaitEvent       string `json:"waitEvent"`
+	Query           string `json:"query"`
+	Duration        *int64 `json:"duration"`
+}
+
+// DBLock is a lock held or waited for by a session. Relation is the locked data model, or database for mongo,
+// and is empty for the locks which are not on one, like the locks of transaction ids.
+type DBLock struct {
+	LockType string `json:"lockType"`
+	Mode     string `json:"mode"`
+	Granted  bool   `json:"granted"`
+	Relation string `json:"relation,omitempty"`
+}
+
+// DBLockedSession is a session which blocks or is blocked by other sessions, BlockedBy are the pids of the
+// sessions holding the locks it waits for.
+type DBLockedSession struct {
+	DBSession
+	Locks     []DBLock `json:"locks"`
+	BlockedBy []int32  `json:"blockedBy"`
+}
+
+// DBBlockingNode is a session in a tree of blocking sessions, Blocked are the sessions waiting for it.
+type DBBlockingNode struct {
+	*DBLockedSession
+	Blocked []*DBBlockingNode `json:"blocked"`
+}
+
+// DBLocks are the sessions blocking each other, and the trees of the blocking chains whose roots are the sessions
+// which block others without being blocked. The sessions of a deadlock, which block each other, are a tree of
+// their own rooted at one of them.
+type DBLocks struct {
+	Sessions     []*DBLockedSession `json:"sessions"`
+	BlockingTree []*DBBlockingNode  `json:"blockingTree"`
+}
+
+// NewDBLocks returns the locks of the sessions with the trees of their blocking chains. A session blocked by
+// more than one session is in the tree of each of them.
+func NewDBLocks(sessions []*DBLockedSession) *DBLocks {
+	sessionsByPID := map[int32]*DBLockedSession{}
+	for _, session := range sessions {
+		sessionsByPID[session.PID] = session
+	}
+	blocked := map[int32][]*DBLockedSession{}
+	for _, session := range sessions {
+		for _, pid := range session.BlockedBy {
+			blocked[pid] = append(blocked[pid], session)
+		}
+	}
+
+	visited := map[int32]bool{}
+	var buildNode func(session *DBLockedSession, path map[int32]bool) *DBBlockingNode
+	buildNode = func(session *DBLockedSession, path map[int32]bool) *DBBlockingNode {
+		visited[session.PID] = true
+		path[session.PID] = true
+		defer delete(path, session.PID)
+		node := DBBlockingNode{DBLockedSession: session, Blocked: []*DBBlockingNode{}}
+		for _, blockedSession := range blocked[session.PID] {
+			if !path[blockedSession.PID] {
+				node.Blocked = append(node.Blocked, buildNode(blockedSession, path))
+			}
+		}
+		return &node
+	}
+	isRoot := func(session *DBLockedSession) bool {
+		for _, pid := range session.BlockedBy {
+			if sessionsByPID[pid] != nil {
+				return false
+			}
+		}
+		return len(blocked[session.PID]) > 0
+	}
+
+	locks := DBLocks{Sessions: sessions, BlockingTree: []*DBBlockingNode{}}
+	for _, session := range sessions {
+		if isRoot(session) {
+			locks.BlockingTree = append(locks.BlockingTree, buildNode(session, map[int32]bool{}))
+		}
+	}
+	// the sessions left are in cycles of sessions blocking each other
+	for _, session := range sessions {
+		if !visited[session.PID] && len(blocked[session.PID]) > 0 {
+			locks.BlockingTree = append(locks.BlockingTree, buildNode(session, map[int32]bool{}))
+		}
+	}
+	return &locks
+}
@@ -0,0 +1,50 @@
+package qemodels
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// blockingTreeString writes the trees as pid(blocked pids), to compare trees in the tests.
+func blockingTreeString(nodes []*DBBlockingNode) string {
+	trees := []string{}
+	for _, node := range nodes {
+		tree := fmt.Sprint(node.PID)
+		if len(node.Blocked) > 0 {
+			tree += "(" + blockingTreeString(node.Blocked) + ")"
+		}
+		trees = append(trees, tree)
+	}
+	return strings.Join(trees, " ")
+}
+
+func lockedSession(pid int32, blockedBy ...int32) *DBLockedSession {
+	return &DBLockedSession{DBSession: DBSession{PID: pid}, Locks: []DBLock{}, BlockedBy: blockedBy}
+}
+
+func TestNewDBLocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []*DBLockedSession
+		expected string
+	}{
+		{"no sessions", []*DBLockedSession{}, ""},
+		{"chain", []*DBLockedSession{lockedSession(3, 2), lockedSession(1), lockedSession(2, 1)}, "1(2(3))"},
+		{"several blockers", []*DBLockedSession{lockedSession(1), lockedSession(2), lockedSession(3, 1, 2)}, "1(3) 2(3)"},
+		{"several blocked", []*DBLockedSession{lockedSession(1), lockedSession(2, 1), lockedSession(3, 1), lockedSession(4, 3)}, "1(2 3(4))"},
+		{"deadlock", []*DBLockedSession{lockedSession(1, 2), lockedSession(2, 1)}, "1(2)"},
+		{"deadlock with waiting session", []*DBLockedSession{lockedSession(1, 3), lockedSession(2, 1), lockedSession(3, 2), lockedSession(4, 2)}, "1(2(3 4))"},
+		{"deadlock and chain", []*DBLockedSession{lockedSession(1), lockedSession(2, 1), lockedSession(3, 4), lockedSession(4, 3)}, "1(2) 3(4)"},
+		{"unknown blocker", []*DBLockedSession{lockedSession(2, 99), lockedSession(3, 2)}, "2(3)"},
+	}
+	for _, test := range tests {
+		locks := NewDBLocks(test.sessions)
+		if len(locks.Sessions) != len(test.sessions) {
+			t.Errorf("%s: sessions: %d", test.name, len(locks.Sessions))
+		}
+		if tree := blockingTreeString(locks.BlockingTree); tree != test.expected {
+			t.Errorf("%s: blocking tree: %s, expected %s", test.name, tree, test.expected)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package qemodels
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Cursor is the position after the last row of a page, used for keyset pagination.
+// Keys are the sort fields followed by the row identity fields and
+// Values are the values of the keys in the last row, encoded by the query engine.
+type Cursor struct {
+	Keys   []string `json:"k"`
+	Values string   `json:"v"`
+}
+
+// Encode returns the opaque cursor string sent to the client.
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes the cursor string and checks it was created for the same keys.
+func DecodeCursor(cursor string, keys []string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	decoded := Cursor{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	if strings.Join(decoded.Keys, "\x00") != strings.Join(keys, "\x00") {
+		return nil, errors.New("cursor does not match the sort")
+	}
+	return &decoded, nil
+}
+
+// KeysetFields returns the sort fields followed by the row identity fields which are
+// not already sorted on, so that the order of the rows is unique.
+func KeysetFields(sort []SortField, rowIDFields []string) []SortField {
+	fields := append([]SortField{}, sort...)
+	for _, rowIDField := range rowIDFields {
+		sorted := false
+		for _, field := range sort {
+			if field.Field == rowIDField {
+				sorted = true
+				break
+			}
+		}
+		if !sorted {
+			fields = append(fields, SortField{Field: rowIDField, Direction: "ASC"})
+		}
+	}
+	return fields
+}
@@ -0,0 +1,112 @@
+package qemodels
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DataModelSpec is a data model to create. Constraints are table constraints, like CHECK or a
+// composite UNIQUE, their type is not used and their definition is written as is.
+type DataModelSpec struct {
+	Name        string                  `json:"name"`
+	Fields      []FieldSpec             `json:"fields"`
+	Constraints []DBDataModelConstraint `json:"constraints"`
+}
+
+// FieldSpec is a field of a data model to create, Type is a type of the database. The fields
+// which are primary make the primary key of the data model in their order.
+type FieldSpec struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	IsNullable bool    `json:"isNullable"`
+	IsPrimary  bool    `json:"isPrimary"`
+	IsUnique   bool    `json:"isUnique"`
+	Default    *string `json:"default"`
+}
+
+// Validate returns an error if the data model cannot be created, the types, defaults and definitions
+// are written as is so they must not end the statement they are part of.
+func (spec *DataModelSpec) Validate() error {
+	if spec.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(spec.Fields) == 0 {
+		return errors.New("at least one field is required")
+	}
+	for _, field := range spec.Fields {
+		if field.Name == "" || !IsSingleExpression(field.Type) {
+			return errors.New("invalid field: " + field.Name)
+		}
+		if field.Default != nil && !IsSingleExpression(*field.Default) {
+			return errors.New("invalid default of field: " + field.Name)
+		}
+	}
+	for _, constraint := range spec.Constraints {
+		if constraint.Definition == "" || !IsSingleExpression(constraint.Definition) {
+			return errors.New("invalid constraint: " + constraint.Name)
+		}
+	}
+	return nil
+}
+
+// CreateTableQuery returns the CREATE TABLE statement of the data model for the SQL databases,
+// table is the quoted name of the table and quoteIdentifier quotes the names of the columns.
+func (spec *DataModelSpec) CreateTableQuery(table string, quoteIdentifier func(string) string) string {
+	definitions := []string{}
+	primaryKey := []string{}
+	for _, field := range spec.Fields {
+		definition := quoteIdentifier(field.Name) + " " + field.Type
+		if !field.IsNullable {
+			definition += " NOT NULL"
+		}
+		if field.Default != nil {
+			definition += " DEFAULT " + *field.Default
+		}
+		if field.IsUnique {
+			definition += " UNIQUE"
+		}
+		if field.IsPrimary {
+			primaryKey = append(primaryKey, quoteIdentifier(field.Name))
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(primaryKey) > 0 {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
+	}
+	for _, constraint := range spec.Constraints {
+		if constraint.Name != "" {
+			definitions = append(definitions, fmt.Sprintf("CONSTRAINT %s %s", quoteIdentifier(constraint.Name), constraint.Definition))
+		} else {
+			definitions = append(definitions, constraint.Definition)
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n    %s\n);", table, strings.Join(definitions, ",\n    "))
+}
+
+// IsSingleExpression reports if the type or expression cannot end the statement it is part of in any of the
+// SQL databases. Outside of quotes it must not have a semicolon, a comment or a dollar quote, and inside quotes
+// no backslash, as the databases do not read them alike. The parsers cannot be used as they do not know user
+// defined types.
+func IsSingleExpression(expr string) bool {
+	var quote, previous rune
+	for _, r := range expr {
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				return false
+			}
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ';' || r == '#' || r == '$':
+			return false
+		case previous == '-' && r == '-', previous == '/' && r == '*':
+			return false
+		}
+		previous = r
+	}
+	return quote == 0
+}
@@ -0,0 +1,59 @@
+package qemodels
+
+import "testing"
+
+func TestIsSingleExpression(t *testing.T) {
+	tests := map[string]bool{
+		"integer":                              true,
+		"varchar(255)":                         true,
+		"public.user_status":                   true,
+		"'a;b'":                                true,
+		"'it''s'":                              true,
+		`"weird;name"`:                         true,
+		"CHECK (price > 0 AND price - 1 < 10)": true,
+		"now() - interval '1 day'":             true,
+		"'--' || '/*'":                         true,
+		"integer; DROP TABLE users":            false,
+		"now() -- ; DROP TABLE users":          false,
+		"now() /* ; */":                        false,
+		"text /* unterminated":                 false,
+		"$$a$$":                                false,
+		"$tag$ ; DROP TABLE users; $tag$":      false,
+		"'a\\'; DROP TABLE users; --'":         false,
+		"1 # ; DROP TABLE users":               false,
+		"'unterminated":                        false,
+		"`a`; DROP TABLE users":                false,
+	}
+	for expr, expected := range tests {
+		if IsSingleExpression(expr) != expected {
+			t.Errorf("IsSingleExpression(%s) is not %t", expr, expected)
+		}
+	}
+}
+
+func TestDataModelSpecValidate(t *testing.T) {
+	injections := []string{
+		"text); DROP TABLE users; --",
+		"text /* ; */",
+		"text DEFAULT $$x$$); DROP TABLE users; SELECT ($$",
+	}
+	for _, injection := range injections {
+		spec := DataModelSpec{Name: "posts", Fields: []FieldSpec{{Name: "title", Type: injection}}}
+		if err := spec.Validate(); err == nil {
+			t.Error("invalid type is allowed:", injection)
+		}
+		spec = DataModelSpec{Name: "posts", Fields: []FieldSpec{{Name: "title", Type: "text", Default: &injection}}}
+		if err := spec.Validate(); err == nil {
+			t.Error("invalid default is allowed:", injection)
+		}
+		spec = DataModelSpec{Name: "posts", Fields: []FieldSpec{{Name: "title", Type: "text"}},
+			Constraints: []DBDataModelConstraint{{Definition: "CHECK (title <> '') " + injection}}}
+		if err := spec.Validate(); err == nil {
+			t.Error("invalid constraint is allowed:", injection)
+		}
+	}
+	spec := DataModelSpec{Name: "posts", Fields: []FieldSpec{{Name: "title", Type: "text"}}}
+	if err := spec.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,333 @@
+package qemodels
+
+import (
+	"reflect"
+)
+
+// SchemaDiff is the difference between the data models of a source and a target database, as the changes
+// which would bring the target in line with the source. MigrationScript applies the changes on the target,
+// it is only set for the engines which can generate it.
+type SchemaDiff struct {
+	AddedDataModels   []*DBDataModel   `json:"addedDataModels"`
+	RemovedDataModels []*DBDataModel   `json:"removedDataModels"`
+	ChangedDataModels []*DataModelDiff `json:"changedDataModels"`
+	// RecreatedViews are the views of the source whose view in the target is dropped and created again, as its
+	// definition changed or it depends on a data model, a column or a view which is dropped or retyped. Their
+	// dependencies are the ones of the source and the target, they are ordered after the views they depend on.
+	RecreatedViews  []*DBDataModel `json:"recreatedViews"`
+	MigrationScript string         `json:"migrationScript,omitempty"`
+}
+
+// DataModelDiff is the difference between a data model of the source and the data model of the target with
+// the same schema, name and kind. Added are only in the source, removed only in the target, and changed
+// have the same name but differ. The definitions are only set when they differ.
+type DataModelDiff struct {
+	SchemaName         string                  `json:"schemaName"`
+	Name               string                  `json:"name"`
+	Kind               string                  `json:"kind"`
+	SourceDefinition   string                  `json:"sourceDefinition,omitempty"`
+	TargetDefinition   string                  `json:"targetDefinition,omitempty"`
+	AddedFields        []DBDataModelField      `json:"addedFields"`
+	RemovedFields      []DBDataModelField      `json:"removedFields"`
+	ChangedFields      []FieldChange           `json:"changedFields"`
+	AddedIndexes       []DBDataModelIndex      `json:"addedIndexes"`
+	RemovedIndexes     []DBDataModelIndex      `json:"removedIndexes"`
+	ChangedIndexes     []IndexChange           `json:"changedIndexes"`
+	AddedConstraints   []DBDataModelConstraint `json:"addedConstraints"`
+	RemovedConstraints []DBDataModelConstraint `json:"removedConstraints"`
+	ChangedConstraints []ConstraintChange      `json:"changedConstraints"`
+	AddedForeignKeys   []DBDataModelForeignKey `json:"addedForeignKeys"`
+	RemovedForeignKeys []DBDataModelForeignKey `json:"removedForeignKeys"`
+	ChangedForeignKeys []ForeignKeyChange      `json:"changedForeignKeys"`
+}
+
+type FieldChange struct {
+	Source DBDataModelField `json:"source"`
+	Target DBDataModelField `json:"target"`
+}
+
+type IndexChange struct {
+	Source DBDataModelIndex `json:"source"`
+	Target DBDataModelIndex `json:"target"`
+}
+
+type ConstraintChange struct {
+	Source DBDataModelConstraint `json:"source"`
+	Target DBDataModelConstraint `json:"target"`
+}
+
+type ForeignKeyChange struct {
+	Source DBDataModelForeignKey `json:"source"`
+	Target DBDataModelForeignKey `json:"target"`
+}
+
+// DiffDataModels returns the difference between the source and target data models, which must have
+// their fields, indexes, constraints and foreign keys. A data model whose kind changed is removed and added.
+func DiffDataModels(source, target []*DBDataModel) *SchemaDiff {
+	diff := SchemaDiff{
+		AddedDataModels:   []*DBDataModel{},
+		RemovedDataModels: []*DBDataModel{},
+		ChangedDataModels: []*DataModelDiff{},
+	}
+	targetDataModels := map[[3]string]*DBDataModel{}
+	for _, dataModel := range target {
+		targetDataModels[dataModelKey(dataModel)] = dataModel
+	}
+	sourceDataModels := map[[3]string]bool{}
+	for _, sourceDataModel := range source {
+		key := dataModelKey(sourceDataModel)
+		sourceDataModels[key] = true
+		targetDataModel, exists := targetDataModels[key]
+		if !exists {
+			diff.AddedDataModels = append(diff.AddedDataModels, sourceDataModel)
+			continue
+		}
+		if dataModelDiff := diffDataModel(sourceDataModel, targetDataModel); dataModelDiff != nil {
+			diff.ChangedDataModels = append(diff.ChangedDataModels, dataModelDiff)
+		}
+	}
+	for _, targetDataModel := range target {
+		if !sourceDataModels[dataModelKey(targetDataModel)] {
+			diff.RemovedDataModels = append(diff.RemovedDataModels, targetDataModel)
+		}
+	}
+	diff.RecreatedViews = recreatedViews(source, target, &diff)
+	return &diff
+}
+
+// recreatedViews returns the views of the source whose view in the target must be dropped and created again.
+// A view which depends on a column cannot be altered when the column is dropped or retyped, and a view cannot be
+// dropped while other views depend on it, so the views depending on a recreated view are recreated too.
+func recreatedViews(source, target []*DBDataModel, diff *SchemaDiff) []*DBDataModel {
+	removed := map[[2]string]bool{}
+	for _, dataModel := range diff.RemovedDataModels {
+		removed[[2]string{dataModel.SchemaName, dataModel.Name}] = true
+	}
+	changedColumns := map[[2]string]map[string]bool{}
+	recreated := map[[2]string]bool{}
+	for _, dataModelDiff := range diff.ChangedDataModels {
+		key := [2]string{dataModelDiff.SchemaName, dataModelDiff.Name}
+		columns := map[string]bool{}
+		for _, field := range dataModelDiff.RemovedFields {
+			columns[field.Name] = true
+		}
+		for _, change := range dataModelDiff.ChangedFields {
+			if change.Source.Type != change.Target.Type {
+				columns[change.Target.Name] = true
+			}
+		}
+		changedColumns[key] = columns
+		if isView(dataModelDiff.Kind) && dataModelDiff.SourceDefinition != dataModelDiff.TargetDefinition {
+			recreated[key] = true
+		}
+	}
+	isChanged := func(dependency DBDataModelDependency) bool {
+		key := [2]string{dependency.SchemaName, dependency.Name}
+		if removed[key] || recreated[key] {
+			return true
+		}
+		if dependency.Column == "" {
+			return len(changedColumns[key]) > 0
+		}
+		return changedColumns[key][dependency.Column]
+	}
+
+	sourceViews := map[[3]string]*DBDataModel{}
+	for _, dataModel := range source {
+		if isView(dataModel.Kind) {
+			sourceViews[dataModelKey(dataModel)] = dataModel
+		}
+	}
+	targetViews := []*DBDataModel{}
+	for _, dataModel := range target {
+		if sourceViews[dataModelKey(dataModel)] != nil {
+			targetViews = append(targetViews, dataModel)
+		}
+	}
+	for isRecreating := true; isRecreating; {
+		isRecreating = false
+		for _, view := range targetViews {
+			key := [2]string{view.SchemaName, view.Name}
+			if recreated[key] {
+				continue
+			}
+			for _, dependency := range view.Dependencies {
+				if isChanged(dependency) {
+					recreated[key] = true
+					isRecreating = true
+					break
+				}
+			}
+		}
+	}
+
+	views := []*DBDataModel{}
+	for _, targetView := range targetViews {
+		if !recreated[[2]string{targetView.SchemaName, targetView.Name}] {
+			continue
+		}
+		view := *sourceViews[dataModelKey(targetView)]
+		view.Dependencies = append(append([]DBDataModelDependency{}, view.Dependencies...), targetView.Dependencies...)
+		views = append(views, &view)
+	}
+	return SortViews(views)
+}
+
+func isView(kind string) bool {
+	return kind == DATA_MODEL_KIND_VIEW || kind == DATA_MODEL_KIND_MATERIALIZED_VIEW
+}
+
+// SortViews orders the views after the views among them which they depend on.
+func SortViews(views []*DBDataModel) []*DBDataModel {
+	viewsByName := map[[2]string]*DBDataModel{}
+	for _, view := range views {
+		viewsByName[[2]string{view.SchemaName, view.Name}] = view
+	}
+	sorted := []*DBDataModel{}
+	visited := map[[2]string]bool{}
+	var visit func(view *DBDataModel)
+	visit = func(view *DBDataModel) {
+		key := [2]string{view.SchemaName, view.Name}
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		for _, dependency := range view.Dependencies {
+			if dependencyView, exists := viewsByName[[2]string{dependency.SchemaName, dependency.Name}]; exists {
+				visit(dependencyView)
+			}
+		}
+		sorted = append(sorted, view)
+	}
+	for _, view := range views {
+		visit(view)
+	}
+	return sorted
+}
+
+func dataModelKey(dataModel *DBDataModel) [3]string {
+	return [3]string{dataModel.SchemaName, dataModel.Name, dataModel.Kind}
+}
+
+// diffDataModel returns the difference between the data models with the same key, nil if they are the same.
+func diffDataModel(source, target *DBDataModel) *DataModelDiff {
+	dataModelDiff := DataModelDiff{
+		SchemaName:         source.SchemaName,
+		Name:               source.Name,
+		Kind:               source.Kind,
+		AddedFields:        []DBDataModelField{},
+		RemovedFields:      []DBDataModelField{},
+		ChangedFields:      []FieldChange{},
+		AddedIndexes:       []DBDataModelIndex{},
+		RemovedIndexes:     []DBDataModelIndex{},
+		ChangedIndexes:     []IndexChange{},
+		AddedConstraints:   []DBDataModelConstraint{},
+		RemovedConstraints: []DBDataModelConstraint{},
+		ChangedConstraints: []ConstraintChange{},
+		AddedForeignKeys:   []DBDataModelForeignKey{},
+		RemovedForeignKeys: []DBDataModelForeignKey{},
+		ChangedForeignKeys: []ForeignKeyChange{},
+	}
+	changed := false
+	if source.Definition != target.Definition {
+		dataModelDiff.SourceDefinition = source.Definition
+		dataModelDiff.TargetDefinition = target.Definition
+		changed = true
+	}
+
+	targetFields := map[string]DBDataModelField{}
+	for _, field := range target.Fields {
+		targetFields[field.Name] = field
+	}
+	sourceFields := map[string]bool{}
+	for _, field := range source.Fields {
+		sourceFields[field.Name] = true
+		if targetField, exists := targetFields[field.Name]; !exists {
+			dataModelDiff.AddedFields = append(dataModelDiff.AddedFields, field)
+		} else if !reflect.DeepEqual(field, targetField) {
+			dataModelDiff.ChangedFields = append(dataModelDiff.ChangedFields, FieldChange{Source: field, Target: targetField})
+		}
+	}
+	for _, field := range target.Fields {
+		if !sourceFields[field.Name] {
+			dataModelDiff.RemovedFields = append(dataModelDiff.RemovedFields, field)
+		}
+	}
+
+	targetIndexes := map[string]DBDataModelIndex{}
+	for _, index := range target.Indexes {
+		targetIndexes[index.Name] = index
+	}
+	sourceIndexes := map[string]bool{}
+	for _, index := range source.Indexes {
+		sourceIndexes[index.Name] = true
+		if targetIndex, exists := targetIndexes[index.Name]; !exists {
+			dataModelDiff.AddedIndexes = append(dataModelDiff.AddedIndexes, index)
+		} else if index != targetIndex {
+			dataModelDiff.ChangedIndexes = append(dataModelDiff.ChangedIndexes, IndexChange{Source: index, Target: targetIndex})
+		}
+	}
+	for _, index := range target.Indexes {
+		if !sourceIndexes[index.Name] {
+			dataModelDiff.RemovedIndexes = append(dataModelDiff.RemovedIndexes, index)
+		}
+	}
+
+	targetConstraints := map[string]DBDataModelConstraint{}
+	for _, constraint := range target.Constraints {
+		targetConstraints[constraint.Name] = constraint
+	}
+	sourceConstraints := map[string]bool{}
+	for _, constraint := range source.Constraints {
+		sourceConstraints[constraint.Name] = true
+		if targetConstraint, exists := targetConstraints[constraint.Name]; !exists {
+			dataModelDiff.AddedConstraints = append(dataModelDiff.AddedConstraints, constraint)
+		} else if constraint != targetConstraint {
+			dataModelDiff.ChangedConstraints = append(dataModelDiff.ChangedConstraints, ConstraintChange{Source: constraint, Target: targetConstraint})
+		}
+	}
+	for _, constraint := range target.Constraints {
+		if !sourceConstraints[constraint.Name] {
+			dataModelDiff.RemovedConstraints = append(dataModelDiff.RemovedConstraints, constraint)
+		}
+	}
+
+	// foreign keys do not have names in some engines, like sqlite, they are compared by their columns then
+	foreignKeyKey := func(foreignKey DBDataModelForeignKey) string {
+		if foreignKey.Name != "" {
+			return foreignKey.Name
+		}
+		key := ""
+		for _, column := range foreignKey.Columns {
+			key += column + "\x00"
+		}
+		return key
+	}
+	targetForeignKeys := map[string]DBDataModelForeignKey{}
+	for _, foreignKey := range target.ForeignKeys {
+		targetForeignKeys[foreignKeyKey(foreignKey)] = foreignKey
+	}
+	sourceForeignKeys := map[string]bool{}
+	for _, foreignKey := range source.ForeignKeys {
+		sourceForeignKeys[foreignKeyKey(foreignKey)] = true
+		if targetForeignKey, exists := targetForeignKeys[foreignKeyKey(foreignKey)]; !exists {
+			dataModelDiff.AddedForeignKeys = append(dataModelDiff.AddedForeignKeys, foreignKey)
+		} else if !reflect.DeepEqual(foreignKey, targetForeignKey) {
+			dataModelDiff.ChangedForeignKeys = append(dataModelDiff.ChangedForeignKeys, ForeignKeyChange{Source: foreignKey, Target: targetForeignKey})
+		}
+	}
+	for _, foreignKey := range target.ForeignKeys {
+		if !sourceForeignKeys[foreignKeyKey(foreignKey)] {
+			dataModelDiff.RemovedForeignKeys = append(dataModelDiff.RemovedForeignKeys, foreignKey)
+		}
+	}
+
+	changed = changed || len(dataModelDiff.AddedFields) > 0 || len(dataModelDiff.RemovedFields) > 0 || len(dataModelDiff.ChangedFields) > 0 ||
+		len(dataModelDiff.AddedIndexes) > 0 || len(dataModelDiff.RemovedIndexes) > 0 || len(dataModelDiff.ChangedIndexes) > 0 ||
+		len(dataModelDiff.AddedConstraints) > 0 || len(dataModelDiff.RemovedConstraints) > 0 || len(dataModelDiff.ChangedConstraints) > 0 ||
+		len(dataModelDiff.AddedForeignKeys) > 0 || len(dataModelDiff.RemovedForeignKeys) > 0 || len(dataModelDiff.ChangedForeignKeys) > 0
+	if !changed {
+		return nil
+	}
+	return &dataModelDiff
+}
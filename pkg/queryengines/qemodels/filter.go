@@ -0,0 +1,136 @@
+package qemodels
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	FILTER_GROUP_AND = "AND"
+	FILTER_GROUP_OR  = "OR"
+)
+
+const (
+	FILTER_OP_EQUAL         = "="
+	FILTER_OP_NOT_EQUAL     = "!="
+	FILTER_OP_LESS          = "<"
+	FILTER_OP_GREATER       = ">"
+	FILTER_OP_LESS_EQUAL    = "<="
+	FILTER_OP_GREATER_EQUAL = ">="
+	FILTER_OP_IN            = "IN"
+	FILTER_OP_NOT_IN        = "NOT IN"
+	FILTER_OP_LIKE          = "LIKE"
+	FILTER_OP_NOT_LIKE      = "NOT LIKE"
+	FILTER_OP_ILIKE         = "ILIKE"
+	FILTER_OP_NOT_ILIKE     = "NOT ILIKE"
+	FILTER_OP_IS_NULL       = "IS NULL"
+	FILTER_OP_IS_NOT_NULL   = "IS NOT NULL"
+	FILTER_OP_BETWEEN       = "BETWEEN"
+	FILTER_OP_REGEX         = "REGEX"
+)
+
+// Filter is an engine neutral filter tree used by GetData.
+// A node is either a group of conditions joined with AND/OR,
+// or a condition on a single field.
+type Filter struct {
+	Group      string        `json:"group,omitempty"`
+	Conditions []*Filter     `json:"conditions,omitempty"`
+	Field      string        `json:"field,omitempty"`
+	Operator   string        `json:"operator,omitempty"`
+	Value      interface{}   `json:"value,omitempty"`
+	Values     []interface{} `json:"values,omitempty"` // used by IN, NOT IN and BETWEEN
+}
+
+type SortField struct {
+	Field     string `json:"field"`
+	Direction string `json:"direction"`
+}
+
+// IsGroup reports if the filter is a group of conditions.
+func (f *Filter) IsGroup() bool {
+	return f.Group != ""
+}
+
+// Validate checks the filter tree and normalises groups and operators to upper case.
+func (f *Filter) Validate() error {
+	if f.IsGroup() {
+		f.Group = strings.ToUpper(f.Group)
+		if f.Group != FILTER_GROUP_AND && f.Group != FILTER_GROUP_OR {
+			return errors.New("invalid filter group")
+		}
+		if len(f.Conditions) == 0 {
+			return errors.New("filter group has no conditions")
+		}
+		for _, condition := range f.Conditions {
+			if condition == nil {
+				return errors.New("invalid filter condition")
+			}
+			if err := condition.Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if f.Field == "" {
+		return errors.New("filter field is required")
+	}
+	f.Operator = strings.ToUpper(f.Operator)
+	switch f.Operator {
+	case FILTER_OP_EQUAL, FILTER_OP_NOT_EQUAL, FILTER_OP_LESS, FILTER_OP_GREATER, FILTER_OP_LESS_EQUAL, FILTER_OP_GREATER_EQUAL:
+		if f.Value == nil {
+			return errors.New("filter value is required for " + f.Operator)
+		}
+	case FILTER_OP_LIKE, FILTER_OP_NOT_LIKE, FILTER_OP_ILIKE, FILTER_OP_NOT_ILIKE, FILTER_OP_REGEX:
+		if _, ok := f.Value.(string); !ok {
+			return errors.New("filter value must be a string for " + f.Operator)
+		}
+	case FILTER_OP_IN, FILTER_OP_NOT_IN:
+		if len(f.Values) == 0 {
+			return errors.New("filter values are required for " + f.Operator)
+		}
+	case FILTER_OP_BETWEEN:
+		if len(f.Values) != 2 {
+			return errors.New("filter requires two values for " + f.Operator)
+		}
+	case FILTER_OP_IS_NULL, FILTER_OP_IS_NOT_NULL:
+	default:
+		return errors.New("invalid filter operator")
+	}
+	return nil
+}
+
+// Validate checks the sort direction and normalises it to upper case.
+func (s *SortField) Validate() error {
+	if s.Field == "" {
+		return errors.New("sort field is required")
+	}
+	s.Direction = strings.ToUpper(s.Direction)
+	if s.Direction == "" {
+		s.Direction = "ASC"
+	}
+	if s.Direction != "ASC" && s.Direction != "DESC" {
+		return errors.New("invalid sort direction")
+	}
+	return nil
+}
+
+// LikeToRegex converts a sql LIKE pattern to an anchored regular expression.
+func LikeToRegex(pattern string) string {
+	regex := strings.Builder{}
+	regex.WriteString("^")
+	for _, char := range pattern {
+		switch char {
+		case '%':
+			regex.WriteString(".*")
+		case '_':
+			regex.WriteString(".")
+		case '\\', '.', '+', '*', '?', '(', ')', '|', '[', ']', '{', '}', '^', '$':
+			regex.WriteRune('\\')
+			regex.WriteRune(char)
+		default:
+			regex.WriteRune(char)
+		}
+	}
+	regex.WriteString("$")
+	return regex.String()
+}
@@ -0,0 +1,42 @@
+package qemodels
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestLikeToRegex(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		expected  string
+		matches   []string
+		unmatches []string
+	}{
+		{"a%", `^a.*$`, []string{"a", "abc"}, []string{"ba"}},
+		{"_b", `^.b$`, []string{"ab", "bb"}, []string{"b", "abb"}},
+		{"a.c", `^a\.c$`, []string{"a.c"}, []string{"abc"}},
+		{"1+1*2?", `^1\+1\*2\?$`, []string{"1+1*2?"}, []string{"11112"}},
+		{"(a|b)", `^\(a\|b\)$`, []string{"(a|b)"}, []string{"a", "b"}},
+		{"[a-z]{2}", `^\[a-z\]\{2\}$`, []string{"[a-z]{2}"}, []string{"ab"}},
+		{"^$%", `^\^\$.*$`, []string{"^$", "^$a"}, []string{""}},
+		{`a\b`, `^a\\b$`, []string{`a\b`}, []string{"ab", "a\b"}},
+	}
+	for _, test := range tests {
+		regex := LikeToRegex(test.pattern)
+		if regex != test.expected {
+			t.Errorf("regex of %s: %s, expected %s", test.pattern, regex, test.expected)
+			continue
+		}
+		compiled := regexp.MustCompile(regex)
+		for _, value := range test.matches {
+			if !compiled.MatchString(value) {
+				t.Errorf("%s does not match %s", test.pattern, value)
+			}
+		}
+		for _, value := range test.unmatches {
+			if compiled.MatchString(value) {
+				t.Errorf("%s matches %s", test.pattern, value)
+			}
+		}
+	}
+}
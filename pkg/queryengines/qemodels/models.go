@@ -0,0 +1,152 @@
+package qemodels
+
+const (
+	DATA_MODEL_KIND_TABLE             = "TABLE"
+	DATA_MODEL_KIND_VIEW              = "VIEW"
+	DATA_MODEL_KIND_MATERIALIZED_VIEW = "MATERIALIZED_VIEW"
+	DATA_MODEL_KIND_FOREIGN_TABLE     = "FOREIGN_TABLE"
+	DATA_MODEL_KIND_COLLECTION        = "COLLECTION"
+	DATA_MODEL_KIND_KEYSPACE          = "KEYSPACE"
+)
+
+// DBDataModel is a table like object of the database which has data. Definition is the statement
+// creating it for the kinds defined by a query, like views, and is only set for a single data model.
+// Constraints and the dependencies of views are only set by the engines which read them, for the schema diff.
+type DBDataModel struct {
+	Name         string                  `json:"name"`
+	SchemaName   string                  `json:"schemaName"`
+	Kind         string                  `json:"kind"`
+	Definition   string                  `json:"definition,omitempty"`
+	Fields       []DBDataModelField      `json:"fields"`
+	Indexes      []DBDataModelIndex      `json:"indexes"`
+	ForeignKeys  []DBDataModelForeignKey `json:"foreignKeys"`
+	Constraints  []DBDataModelConstraint `json:"constraints,omitempty"`
+	Dependencies []DBDataModelDependency `json:"dependencies,omitempty"`
+}
+
+// DBDataModelDependency is a column of a data model which a view depends on,
+// Column is empty when the view depends on the whole data model.
+type DBDataModelDependency struct {
+	SchemaName string `json:"schemaName"`
+	Name       string `json:"name"`
+	Column     string `json:"column,omitempty"`
+}
+
+// DBDataModelField is a field of a data model, Default is the expression of its default value if it has one.
+type DBDataModelField struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	IsPrimary  bool     `json:"isPrimary"`
+	IsNullable bool     `json:"isNullable"`
+	Default    *string  `json:"default,omitempty"`
+	Tags       []string `json:"tags"`
+}
+
+type DBDataModelIndex struct {
+	Name     string `json:"name"`
+	IndexDef string `json:"indexDef"`
+}
+
+// FieldAlteration is a change of a field of a data model, the nil or empty parts are not changed.
+// Using is the expression converting the values to the new type, the field is cast by default.
+type FieldAlteration struct {
+	NewName     string  `json:"newName"`
+	Type        string  `json:"type"`
+	Using       string  `json:"using"`
+	Default     *string `json:"default"`
+	DropDefault bool    `json:"dropDefault"`
+	IsNullable  *bool   `json:"isNullable"`
+}
+
+// IndexSpec is an index to create on a data model. Method, Where and Concurrently are used by postgres,
+// Where is the predicate of a partial index, a SQL condition for postgres and a JSON filter for mongo.
+// ExpireAfterSeconds makes a TTL index in mongo.
+type IndexSpec struct {
+	Name               string       `json:"name"`
+	Fields             []IndexField `json:"fields"`
+	Unique             bool         `json:"unique"`
+	Method             string       `json:"method"`
+	Where              string       `json:"where"`
+	Concurrently       bool         `json:"concurrently"`
+	ExpireAfterSeconds *int32       `json:"expireAfterSeconds"`
+}
+
+// IndexField is a field of an index in key order. Type is the operator class of the field for postgres
+// and the type of index for mongo, like text, hashed or 2dsphere, instead of ascending or descending.
+type IndexField struct {
+	Name       string `json:"name"`
+	Descending bool   `json:"descending"`
+	Type       string `json:"type"`
+}
+
+// DBDataModelConstraint is a constraint of a data model other than a foreign key or not null,
+// Type is like PRIMARY KEY or CHECK and Definition is the constraint as written in a CREATE TABLE.
+type DBDataModelConstraint struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Definition string `json:"definition"`
+}
+
+// DBDataModelForeignKey is a foreign key of a data model, its Columns reference the ReferencedColumns
+// of the referenced data model in order. ReferencedColumns is empty when the engine does not know them,
+// the primary key is referenced then. OnDelete and OnUpdate are the actions, like CASCADE or NO ACTION.
+type DBDataModelForeignKey struct {
+	Name                 string   `json:"name"`
+	Columns              []string `json:"columns"`
+	ReferencedSchemaName string   `json:"referencedSchemaName"`
+	ReferencedName       string   `json:"referencedName"`
+	ReferencedColumns    []string `json:"referencedColumns"`
+	OnDelete             string   `json:"onDelete"`
+	OnUpdate             string   `json:"onUpdate"`
+}
+
+// DBGraph is the schema of a database as a graph to draw ER diagrams, the data models are the nodes
+// and the relationships, one per foreign key, are the edges between them.
+type DBGraph struct {
+	DataModels    []*DBDataModel        `json:"dataModels"`
+	Relationships []DBGraphRelationship `json:"relationships"`
+}
+
+// DBGraphRelationship is a foreign key of the data model with SchemaName and Name.
+type DBGraphRelationship struct {
+	SchemaName string                `json:"schemaName"`
+	Name       string                `json:"name"`
+	ForeignKey DBDataModelForeignKey `json:"foreignKey"`
+}
+
+// NewDBGraph returns the graph of the data models, with a relationship for each of their foreign keys.
+func NewDBGraph(dataModels []*DBDataModel) *DBGraph {
+	graph := DBGraph{
+		DataModels:    dataModels,
+		Relationships: []DBGraphRelationship{},
+	}
+	for _, dataModel := range dataModels {
+		for _, foreignKey := range dataModel.ForeignKeys {
+			graph.Relationships = append(graph.Relationships, DBGraphRelationship{
+				SchemaName: dataModel.SchemaName,
+				Name:       dataModel.Name,
+				ForeignKey: foreignKey,
+			})
+		}
+	}
+	return &graph
+}
+
+const (
+	DB_OBJECT_KIND_FUNCTION  = "FUNCTION"
+	DB_OBJECT_KIND_PROCEDURE = "PROCEDURE"
+	DB_OBJECT_KIND_SEQUENCE  = "SEQUENCE"
+	DB_OBJECT_KIND_ENUM      = "ENUM"
+	DB_OBJECT_KIND_TRIGGER   = "TRIGGER"
+	DB_OBJECT_KIND_EXTENSION = "EXTENSION"
+)
+
+// DBObject is an object of the database which is not a data model, like a function or a sequence.
+// Details depend on the kind and Definition is only set for a single object.
+type DBObject struct {
+	Kind       string                 `json:"kind"`
+	SchemaName string                 `json:"schemaName"`
+	Name       string                 `json:"name"`
+	Details    map[string]interface{} `json:"details"`
+	Definition string                 `json:"definition,omitempty"`
+}
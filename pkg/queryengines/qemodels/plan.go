@@ -0,0 +1,26 @@
+package qemodels
+
+// QueryPlan is the plan of a query returned by ExplainQuery. Raw is the plan as returned by the database.
+type QueryPlan struct {
+	Plan            *PlanNode   `json:"plan"`
+	Analyzed        bool        `json:"analyzed"`
+	PlanningTimeMs  *float64    `json:"planningTimeMs,omitempty"`
+	ExecutionTimeMs *float64    `json:"executionTimeMs,omitempty"`
+	Raw             interface{} `json:"raw"`
+}
+
+// PlanNode is a node of a query plan normalised across engines. Costs and estimated rows come from the planner,
+// actual rows and time are only set when the query was analyzed and are totals over all the loops of the node.
+type PlanNode struct {
+	NodeType      string                 `json:"nodeType"`
+	Relation      string                 `json:"relation,omitempty"`
+	Index         string                 `json:"index,omitempty"`
+	StartupCost   *float64               `json:"startupCost,omitempty"`
+	TotalCost     *float64               `json:"totalCost,omitempty"`
+	EstimatedRows *float64               `json:"estimatedRows,omitempty"`
+	ActualRows    *float64               `json:"actualRows,omitempty"`
+	ActualTimeMs  *float64               `json:"actualTimeMs,omitempty"`
+	Loops         *float64               `json:"loops,omitempty"`
+	Details       map[string]interface{} `json:"details,omitempty"`
+	Children      []*PlanNode            `json:"children"`
+}
@@ -0,0 +1,26 @@
+package qemodels
+
+type AddDataResponse struct {
+	NewID string `json:"newId"`
+}
+
+// ColumnMetadata describes a column of the result of a query. Nullable and the source
+// schema, table and column are only set when they are known, for columns read from a table.
+// An editable column can be updated with UpdateSingleData on its table, using the row id built
+// from the values of the RowIDColumns of the row, which are indexes of columns of the result.
+type ColumnMetadata struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	TypeOID      uint32 `json:"typeOid,omitempty"`
+	Nullable     *bool  `json:"nullable,omitempty"`
+	Schema       string `json:"schema,omitempty"`
+	Table        string `json:"table,omitempty"`
+	Column       string `json:"column,omitempty"`
+	Editable     bool   `json:"editable"`
+	RowIDColumns []int  `json:"rowIdColumns,omitempty"`
+
+	// TypeModifier, TableOID and ColumnNumber are set by the postgres engine to resolve the metadata.
+	TypeModifier int32  `json:"-"`
+	TableOID     uint32 `json:"-"`
+	ColumnNumber uint16 `json:"-"`
+}
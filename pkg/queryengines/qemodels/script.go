@@ -0,0 +1,15 @@
+package qemodels
+
+// StatementResult is the result of a single statement of a script: the columns and rows
+// if the statement returns rows, and the command tag as message.
+type StatementResult struct {
+	Query         string                   `json:"query"`
+	Position      int                      `json:"position"`
+	Columns       []string                 `json:"columns,omitempty"`
+	Rows          []map[string]interface{} `json:"rows"`
+	Truncated     bool                     `json:"truncated,omitempty"` // rows were cut to the max rows of the role
+	Message       string                   `json:"message,omitempty"`
+	DurationMs    float64                  `json:"durationMs"`
+	Error         string                   `json:"error,omitempty"`
+	ErrorPosition int                      `json:"errorPosition,omitempty"` // 1-based character position in the script
+}
@@ -0,0 +1,8 @@
+package qemodels
+
+// ResultStream receives the rows of a query while they are read from the database.
+// An error returned by a write stops reading and aborts the query.
+type ResultStream interface {
+	WriteColumns(columns []string) error
+	WriteRow(row map[string]interface{}) error
+}
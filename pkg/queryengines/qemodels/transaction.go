@@ -0,0 +1,6 @@
+package qemodels
+
+import "time"
+
+// TRANSACTION_IDLE_TIMEOUT is the time after which an unused transaction is rolled back.
+const TRANSACTION_IDLE_TIMEOUT = 5 * time.Minute
@@ -1,8 +1,23 @@
 package queryconfig
 
+import (
+	"context"
+	"time"
+)
+
 type QueryConfig struct {
 	ReadOnly    bool
 	CreateLogFn func(string)
+	// Context of the request running the query, the query is cancelled when it is done.
+	Context context.Context
+	// QueryID identifies an in-flight query so that it can be cancelled, empty if not cancellable.
+	QueryID string
+	// MaxExecutionTime is the time after which a query is cancelled, 0 for no limit.
+	MaxExecutionTime time.Duration
+	// MaxRows is the number of rows after which the result of a query is truncated, 0 for no limit.
+	MaxRows int
+	// UserID is the user running the query, a transaction can only be used by the user who began it.
+	UserID string
 }
 
 func NewQueryConfig(readOnly bool, createLogFn func(string)) *QueryConfig {
@@ -11,3 +26,20 @@ func NewQueryConfig(readOnly bool, createLogFn func(string)) *QueryConfig {
 		CreateLogFn: createLogFn,
 	}
 }
+
+// GetContext returns the context of the query, background if none was set.
+func (config *QueryConfig) GetContext() context.Context {
+	if config.Context == nil {
+		return context.Background()
+	}
+	return config.Context
+}
+
+// GetContextWithTimeout returns the context of the query which is done after MaxExecutionTime,
+// for engines which cannot enforce the limit on the server.
+func (config *QueryConfig) GetContextWithTimeout() (context.Context, context.CancelFunc) {
+	if config.MaxExecutionTime <= 0 {
+		return context.WithCancel(config.GetContext())
+	}
+	return context.WithTimeout(config.GetContext(), config.MaxExecutionTime)
+}
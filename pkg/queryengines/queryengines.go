@@ -3,192 +3,672 @@ package queryengines
 import (
 	"errors"
 
+	"slashbase.com/backend/internal/config"
 	"slashbase.com/backend/internal/models"
 	"slashbase.com/backend/pkg/queryengines/mongoqueryengine"
+	"slashbase.com/backend/pkg/queryengines/mysqlqueryengine"
 	"slashbase.com/backend/pkg/queryengines/pgqueryengine"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
 	"slashbase.com/backend/pkg/queryengines/queryconfig"
+	"slashbase.com/backend/pkg/queryengines/redisqueryengine"
+	"slashbase.com/backend/pkg/queryengines/sqlitequeryengine"
 )
 
-var postgresQueryEngine *pgqueryengine.PostgresQueryEngine
-var mongoQueryEngine *mongoqueryengine.MongoQueryEngine
+// QueryEngine is implemented by every database engine supported by slashbase.
+// schema is ignored by engines which do not have schemas, like mongo.
+type QueryEngine interface {
+	RunQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	TestConnection(dbConn *models.DBConnection, config *queryconfig.QueryConfig) bool
+	GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBDataModel, error)
+	GetSingleDataModel(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (*qemodels.DBDataModel, error)
+	AddSingleDataModelField(dbConn *models.DBConnection, schema string, name string, fieldName, dataType string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	DeleteSingleDataModelField(dbConn *models.DBConnection, schema string, name string, fieldName string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	GetData(dbConn *models.DBConnection, schema string, name string, limit int, offset int64, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	UpdateSingleData(dbConn *models.DBConnection, schema string, name string, id string, columnName string, value string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	AddData(dbConn *models.DBConnection, schema string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (*qemodels.AddDataResponse, error)
+	DeleteData(dbConn *models.DBConnection, schema string, name string, ids []string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	RemoveUnusedConnections()
+}
+
+// KeyValueQueryEngine is implemented by the query engines of key value stores, like redis.
+type KeyValueQueryEngine interface {
+	GetKeyValue(dbConn *models.DBConnection, key string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+}
+
+// KeysetQueryEngine is implemented by the query engines which can page through data with a keyset cursor.
+type KeysetQueryEngine interface {
+	GetDataByCursor(dbConn *models.DBConnection, schema string, name string, limit int, cursor string, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+}
+
+// ScriptQueryEngine is implemented by the query engines which can run scripts of multiple statements.
+type ScriptQueryEngine interface {
+	RunScript(dbConn *models.DBConnection, script string, inTransaction bool, config *queryconfig.QueryConfig) ([]*qemodels.StatementResult, error)
+}
+
+// TransactionQueryEngine is implemented by the query engines which can keep a transaction open across requests.
+// Transactions are rolled back after being idle for qemodels.TRANSACTION_IDLE_TIMEOUT.
+type TransactionQueryEngine interface {
+	BeginTransaction(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (string, error)
+	RunTransactionQuery(dbConn *models.DBConnection, transactionID string, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	CommitTransaction(dbConn *models.DBConnection, transactionID string, config *queryconfig.QueryConfig) error
+	RollbackTransaction(dbConn *models.DBConnection, transactionID string, config *queryconfig.QueryConfig) error
+}
+
+// CancellableQueryEngine is implemented by the query engines which can cancel a query run by RunQuery
+// while it is in flight, the query is identified by the QueryID of its config.
+type CancellableQueryEngine interface {
+	CancelQuery(dbConn *models.DBConnection, queryID string, config *queryconfig.QueryConfig) error
+}
+
+// StreamQueryEngine is implemented by the query engines which can write the rows of a query
+// to a stream while reading them, instead of buffering the whole result.
+type StreamQueryEngine interface {
+	StreamQuery(dbConn *models.DBConnection, query string, stream qemodels.ResultStream, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+}
+
+// ExplainQueryEngine is implemented by the query engines which can return the plan of a query.
+type ExplainQueryEngine interface {
+	ExplainQuery(dbConn *models.DBConnection, query string, analyze bool, config *queryconfig.QueryConfig) (*qemodels.QueryPlan, error)
+}
+
+// GraphQueryEngine is implemented by the query engines which can read the graph of the data models
+// and their foreign keys faster than reading each data model.
+type GraphQueryEngine interface {
+	GetDBGraph(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (*qemodels.DBGraph, error)
+}
+
+// DataModelQueryEngine is implemented by the query engines which can create, rename, truncate and drop data models.
+type DataModelQueryEngine interface {
+	CreateDataModel(dbConn *models.DBConnection, schema string, spec *qemodels.DataModelSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	RenameDataModel(dbConn *models.DBConnection, schema, name, newName string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	TruncateDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	DropDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+}
+
+// AlterFieldQueryEngine is implemented by the query engines which can alter the fields of data models.
+type AlterFieldQueryEngine interface {
+	AlterSingleDataModelField(dbConn *models.DBConnection, schema, name, fieldName string, alteration *qemodels.FieldAlteration, preview bool, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+}
+
+// IndexQueryEngine is implemented by the query engines which can create and drop the indexes of data models.
+type IndexQueryEngine interface {
+	CreateIndex(dbConn *models.DBConnection, schema, name string, index *qemodels.IndexSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+	DropIndex(dbConn *models.DBConnection, schema, name, indexName string, concurrently bool, config *queryconfig.QueryConfig) (map[string]interface{}, error)
+}
+
+// DDLQueryEngine is implemented by the query engines which can generate the script creating a data model.
+type DDLQueryEngine interface {
+	GetSingleDataModelDDL(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (string, error)
+}
+
+// SchemaQueryEngine is implemented by the query engines which can read the data models of the database
+// with their fields, indexes, constraints and foreign keys faster than reading each data model.
+type SchemaQueryEngine interface {
+	GetDBSchema(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBDataModel, error)
+}
+
+// MigrationQueryEngine is implemented by the query engines which can generate the script applying a schema diff.
+type MigrationQueryEngine interface {
+	GetMigrationScript(diff *qemodels.SchemaDiff) string
+}
+
+// DBObjectQueryEngine is implemented by the query engines which can browse the objects of the database
+// which are not data models, like functions, sequences and triggers.
+type DBObjectQueryEngine interface {
+	GetDBObjects(dbConn *models.DBConnection, kind string, config *queryconfig.QueryConfig) ([]*qemodels.DBObject, error)
+	GetSingleDBObject(dbConn *models.DBConnection, kind, schema, name string, config *queryconfig.QueryConfig) (*qemodels.DBObject, error)
+}
+
+// ActivityQueryEngine is implemented by the query engines which can list the sessions connected to the
+// database server, cancel their queries and terminate them.
+type ActivityQueryEngine interface {
+	GetDBSessions(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBSession, error)
+	CancelDBSession(dbConn *models.DBConnection, pid int32, terminate bool, config *queryconfig.QueryConfig) error
+}
+
+// LockQueryEngine is implemented by the query engines which can list the sessions blocking each other with their locks.
+type LockQueryEngine interface {
+	GetDBLocks(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (*qemodels.DBLocks, error)
+}
+
+var queryEngines = map[string]QueryEngine{}
 
 func Init() {
-	postgresQueryEngine = pgqueryengine.InitPostgresQueryEngine()
-	mongoQueryEngine = mongoqueryengine.InitMongoQueryEngine()
+	Register(models.DBTYPE_POSTGRES, models.DBTypeInfo{Schemes: []string{"postgres"}}, pgqueryengine.InitPostgresQueryEngine())
+	Register(models.DBTYPE_MONGO, models.DBTypeInfo{Schemes: []string{"mongodb", "mongodb+srv"}}, mongoqueryengine.InitMongoQueryEngine())
+	Register(models.DBTYPE_MYSQL, models.DBTypeInfo{Schemes: []string{"mysql"}}, mysqlqueryengine.InitMysqlQueryEngine())
+	Register(models.DBTYPE_SQLITE, models.DBTypeInfo{Schemes: []string{"sqlite"}, IsFile: true}, sqlitequeryengine.InitSQLiteQueryEngine(config.GetConfig().SQLiteFilesDir, config.APP_DATABASE_FILE))
+	Register(models.DBTYPE_REDIS, models.DBTypeInfo{Schemes: []string{"redis", "rediss"}}, redisqueryengine.InitRedisQueryEngine())
+}
+
+// Register makes a query engine available for the db connections of type dbType.
+func Register(dbType string, info models.DBTypeInfo, engine QueryEngine) {
+	queryEngines[dbType] = engine
+	models.RegisterDBType(dbType, info)
+}
+
+func getQueryEngine(dbConn *models.DBConnection) (QueryEngine, error) {
+	engine, exists := queryEngines[dbConn.Type]
+	if !exists {
+		return nil, errors.New("invalid db type")
+	}
+	return engine, nil
 }
 
 func RunQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		return postgresQueryEngine.RunQuery(dbConn, query, config)
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		return mongoQueryEngine.RunQuery(dbConn, query, config)
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	return engine.RunQuery(dbConn, query, config)
+}
+
+// StreamQuery function to run a query writing its rows to the stream as they are read,
+// it returns the rest of the result, like the row count or the message of queries which do not return rows.
+func StreamQuery(dbConn *models.DBConnection, query string, stream ResultStream, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	streamEngine, ok := engine.(StreamQueryEngine)
+	if !ok {
+		return nil, errors.New("streaming is not supported for db type")
 	}
-	return nil, errors.New("invalid db type")
+	return streamEngine.StreamQuery(dbConn, query, stream, config)
+}
+
+// CancelQuery function to cancel the in-flight query started by RunQuery with the query id.
+func CancelQuery(dbConn *models.DBConnection, queryID string, config *queryconfig.QueryConfig) error {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return err
+	}
+	cancellableEngine, ok := engine.(CancellableQueryEngine)
+	if !ok {
+		return errors.New("cancelling queries is not supported for db type")
+	}
+	return cancellableEngine.CancelQuery(dbConn, queryID, config)
+}
+
+// ExplainQuery function to get the plan of a query, analyze runs the query to get the actual rows and timings.
+func ExplainQuery(dbConn *models.DBConnection, query string, analyze bool, config *queryconfig.QueryConfig) (*QueryPlan, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	explainEngine, ok := engine.(ExplainQueryEngine)
+	if !ok {
+		return nil, errors.New("explain is not supported for db type")
+	}
+	return explainEngine.ExplainQuery(dbConn, query, analyze, withoutMaxRows(config))
+}
+
+// RunScript function to run the statements of a script in order, optionally in one transaction.
+func RunScript(dbConn *models.DBConnection, script string, inTransaction bool, config *queryconfig.QueryConfig) ([]*StatementResult, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	scriptEngine, ok := engine.(ScriptQueryEngine)
+	if !ok {
+		return nil, errors.New("scripts are not supported for db type")
+	}
+	return scriptEngine.RunScript(dbConn, script, inTransaction, config)
+}
+
+func getTransactionQueryEngine(dbConn *models.DBConnection) (TransactionQueryEngine, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	transactionEngine, ok := engine.(TransactionQueryEngine)
+	if !ok {
+		return nil, errors.New("transactions are not supported for db type")
+	}
+	return transactionEngine, nil
+}
+
+// BeginTransaction function to open a transaction which is kept open across requests, it returns the transaction id.
+func BeginTransaction(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (string, error) {
+	engine, err := getTransactionQueryEngine(dbConn)
+	if err != nil {
+		return "", err
+	}
+	return engine.BeginTransaction(dbConn, config)
+}
+
+func RunTransactionQuery(dbConn *models.DBConnection, transactionID string, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	engine, err := getTransactionQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	return engine.RunTransactionQuery(dbConn, transactionID, query, config)
+}
+
+func CommitTransaction(dbConn *models.DBConnection, transactionID string, config *queryconfig.QueryConfig) error {
+	engine, err := getTransactionQueryEngine(dbConn)
+	if err != nil {
+		return err
+	}
+	return engine.CommitTransaction(dbConn, transactionID, config)
+}
+
+func RollbackTransaction(dbConn *models.DBConnection, transactionID string, config *queryconfig.QueryConfig) error {
+	engine, err := getTransactionQueryEngine(dbConn)
+	if err != nil {
+		return err
+	}
+	return engine.RollbackTransaction(dbConn, transactionID, config)
 }
 
 func TestConnection(dbConn *models.DBConnection, config *queryconfig.QueryConfig) bool {
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		return postgresQueryEngine.TestConnection(dbConn, config)
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		return mongoQueryEngine.TestConnection(dbConn, config)
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return false
 	}
-	return false
+	return engine.TestConnection(dbConn, withoutMaxRows(config))
 }
 
 func GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*DBDataModel, error) {
-	var err error
-	var data []map[string]interface{}
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		data, err = postgresQueryEngine.GetDataModels(dbConn, config)
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		data, err = mongoQueryEngine.GetDataModels(dbConn, config)
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
 	}
+	return engine.GetDataModels(dbConn, withoutMaxRows(config))
+}
+
+func GetSingleDataModel(dbConn *models.DBConnection, schemaName string, name string, config *queryconfig.QueryConfig) (*DBDataModel, error) {
+	engine, err := getQueryEngine(dbConn)
 	if err != nil {
 		return nil, err
 	}
-	dataModels := []*DBDataModel{}
-	for _, table := range data {
-		view := BuildDBDataModel(dbConn, table)
-		if view != nil {
-			dataModels = append(dataModels, view)
-		}
+	return engine.GetSingleDataModel(dbConn, schemaName, name, withoutMaxRows(config))
+}
+
+// GetSingleDataModelDDL function to get the script creating a data model, to review it or to recreate it in another database.
+func GetSingleDataModelDDL(dbConn *models.DBConnection, schemaName string, name string, config *queryconfig.QueryConfig) (string, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return "", err
+	}
+	ddlEngine, ok := engine.(DDLQueryEngine)
+	if !ok {
+		return "", errors.New("generating DDL is not supported for db type")
 	}
-	return dataModels, nil
+	return ddlEngine.GetSingleDataModelDDL(dbConn, schemaName, name, withoutMaxRows(config))
 }
 
-func GetSingleDataModel(dbConn *models.DBConnection, schemaName string, name string, config *queryconfig.QueryConfig) (*DBDataModel, error) {
-	var dataModel DBDataModel
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		fieldsData, err := postgresQueryEngine.GetSingleDataModelFields(dbConn, schemaName, name, config)
-		if err != nil {
-			return nil, err
-		}
-		indexesData, err := postgresQueryEngine.GetSingleDataModelIndexes(dbConn, schemaName, name, config)
-		if err != nil {
-			return nil, err
-		}
-		allFields := []DBDataModelField{}
-		for _, field := range fieldsData {
-			fieldView := BuildDBDataModelField(dbConn, field)
-			if fieldView != nil {
-				allFields = append(allFields, *fieldView)
-			}
-		}
-		allIndexes := []DBDataModelIndex{}
-		for _, index := range indexesData {
-			indexView := BuildDBDataModelIndex(dbConn, index)
-			if indexView != nil {
-				allIndexes = append(allIndexes, *indexView)
-			}
-		}
-		dataModel = DBDataModel{
-			SchemaName: schemaName,
-			Name:       name,
-			Fields:     allFields,
-			Indexes:    allIndexes,
-		}
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		fieldsData, err := mongoQueryEngine.GetSingleDataModelFields(dbConn, name, config)
-		if err != nil {
-			return nil, err
+// GetDBGraph function to get the data models of the database with their fields and the relationships between them.
+func GetDBGraph(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (*DBGraph, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	config = withoutMaxRows(config)
+	if graphEngine, ok := engine.(GraphQueryEngine); ok {
+		return graphEngine.GetDBGraph(dbConn, config)
+	}
+	singleDataModels, err := getSingleDataModels(engine, dbConn, config)
+	if err != nil {
+		return nil, err
+	}
+	return qemodels.NewDBGraph(singleDataModels), nil
+}
+
+// DiffDBSchemas function to get the difference between the schemas of two databases of the same type, with the
+// script bringing the target in line with the source for the engines which can generate it.
+func DiffDBSchemas(sourceDBConn, targetDBConn *models.DBConnection, sourceConfig, targetConfig *queryconfig.QueryConfig) (*SchemaDiff, error) {
+	if sourceDBConn.Type != targetDBConn.Type {
+		return nil, errors.New("connections must be of the same db type")
+	}
+	engine, err := getQueryEngine(sourceDBConn)
+	if err != nil {
+		return nil, err
+	}
+	getDBSchema := func(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*DBDataModel, error) {
+		config = withoutMaxRows(config)
+		if schemaEngine, ok := engine.(SchemaQueryEngine); ok {
+			return schemaEngine.GetDBSchema(dbConn, config)
 		}
-		indexesData, err := mongoQueryEngine.GetSingleDataModelIndexes(dbConn, name, config)
+		return getSingleDataModels(engine, dbConn, config)
+	}
+	sourceDataModels, err := getDBSchema(sourceDBConn, sourceConfig)
+	if err != nil {
+		return nil, err
+	}
+	targetDataModels, err := getDBSchema(targetDBConn, targetConfig)
+	if err != nil {
+		return nil, err
+	}
+	diff := qemodels.DiffDataModels(sourceDataModels, targetDataModels)
+	if migrationEngine, ok := engine.(MigrationQueryEngine); ok {
+		diff.MigrationScript = migrationEngine.GetMigrationScript(diff)
+	}
+	return diff, nil
+}
+
+// getSingleDataModels returns all the data models of the database with their fields, indexes and foreign keys.
+func getSingleDataModels(engine QueryEngine, dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*DBDataModel, error) {
+	dataModels, err := engine.GetDataModels(dbConn, config)
+	if err != nil {
+		return nil, err
+	}
+	singleDataModels := []*DBDataModel{}
+	for _, dataModel := range dataModels {
+		singleDataModel, err := engine.GetSingleDataModel(dbConn, dataModel.SchemaName, dataModel.Name, config)
 		if err != nil {
 			return nil, err
 		}
-		allFields := []DBDataModelField{}
-		for _, field := range fieldsData {
-			fieldView := BuildDBDataModelField(dbConn, field)
-			if fieldView != nil {
-				allFields = append(allFields, *fieldView)
-			}
-		}
-		allIndexes := []DBDataModelIndex{}
-		for _, index := range indexesData {
-			indexView := BuildDBDataModelIndex(dbConn, index)
-			if indexView != nil {
-				allIndexes = append(allIndexes, *indexView)
-			}
-		}
-		dataModel = DBDataModel{
-			Name:    name,
-			Fields:  allFields,
-			Indexes: allIndexes,
-		}
+		singleDataModels = append(singleDataModels, singleDataModel)
+	}
+	return singleDataModels, nil
+}
+
+// GetDBObjects function to list the objects of a kind, like FUNCTION or SEQUENCE.
+func GetDBObjects(dbConn *models.DBConnection, kind string, config *queryconfig.QueryConfig) ([]*DBObject, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	dbObjectEngine, ok := engine.(DBObjectQueryEngine)
+	if !ok {
+		return nil, errors.New("browsing objects is not supported for db type")
+	}
+	return dbObjectEngine.GetDBObjects(dbConn, kind, withoutMaxRows(config))
+}
+
+// GetSingleDBObject function to get an object of a kind with its definition.
+func GetSingleDBObject(dbConn *models.DBConnection, kind, schemaName, name string, config *queryconfig.QueryConfig) (*DBObject, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	dbObjectEngine, ok := engine.(DBObjectQueryEngine)
+	if !ok {
+		return nil, errors.New("browsing objects is not supported for db type")
 	}
-	return &dataModel, nil
+	return dbObjectEngine.GetSingleDBObject(dbConn, kind, schemaName, name, withoutMaxRows(config))
 }
 
 func AddSingleDataModelField(dbConn *models.DBConnection, schemaName string, name string, fieldName, datatype string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		return postgresQueryEngine.AddSingleDataModelColumn(dbConn, schemaName, name, fieldName, datatype, config)
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		return mongoQueryEngine.AddSingleDataModelKey(dbConn, schemaName, name, fieldName, datatype)
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("invalid db type")
+	return engine.AddSingleDataModelField(dbConn, schemaName, name, fieldName, datatype, withoutMaxRows(config))
 }
 
 func DeleteSingleDataModelField(dbConn *models.DBConnection, schemaName string, name string, fieldName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		return postgresQueryEngine.DeleteSingleDataModelColumn(dbConn, schemaName, name, fieldName, config)
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		return mongoQueryEngine.DeleteSingleDataModelKey(dbConn, schemaName, name, fieldName, config)
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("invalid db type")
+	return engine.DeleteSingleDataModelField(dbConn, schemaName, name, fieldName, withoutMaxRows(config))
 }
 
-func GetData(dbConn *models.DBConnection, schemaName string, name string, limit int, offset int64, fetchCount bool, filter []string, sort []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		return postgresQueryEngine.GetData(dbConn, schemaName, name, limit, offset, fetchCount, filter, sort, config)
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		return mongoQueryEngine.GetData(dbConn, name, limit, offset, fetchCount, filter, sort, config)
+func getDataModelQueryEngine(dbConn *models.DBConnection) (DataModelQueryEngine, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	dataModelEngine, ok := engine.(DataModelQueryEngine)
+	if !ok {
+		return nil, errors.New("managing data models is not supported for db type")
+	}
+	return dataModelEngine, nil
+}
+
+func CreateDataModel(dbConn *models.DBConnection, schemaName string, spec *DataModelSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	dataModelEngine, err := getDataModelQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
 	}
-	return nil, errors.New("invalid db type")
+	return dataModelEngine.CreateDataModel(dbConn, schemaName, spec, withoutMaxRows(config))
+}
+
+func RenameDataModel(dbConn *models.DBConnection, schemaName string, name string, newName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	dataModelEngine, err := getDataModelQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	return dataModelEngine.RenameDataModel(dbConn, schemaName, name, newName, withoutMaxRows(config))
+}
+
+func TruncateDataModel(dbConn *models.DBConnection, schemaName string, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	dataModelEngine, err := getDataModelQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	return dataModelEngine.TruncateDataModel(dbConn, schemaName, name, withoutMaxRows(config))
+}
+
+func DropDataModel(dbConn *models.DBConnection, schemaName string, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	dataModelEngine, err := getDataModelQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	return dataModelEngine.DropDataModel(dbConn, schemaName, name, withoutMaxRows(config))
+}
+
+// AlterSingleDataModelField function to alter a field of a data model, with preview the query altering it is only returned.
+func AlterSingleDataModelField(dbConn *models.DBConnection, schemaName string, name string, fieldName string, alteration *FieldAlteration, preview bool, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	alterFieldEngine, ok := engine.(AlterFieldQueryEngine)
+	if !ok {
+		return nil, errors.New("altering fields is not supported for db type")
+	}
+	return alterFieldEngine.AlterSingleDataModelField(dbConn, schemaName, name, fieldName, alteration, preview, withoutMaxRows(config))
+}
+
+func CreateIndex(dbConn *models.DBConnection, schemaName string, name string, index *IndexSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	indexEngine, ok := engine.(IndexQueryEngine)
+	if !ok {
+		return nil, errors.New("managing indexes is not supported for db type")
+	}
+	return indexEngine.CreateIndex(dbConn, schemaName, name, index, withoutMaxRows(config))
+}
+
+func DropIndex(dbConn *models.DBConnection, schemaName string, name string, indexName string, concurrently bool, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	indexEngine, ok := engine.(IndexQueryEngine)
+	if !ok {
+		return nil, errors.New("managing indexes is not supported for db type")
+	}
+	return indexEngine.DropIndex(dbConn, schemaName, name, indexName, concurrently, withoutMaxRows(config))
+}
+
+// GetData function to get the rows of a table or documents of a collection,
+// filter is optional and sort can be empty.
+func GetData(dbConn *models.DBConnection, schemaName string, name string, limit int, offset int64, fetchCount bool, filter *Filter, sort []SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateFilterAndSort(filter, sort); err != nil {
+		return nil, err
+	}
+	limit, capped := limitToMaxRows(limit, config)
+	fetchLimit := limit
+	// key value engines page by a scan cursor, a page cannot be trimmed without skipping keys
+	_, isKeyValue := engine.(KeyValueQueryEngine)
+	if capped && !isKeyValue {
+		// one more row tells if the rows were truncated
+		fetchLimit++
+	}
+	data, err := engine.GetData(dbConn, schemaName, name, fetchLimit, offset, fetchCount, filter, sort, withoutMaxRows(config))
+	if err != nil {
+		return nil, err
+	}
+	data["truncated"] = capped && !isKeyValue && trimToLimit(data, limit)
+	data["limit"] = limit
+	return data, nil
+}
+
+// GetDataByCursor function to get the page of rows after the cursor, cursor is empty for the first page.
+// The cursor of the next page is returned as nextCursor.
+func GetDataByCursor(dbConn *models.DBConnection, schemaName string, name string, limit int, cursor string, fetchCount bool, filter *Filter, sort []SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	keysetEngine, ok := engine.(KeysetQueryEngine)
+	if !ok {
+		return nil, errors.New("cursor pagination is not supported for db type")
+	}
+	if err := validateFilterAndSort(filter, sort); err != nil {
+		return nil, err
+	}
+	limit, capped := limitToMaxRows(limit, config)
+	data, err := keysetEngine.GetDataByCursor(dbConn, schemaName, name, limit, cursor, fetchCount, filter, sort, withoutMaxRows(config))
+	if err != nil {
+		return nil, err
+	}
+	// the next cursor is only returned for a full page
+	data["truncated"] = capped && data["nextCursor"] != ""
+	data["limit"] = limit
+	return data, nil
+}
+
+func validateFilterAndSort(filter *Filter, sort []SortField) error {
+	if filter != nil {
+		if err := filter.Validate(); err != nil {
+			return err
+		}
+	}
+	for i := range sort {
+		if err := sort[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetKeyValue(dbConn *models.DBConnection, key string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	kvEngine, ok := engine.(KeyValueQueryEngine)
+	if !ok {
+		return nil, errors.New("not supported for db type")
+	}
+	return kvEngine.GetKeyValue(dbConn, key, config)
 }
 
 // UpdateSingleData function to update single data row in the database
-// id is a unique row ids: ctid for postgres, _id for mongo
+// id is a unique row ids: primary key json for postgres (ctid if there is no key) and mysql, rowid for sqlite (primary key json for WITHOUT ROWID tables), _id for mongo, key for redis
 func UpdateSingleData(dbConn *models.DBConnection, schemaName string, name string, id string, columnName, value string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		return postgresQueryEngine.UpdateSingleData(dbConn, schemaName, name, id, columnName, value, config)
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		return mongoQueryEngine.UpdateSingleData(dbConn, name, id, value, config)
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("invalid db type")
+	return engine.UpdateSingleData(dbConn, schemaName, name, id, columnName, value, withoutMaxRows(config))
 }
 
 func AddData(dbConn *models.DBConnection, schemaName string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (*AddDataResponse, error) {
-	var result map[string]interface{}
-	var err error
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		result, err = postgresQueryEngine.AddData(dbConn, schemaName, name, data, config)
-		if err != nil {
-			return nil, err
-		}
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		result, err = mongoQueryEngine.AddData(dbConn, schemaName, name, data, config)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		return nil, errors.New("invalid db type")
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
 	}
-	return BuildAddDataResponse(dbConn, result), nil
+	return engine.AddData(dbConn, schemaName, name, data, withoutMaxRows(config))
 }
 
 // DeleteData function to delete multiple rows in the database
-// ids is a list of unique row ids: ctid for postgres, _id for mongo
+// ids is a list of unique row ids: primary key json for postgres (ctid if there is no key) and mysql, rowid for sqlite (primary key json for WITHOUT ROWID tables), _id for mongo, key for redis
 func DeleteData(dbConn *models.DBConnection, schemaName string, name string, ids []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		return postgresQueryEngine.DeleteData(dbConn, schemaName, name, ids, config)
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		return mongoQueryEngine.DeleteData(dbConn, name, ids, config)
-	} else {
-		return nil, errors.New("invalid db type")
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	return engine.DeleteData(dbConn, schemaName, name, ids, withoutMaxRows(config))
+}
+
+// limitToMaxRows caps the page size to the max rows of the config, capped is true if it was capped.
+func limitToMaxRows(limit int, config *queryconfig.QueryConfig) (int, bool) {
+	if config.MaxRows > 0 && (limit <= 0 || limit > config.MaxRows) {
+		return config.MaxRows, true
+	}
+	return limit, false
+}
+
+// trimToLimit trims the rows of a table or the documents of a collection to the limit,
+// it returns true if there were more.
+func trimToLimit(data map[string]interface{}, limit int) bool {
+	for _, key := range []string{"rows", "data"} {
+		if rows, ok := data[key].([]map[string]interface{}); ok && len(rows) > limit {
+			data[key] = rows[:limit]
+			return true
+		}
 	}
+	return false
+}
+
+// withoutMaxRows returns a copy of the config without the row limit, the limit applies to the results
+// of the queries run by the user and not to the queries run by the engines to get metadata or pages of data.
+func withoutMaxRows(config *queryconfig.QueryConfig) *queryconfig.QueryConfig {
+	configCopy := *config
+	configCopy.MaxRows = 0
+	return &configCopy
 }
 
 func RemoveUnusedConnections() {
-	postgresQueryEngine.RemoveUnusedConnections()
-	mongoQueryEngine.RemoveUnusedConnections()
+	for _, engine := range queryEngines {
+		go engine.RemoveUnusedConnections()
+	}
+}
+
+// GetDBSessions function to list the sessions connected to the database server.
+func GetDBSessions(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*DBSession, error) {
+	activityEngine, err := getActivityQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	return activityEngine.GetDBSessions(dbConn, withoutMaxRows(config))
+}
+
+// CancelDBSession function to cancel the query of a session, or terminate the session.
+func CancelDBSession(dbConn *models.DBConnection, pid int32, terminate bool, config *queryconfig.QueryConfig) error {
+	activityEngine, err := getActivityQueryEngine(dbConn)
+	if err != nil {
+		return err
+	}
+	return activityEngine.CancelDBSession(dbConn, pid, terminate, config)
+}
+
+func getActivityQueryEngine(dbConn *models.DBConnection) (ActivityQueryEngine, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	activityEngine, ok := engine.(ActivityQueryEngine)
+	if !ok {
+		return nil, errors.New("monitoring activity is not supported for db type")
+	}
+	return activityEngine, nil
+}
+
+// GetDBLocks function to list the sessions blocking each other, with the trees of their blocking chains.
+func GetDBLocks(dbConn *models.DBConnection, config *queryconfig.QueryConfig) (*DBLocks, error) {
+	engine, err := getQueryEngine(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	lockEngine, ok := engine.(LockQueryEngine)
+	if !ok {
+		return nil, errors.New("viewing locks is not supported for db type")
+	}
+	return lockEngine.GetDBLocks(dbConn, withoutMaxRows(config))
 }
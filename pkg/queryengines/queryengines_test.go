@@ -0,0 +1,18 @@
+package queryengines
+
+import "testing"
+
+func TestTrimToLimit(t *testing.T) {
+	rows := []map[string]interface{}{{"0": "a"}, {"0": "b"}, {"0": "c"}}
+	data := map[string]interface{}{"rows": rows}
+	if trimToLimit(data, 3) || len(data["rows"].([]map[string]interface{})) != 3 {
+		t.Error("rows are truncated at the limit")
+	}
+	if !trimToLimit(data, 2) || len(data["rows"].([]map[string]interface{})) != 2 {
+		t.Error("rows are not truncated over the limit")
+	}
+	data = map[string]interface{}{"data": rows}
+	if !trimToLimit(data, 1) || len(data["data"].([]map[string]interface{})) != 1 {
+		t.Error("documents are not truncated over the limit")
+	}
+}
@@ -0,0 +1,70 @@
+package redisqueryengine
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type redisClientInstance struct {
+	redisClientInstance *redis.Client
+	LastUsed            time.Time
+}
+
+func (rEngine *RedisQueryEngine) getConnection(dbConnectionId, scheme, host string, port uint16, database, user, password string) (c *redis.Client, err error) {
+	if rClientInstance, exists := rEngine.openClients[dbConnectionId]; exists {
+		rEngine.openClients[dbConnectionId] = redisClientInstance{
+			redisClientInstance: rClientInstance.redisClientInstance,
+			LastUsed:            time.Now(),
+		}
+		return rClientInstance.redisClientInstance, nil
+	}
+	db := 0
+	if database != "" {
+		db, err = strconv.Atoi(database)
+		if err != nil {
+			err = fmt.Errorf("invalid database number: %s", database)
+			return
+		}
+	}
+	options := &redis.Options{
+		Addr:     host + ":" + strconv.Itoa(int(port)),
+		Username: user,
+		Password: password,
+		DB:       db,
+	}
+	if scheme == "rediss" {
+		options.TLSConfig = &tls.Config{ServerName: host}
+	}
+	client := redis.NewClient(options)
+	if err = client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		err = fmt.Errorf("unable to connect to database: %v", err)
+		return
+	}
+	if dbConnectionId != "" {
+		rEngine.openClients[dbConnectionId] = redisClientInstance{
+			redisClientInstance: client,
+			LastUsed:            time.Now(),
+		}
+	}
+	return client, err
+}
+
+func (rEngine *RedisQueryEngine) RemoveUnusedConnections() {
+	for {
+		time.Sleep(time.Minute * time.Duration(5))
+		for dbConnID, instance := range rEngine.openClients {
+			now := time.Now()
+			diff := now.Sub(instance.LastUsed)
+			if diff.Minutes() > 20 {
+				delete(rEngine.openClients, dbConnID)
+				go instance.redisClientInstance.Close()
+			}
+		}
+	}
+}
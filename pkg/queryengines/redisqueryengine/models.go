@@ -0,0 +1,44 @@
+package redisqueryengine
+
+import (
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+func buildDBDataModel(dbConn *models.DBConnection) *qemodels.DBDataModel {
+	database := string(dbConn.DBName)
+	if database == "" {
+		database = "0"
+	}
+	view := qemodels.DBDataModel{
+		Name: "db" + database,
+		Kind: qemodels.DATA_MODEL_KIND_KEYSPACE,
+	}
+	return &view
+}
+
+// buildKeyValue converts the flat replies of HGETALL and ZRANGE WITHSCORES to maps.
+func buildKeyValue(keyType string, value interface{}) interface{} {
+	list, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	if keyType == KEYTYPE_HASH {
+		hash := map[string]interface{}{}
+		for i := 0; i+1 < len(list); i += 2 {
+			hash[list[i].(string)] = list[i+1]
+		}
+		return hash
+	}
+	if keyType == KEYTYPE_ZSET {
+		members := []map[string]interface{}{}
+		for i := 0; i+1 < len(list); i += 2 {
+			members = append(members, map[string]interface{}{
+				"member": list[i],
+				"score":  list[i+1],
+			})
+		}
+		return members
+	}
+	return list
+}
@@ -0,0 +1,423 @@
+package redisqueryengine
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+	"slashbase.com/backend/pkg/queryengines/redisqueryengine/redisutils"
+	"slashbase.com/backend/pkg/sbsql"
+	"slashbase.com/backend/pkg/sshtunnel"
+)
+
+const (
+	KEYTYPE_STRING = "string"
+	KEYTYPE_HASH   = "hash"
+	KEYTYPE_LIST   = "list"
+	KEYTYPE_SET    = "set"
+	KEYTYPE_ZSET   = "zset"
+	KEYTYPE_STREAM = "stream"
+)
+
+type RedisQueryEngine struct {
+	openClients map[string]redisClientInstance
+}
+
+func InitRedisQueryEngine() *RedisQueryEngine {
+	return &RedisQueryEngine{
+		openClients: map[string]redisClientInstance{},
+	}
+}
+
+func (rqe *RedisQueryEngine) getClient(dbConn *models.DBConnection) (*redis.Client, error) {
+	port, _ := strconv.Atoi(string(dbConn.DBPort))
+	if dbConn.UseSSH != models.DBUSESSH_NONE {
+		remoteHost := string(dbConn.DBHost)
+		if remoteHost == "" {
+			remoteHost = "localhost"
+		}
+		sshTun := sshtunnel.GetSSHTunnel(dbConn.ID, dbConn.UseSSH,
+			string(dbConn.SSHHost), remoteHost, port, string(dbConn.SSHUser),
+			string(dbConn.SSHPassword), string(dbConn.SSHKeyFile),
+		)
+		dbConn.DBHost = sbsql.CryptedData("localhost")
+		dbConn.DBPort = sbsql.CryptedData(fmt.Sprintf("%d", sshTun.GetLocalEndpoint().Port))
+	}
+	port, _ = strconv.Atoi(string(dbConn.DBPort))
+	return rqe.getConnection(dbConn.ID, string(dbConn.DBScheme), string(dbConn.DBHost), uint16(port), string(dbConn.DBName), string(dbConn.DBUser), string(dbConn.DBPassword))
+}
+
+// runCommands runs the commands in a MULTI/EXEC transaction when there are more than one,
+// and returns the reply of each command.
+func (rqe *RedisQueryEngine) runCommands(dbConn *models.DBConnection, commands [][]interface{}, config *queryconfig.QueryConfig) ([]interface{}, error) {
+	for _, command := range commands {
+		if !redisutils.IsCommandRead(fmt.Sprint(command[0])) && config.ReadOnly {
+			return nil, errors.New("not allowed run this query")
+		}
+	}
+	client, err := rqe.getClient(dbConn)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+	cmds := []*redis.Cmd{}
+	if len(commands) == 1 {
+		cmds = append(cmds, client.Do(ctx, commands[0]...))
+	} else {
+		_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, command := range commands {
+				cmds = append(cmds, pipe.Do(ctx, command...))
+			}
+			return nil
+		})
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+	}
+	replies := []interface{}{}
+	for _, cmd := range cmds {
+		reply, err := cmd.Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		replies = append(replies, redisutils.ReplyToJson(reply))
+	}
+	if config.CreateLogFn != nil {
+		for _, command := range commands {
+			if !redisutils.IsCommandRead(fmt.Sprint(command[0])) {
+				config.CreateLogFn(strings.TrimSpace(fmt.Sprintln(command...)))
+			}
+		}
+	}
+	return replies, nil
+}
+
+func (rqe *RedisQueryEngine) runCommand(dbConn *models.DBConnection, command []interface{}, config *queryconfig.QueryConfig) (interface{}, error) {
+	replies, err := rqe.runCommands(dbConn, [][]interface{}{command}, config)
+	if err != nil {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+func (rqe *RedisQueryEngine) RunQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	args, err := redisutils.ParseCommand(query)
+	if err != nil {
+		return nil, err
+	}
+	command := []interface{}{}
+	for _, arg := range args {
+		command = append(command, arg)
+	}
+	reply, err := rqe.runCommand(dbConn, command, config)
+	if err != nil {
+		return nil, err
+	}
+	if redisutils.IsCommandRead(args[0]) && config.CreateLogFn != nil {
+		config.CreateLogFn(query)
+	}
+	return map[string]interface{}{
+		"keys": []string{"result"},
+		"data": []map[string]interface{}{
+			{
+				"result": reply,
+			},
+		},
+	}, nil
+}
+
+func (rqe *RedisQueryEngine) TestConnection(dbConn *models.DBConnection, config *queryconfig.QueryConfig) bool {
+	reply, err := rqe.runCommand(dbConn, []interface{}{"PING"}, config)
+	if err != nil {
+		return false
+	}
+	return reply == "PONG"
+}
+
+// GetDataModels returns a single data model for the database of the connection,
+// the keys in it are browsed using GetData.
+func (rqe *RedisQueryEngine) GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBDataModel, error) {
+	return []*qemodels.DBDataModel{buildDBDataModel(dbConn)}, nil
+}
+
+func (rqe *RedisQueryEngine) GetSingleDataModel(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (*qemodels.DBDataModel, error) {
+	dataModel := buildDBDataModel(dbConn)
+	dataModel.Fields = []qemodels.DBDataModelField{
+		{Name: "key", Type: KEYTYPE_STRING, IsPrimary: true, Tags: []string{}},
+		{Name: "type", Type: KEYTYPE_STRING, Tags: []string{}},
+		{Name: "ttl", Type: "integer", Tags: []string{"-1 when the key does not expire"}},
+		{Name: "size", Type: "integer", Tags: []string{}},
+	}
+	dataModel.Indexes = []qemodels.DBDataModelIndex{}
+	dataModel.ForeignKeys = []qemodels.DBDataModelForeignKey{}
+	return dataModel, nil
+}
+
+func (rqe *RedisQueryEngine) AddSingleDataModelField(dbConn *models.DBConnection, schema, name, fieldName, dataType string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (rqe *RedisQueryEngine) DeleteSingleDataModelField(dbConn *models.DBConnection, schema, name, fieldName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+// GetData scans the keys matching the key pattern of the filter, optionally only the keys of its type.
+// offset is the SCAN cursor, the cursor of the next page is returned as next.
+func (rqe *RedisQueryEngine) GetData(dbConn *models.DBConnection, schema string, name string, limit int, offset int64, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	if len(sort) > 0 {
+		return nil, errors.New("sort is not supported for redis")
+	}
+	pattern, keyType := "*", ""
+	if filter != nil {
+		var err error
+		pattern, keyType, err = redisutils.FilterToScanArgs(filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	cursor := strconv.FormatInt(offset, 10)
+	keys := []string{}
+	for len(keys) < limit {
+		command := []interface{}{"SCAN", cursor, "MATCH", pattern, "COUNT", limit}
+		if keyType != "" {
+			command = append(command, "TYPE", keyType)
+		}
+		reply, err := rqe.runCommand(dbConn, command, config)
+		if err != nil {
+			return nil, err
+		}
+		scanReply := reply.([]interface{})
+		cursor = scanReply[0].(string)
+		for _, key := range scanReply[1].([]interface{}) {
+			keys = append(keys, key.(string))
+		}
+		if cursor == "0" {
+			break
+		}
+	}
+	rowsData, err := rqe.getKeysInfo(dbConn, keys, config)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{
+		"keys": []string{"key", "type", "ttl", "size"},
+		"data": rowsData,
+		"next": cursor,
+	}
+	if fetchCount {
+		count, err := rqe.runCommand(dbConn, []interface{}{"DBSIZE"}, config)
+		if err != nil {
+			return nil, err
+		}
+		data["count"] = count
+	}
+	return data, nil
+}
+
+func (rqe *RedisQueryEngine) getKeysInfo(dbConn *models.DBConnection, keys []string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	rowsData := []map[string]interface{}{}
+	if len(keys) == 0 {
+		return rowsData, nil
+	}
+	commands := [][]interface{}{}
+	for _, key := range keys {
+		commands = append(commands, []interface{}{"TYPE", key}, []interface{}{"TTL", key})
+	}
+	replies, err := rqe.runCommands(dbConn, commands, config)
+	if err != nil {
+		return nil, err
+	}
+	commands = [][]interface{}{}
+	for i, key := range keys {
+		rowsData = append(rowsData, map[string]interface{}{
+			"key":  key,
+			"type": replies[2*i],
+			"ttl":  replies[2*i+1],
+		})
+		commands = append(commands, []interface{}{sizeCommand(replies[2*i].(string)), key})
+	}
+	replies, err = rqe.runCommands(dbConn, commands, config)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rowsData {
+		rowsData[i]["size"] = replies[i]
+	}
+	return rowsData, nil
+}
+
+func sizeCommand(keyType string) string {
+	switch keyType {
+	case KEYTYPE_HASH:
+		return "HLEN"
+	case KEYTYPE_LIST:
+		return "LLEN"
+	case KEYTYPE_SET:
+		return "SCARD"
+	case KEYTYPE_ZSET:
+		return "ZCARD"
+	case KEYTYPE_STREAM:
+		return "XLEN"
+	}
+	return "STRLEN"
+}
+
+// GetKeyValue returns the type, ttl and the value of the key.
+func (rqe *RedisQueryEngine) GetKeyValue(dbConn *models.DBConnection, key string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	replies, err := rqe.runCommands(dbConn, [][]interface{}{{"TYPE", key}, {"TTL", key}}, config)
+	if err != nil {
+		return nil, err
+	}
+	keyType := replies[0].(string)
+	var command []interface{}
+	switch keyType {
+	case KEYTYPE_STRING:
+		command = []interface{}{"GET", key}
+	case KEYTYPE_HASH:
+		command = []interface{}{"HGETALL", key}
+	case KEYTYPE_LIST:
+		command = []interface{}{"LRANGE", key, 0, -1}
+	case KEYTYPE_SET:
+		command = []interface{}{"SMEMBERS", key}
+	case KEYTYPE_ZSET:
+		command = []interface{}{"ZRANGE", key, 0, -1, "WITHSCORES"}
+	case KEYTYPE_STREAM:
+		command = []interface{}{"XRANGE", key, "-", "+"}
+	default:
+		return nil, errors.New("key does not exist")
+	}
+	value, err := rqe.runCommand(dbConn, command, config)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"key":   key,
+		"type":  keyType,
+		"ttl":   replies[1],
+		"value": buildKeyValue(keyType, value),
+	}, nil
+}
+
+// UpdateSingleData updates the value of the key name.
+// id is the hash field, the list index or the set member to update, it is ignored for strings.
+// For sorted sets columnName "score" updates the score of the member id, else the member is renamed to value.
+func (rqe *RedisQueryEngine) UpdateSingleData(dbConn *models.DBConnection, schema string, name string, id string, columnName string, value string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	keyType, err := rqe.runCommand(dbConn, []interface{}{"TYPE", name}, config)
+	if err != nil {
+		return nil, err
+	}
+	newID := value
+	var commands [][]interface{}
+	switch keyType {
+	case KEYTYPE_STRING:
+		commands = [][]interface{}{{"SET", name, value, "KEEPTTL"}}
+		newID = name
+	case KEYTYPE_HASH:
+		commands = [][]interface{}{{"HSET", name, id, value}}
+		newID = id
+	case KEYTYPE_LIST:
+		commands = [][]interface{}{{"LSET", name, id, value}}
+		newID = id
+	case KEYTYPE_SET:
+		commands = [][]interface{}{{"SREM", name, id}, {"SADD", name, value}}
+	case KEYTYPE_ZSET:
+		if columnName == "score" {
+			commands = [][]interface{}{{"ZADD", name, "XX", value, id}}
+			newID = id
+		} else {
+			score, err := rqe.runCommand(dbConn, []interface{}{"ZSCORE", name, id}, config)
+			if err != nil {
+				return nil, err
+			}
+			if score == nil {
+				return nil, errors.New("member does not exist")
+			}
+			commands = [][]interface{}{{"ZREM", name, id}, {"ZADD", name, score, value}}
+		}
+	default:
+		return nil, errors.New("cannot update key of type: " + fmt.Sprint(keyType))
+	}
+	_, err = rqe.runCommands(dbConn, commands, config)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id": newID,
+	}, nil
+}
+
+// AddData adds data["value"] to the key name, creating it when it does not exist.
+// data["type"] is the type of a new key, data["field"] is required for hashes and data["score"] for sorted sets.
+func (rqe *RedisQueryEngine) AddData(dbConn *models.DBConnection, schema string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (*qemodels.AddDataResponse, error) {
+	keyType, err := rqe.runCommand(dbConn, []interface{}{"TYPE", name}, config)
+	if err != nil {
+		return nil, err
+	}
+	if keyType == "none" {
+		keyType = KEYTYPE_STRING
+		if dataType, ok := data["type"].(string); ok && dataType != "" {
+			keyType = dataType
+		}
+	}
+	value := fmt.Sprint(data["value"])
+	var command []interface{}
+	switch keyType {
+	case KEYTYPE_STRING:
+		command = []interface{}{"SET", name, value}
+	case KEYTYPE_HASH:
+		field, ok := data["field"].(string)
+		if !ok || field == "" {
+			return nil, errors.New("field is required")
+		}
+		command = []interface{}{"HSET", name, field, value}
+	case KEYTYPE_LIST:
+		command = []interface{}{"RPUSH", name, value}
+	case KEYTYPE_SET:
+		command = []interface{}{"SADD", name, value}
+	case KEYTYPE_ZSET:
+		score, ok := data["score"]
+		if !ok {
+			return nil, errors.New("score is required")
+		}
+		command = []interface{}{"ZADD", name, fmt.Sprint(score), value}
+	default:
+		return nil, errors.New("cannot add to key of type: " + fmt.Sprint(keyType))
+	}
+	_, err = rqe.runCommand(dbConn, command, config)
+	if err != nil {
+		return nil, err
+	}
+	return &qemodels.AddDataResponse{NewID: name}, nil
+}
+
+// DeleteData deletes the keys ids.
+func (rqe *RedisQueryEngine) DeleteData(dbConn *models.DBConnection, schema string, name string, ids []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("no keys to delete")
+	}
+	command := []interface{}{"UNLINK"}
+	for _, id := range ids {
+		command = append(command, id)
+	}
+	deletedCount, err := rqe.runCommand(dbConn, command, config)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"keys": []string{"deletedCount"},
+		"data": []map[string]interface{}{
+			{
+				"deletedCount": deletedCount,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,160 @@
+package redisutils
+
+import (
+	"errors"
+	"strings"
+
+	"slashbase.com/backend/internal/utils"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+var readCommands = []string{
+	"GET", "MGET", "STRLEN", "GETRANGE", "SUBSTR", "LCS", "BITCOUNT", "BITPOS", "GETBIT",
+	"EXISTS", "TYPE", "TTL", "PTTL", "EXPIRETIME", "PEXPIRETIME", "KEYS", "SCAN", "RANDOMKEY", "DUMP", "OBJECT",
+	"HGET", "HMGET", "HGETALL", "HKEYS", "HVALS", "HLEN", "HEXISTS", "HSCAN", "HSTRLEN", "HRANDFIELD",
+	"LRANGE", "LLEN", "LINDEX", "LPOS",
+	"SMEMBERS", "SISMEMBER", "SMISMEMBER", "SCARD", "SSCAN", "SRANDMEMBER", "SINTER", "SINTERCARD", "SUNION", "SDIFF",
+	"ZRANGE", "ZRANGEBYSCORE", "ZREVRANGE", "ZREVRANGEBYSCORE", "ZRANGEBYLEX", "ZREVRANGEBYLEX",
+	"ZSCORE", "ZMSCORE", "ZCARD", "ZCOUNT", "ZLEXCOUNT", "ZRANK", "ZREVRANK", "ZSCAN", "ZRANDMEMBER",
+	"XRANGE", "XREVRANGE", "XLEN", "XINFO",
+	"PFCOUNT", "GEOPOS", "GEODIST", "GEOHASH", "GEOSEARCH", "GEORADIUS_RO", "GEORADIUSBYMEMBER_RO",
+	"DBSIZE", "PING", "ECHO", "INFO", "TIME", "LASTSAVE",
+}
+
+// IsCommandRead reports if the redis command only reads data.
+func IsCommandRead(command string) bool {
+	return utils.ContainsString(readCommands, strings.ToUpper(command))
+}
+
+// ParseCommand splits a redis-cli style command into its arguments,
+// arguments can be quoted with double quotes (supporting escapes) or single quotes.
+func ParseCommand(query string) ([]string, error) {
+	args := []string{}
+	runes := []rune(strings.TrimSpace(query))
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == ' ' || runes[i] == '\t' || runes[i] == '\n' || runes[i] == '\r' {
+			continue
+		}
+		arg := []rune{}
+		if runes[i] == '"' || runes[i] == '\'' {
+			quote := runes[i]
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\\' && quote == '"' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						arg = append(arg, '\n')
+					case 'r':
+						arg = append(arg, '\r')
+					case 't':
+						arg = append(arg, '\t')
+					default:
+						arg = append(arg, runes[i])
+					}
+					continue
+				}
+				if runes[i] == '\\' && quote == '\'' && i+1 < len(runes) && runes[i+1] == '\'' {
+					i++
+					arg = append(arg, '\'')
+					continue
+				}
+				if runes[i] == quote {
+					closed = true
+					break
+				}
+				arg = append(arg, runes[i])
+			}
+			if !closed {
+				return nil, errors.New("unbalanced quotes in command")
+			}
+		} else {
+			for ; i < len(runes) && runes[i] != ' ' && runes[i] != '\t' && runes[i] != '\n' && runes[i] != '\r'; i++ {
+				arg = append(arg, runes[i])
+			}
+		}
+		args = append(args, string(arg))
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+// ReplyToJson converts the reply returned by the redis client to a json friendly value.
+func ReplyToJson(reply interface{}) interface{} {
+	switch value := reply.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = ReplyToJson(item)
+		}
+		return list
+	case map[interface{}]interface{}:
+		dataMap := map[string]interface{}{}
+		for key, item := range value {
+			if keyStr, ok := key.(string); ok {
+				dataMap[keyStr] = ReplyToJson(item)
+			}
+		}
+		return dataMap
+	case []byte:
+		return string(value)
+	default:
+		return value
+	}
+}
+
+// FilterToScanArgs converts the filter to the MATCH pattern and TYPE of the SCAN command.
+// Only conditions on key (=, LIKE) and type (=) joined with AND are supported.
+func FilterToScanArgs(filter *qemodels.Filter) (pattern string, keyType string, err error) {
+	pattern = "*"
+	conditions := []*qemodels.Filter{filter}
+	if filter.IsGroup() {
+		if filter.Group != qemodels.FILTER_GROUP_AND {
+			return "", "", errors.New("only AND filters are supported for redis")
+		}
+		conditions = filter.Conditions
+	}
+	for _, condition := range conditions {
+		value, _ := condition.Value.(string)
+		switch {
+		case condition.Field == "key" && condition.Operator == qemodels.FILTER_OP_EQUAL:
+			pattern = escapeGlob(value)
+		case condition.Field == "key" && condition.Operator == qemodels.FILTER_OP_LIKE:
+			pattern = LikeToGlob(value)
+		case condition.Field == "type" && condition.Operator == qemodels.FILTER_OP_EQUAL:
+			keyType = value
+		default:
+			return "", "", errors.New("only key (=, LIKE) and type (=) filters are supported for redis")
+		}
+	}
+	return pattern, keyType, nil
+}
+
+// LikeToGlob converts a sql LIKE pattern to a redis glob style pattern.
+func LikeToGlob(pattern string) string {
+	glob := strings.Builder{}
+	for _, char := range pattern {
+		switch char {
+		case '%':
+			glob.WriteRune('*')
+		case '_':
+			glob.WriteRune('?')
+		default:
+			glob.WriteString(escapeGlob(string(char)))
+		}
+	}
+	return glob.String()
+}
+
+func escapeGlob(value string) string {
+	glob := strings.Builder{}
+	for _, char := range value {
+		if char == '*' || char == '?' || char == '[' || char == ']' || char == '\\' {
+			glob.WriteRune('\\')
+		}
+		glob.WriteRune(char)
+	}
+	return glob.String()
+}
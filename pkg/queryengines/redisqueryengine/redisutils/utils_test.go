@@ -0,0 +1,59 @@
+package redisutils
+
+import (
+	"testing"
+
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+func TestParseCommand(t *testing.T) {
+	args, err := ParseCommand(`HSET user:1 name "John \"JD\" Doe" bio 'it\'s me'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"HSET", "user:1", "name", `John "JD" Doe`, "bio", "it's me"}
+	if len(args) != len(expected) {
+		t.Fatal("args:", args)
+	}
+	for i := range expected {
+		if args[i] != expected[i] {
+			t.Error("arg:", i, args[i])
+		}
+	}
+}
+
+func TestParseCommandUnbalancedQuotes(t *testing.T) {
+	if _, err := ParseCommand(`GET "user`); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestIsCommandRead(t *testing.T) {
+	if !IsCommandRead("hgetall") {
+		t.Error("hgetall should be read")
+	}
+	if IsCommandRead("FLUSHALL") || IsCommandRead("SET") || IsCommandRead("EVAL") {
+		t.Error("write commands should not be read")
+	}
+}
+
+func TestFilterToScanArgs(t *testing.T) {
+	filter := &qemodels.Filter{
+		Group: qemodels.FILTER_GROUP_AND,
+		Conditions: []*qemodels.Filter{
+			{Field: "key", Operator: qemodels.FILTER_OP_LIKE, Value: "user:%:session_[1]"},
+			{Field: "type", Operator: qemodels.FILTER_OP_EQUAL, Value: "hash"},
+		},
+	}
+	pattern, keyType, err := FilterToScanArgs(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pattern != `user:*:session?\[1\]` || keyType != "hash" {
+		t.Error("pattern:", pattern, "keyType:", keyType)
+	}
+	_, _, err = FilterToScanArgs(&qemodels.Filter{Field: "ttl", Operator: qemodels.FILTER_OP_GREATER, Value: 10.0})
+	if err == nil {
+		t.Error("expected unsupported filter error")
+	}
+}
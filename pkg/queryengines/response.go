@@ -1,25 +1,17 @@
 package queryengines
 
 import (
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
 )
 
-type AddDataResponse struct {
-	NewID string `json:"newId"`
-}
+type AddDataResponse = qemodels.AddDataResponse
 
-func BuildAddDataResponse(dbConn *models.DBConnection, queryData map[string]interface{}) *AddDataResponse {
-	if dbConn.Type == models.DBTYPE_POSTGRES {
-		view := AddDataResponse{
-			NewID: queryData["ctid"].(string),
-		}
-		return &view
-	} else if dbConn.Type == models.DBTYPE_MONGO {
-		view := AddDataResponse{
-			NewID: queryData["insertedId"].(primitive.ObjectID).Hex(),
-		}
-		return &view
-	}
-	return nil
-}
+type StatementResult = qemodels.StatementResult
+
+type QueryPlan = qemodels.QueryPlan
+
+type PlanNode = qemodels.PlanNode
+
+type ResultStream = qemodels.ResultStream
+
+type ColumnMetadata = qemodels.ColumnMetadata
@@ -0,0 +1,136 @@
+package sqlitequeryengine
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// SQLITE_DRIVER_NAME is the sqlite3 driver with the REGEXP function registered, used by the regex filter.
+// ATTACH is disabled on its connections so that queries cannot reach the files outside of the sqlite files directory.
+const SQLITE_DRIVER_NAME = "sqlite3_slashbase"
+
+func init() {
+	sql.Register(SQLITE_DRIVER_NAME, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			conn.SetLimit(sqlite3.SQLITE_LIMIT_ATTACHED, 0)
+			return conn.RegisterFunc("regexp", func(pattern, value string) (bool, error) {
+				return regexp.MatchString(pattern, value)
+			}, true)
+		},
+	})
+}
+
+type sqliteDBInstance struct {
+	sqliteDBInstance *sql.DB
+	LastUsed         time.Time
+}
+
+// getConnection opens the database file at path, read only connections
+// are opened with query_only so that sqlite itself refuses any change to the file.
+func (sqEngine *SQLiteQueryEngine) getConnection(dbConnectionId, path string, readOnly bool) (c *sql.DB, err error) {
+	path, err = sqEngine.getFilePath(path)
+	if err != nil {
+		err = fmt.Errorf("unable to connect to database: %v", err)
+		return
+	}
+	if sqEngine.isAppDBFile(path) {
+		readOnly = true
+	}
+	instanceKey := dbConnectionId
+	if readOnly {
+		instanceKey = dbConnectionId + ":readonly"
+	}
+	if conn, exists := sqEngine.openConnections[instanceKey]; exists {
+		sqEngine.openConnections[instanceKey] = sqliteDBInstance{
+			sqliteDBInstance: conn.sqliteDBInstance,
+			LastUsed:         time.Now(),
+		}
+		return conn.sqliteDBInstance, nil
+	}
+	dsn := url.URL{Scheme: "file", Path: path, RawQuery: fmt.Sprintf("_busy_timeout=5000&_query_only=%t", readOnly)}
+	if readOnly {
+		dsn.RawQuery += "&mode=ro"
+	}
+	db, err := sql.Open(SQLITE_DRIVER_NAME, dsn.String())
+	if err != nil {
+		err = fmt.Errorf("unable to connect to database: %v", err)
+		return
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		err = fmt.Errorf("unable to connect to database: %v", err)
+		return
+	}
+	if dbConnectionId != "" {
+		sqEngine.openConnections[instanceKey] = sqliteDBInstance{
+			sqliteDBInstance: db,
+			LastUsed:         time.Now(),
+		}
+	}
+	return db, err
+}
+
+// getFilePath resolves path, relative to the sqlite files directory if it is not absolute,
+// and refuses the files which are not inside the directory, following the symlinks.
+func (sqEngine *SQLiteQueryEngine) getFilePath(path string) (string, error) {
+	if sqEngine.filesDir == "" {
+		return "", errors.New("sqlite files directory is not configured")
+	}
+	dir, err := filepath.Abs(sqEngine.filesDir)
+	if err != nil {
+		return "", err
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("file is not inside the sqlite files directory")
+	}
+	return path, nil
+}
+
+// isAppDBFile tells if path is the database file of slashbase, which has to be opened read only.
+func (sqEngine *SQLiteQueryEngine) isAppDBFile(path string) bool {
+	if sqEngine.appDBFile == "" {
+		return false
+	}
+	appDBInfo, err := os.Stat(sqEngine.appDBFile)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(appDBInfo, info)
+}
+
+func (sqEngine *SQLiteQueryEngine) RemoveUnusedConnections() {
+	for {
+		time.Sleep(time.Minute * time.Duration(5))
+		for instanceKey, instance := range sqEngine.openConnections {
+			now := time.Now()
+			diff := now.Sub(instance.LastUsed)
+			if diff.Minutes() > 20 {
+				delete(sqEngine.openConnections, instanceKey)
+				go instance.sqliteDBInstance.Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package sqlitequeryengine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+	"slashbase.com/backend/pkg/sbsql"
+)
+
+func newTestDB(t *testing.T, sqqe *SQLiteQueryEngine, id string, statements ...string) *models.DBConnection {
+	// an empty file is an empty database
+	path := filepath.Join(sqqe.filesDir, id+".db")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	dbConn := &models.DBConnection{
+		ID:     id,
+		Type:   models.DBTYPE_SQLITE,
+		DBHost: sbsql.CryptedData(path),
+		UseSSH: models.DBUSESSH_NONE,
+	}
+	config := queryconfig.NewQueryConfig(false, nil)
+	for _, statement := range statements {
+		if _, err := sqqe.RunQuery(dbConn, statement, config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dbConn
+}
+
+func TestWithoutRowIDTable(t *testing.T) {
+	sqqe := InitSQLiteQueryEngine(t.TempDir(), "")
+	dbConn := newTestDB(t, sqqe, "withoutrowid",
+		`CREATE TABLE settings (scope TEXT, key TEXT, value TEXT, PRIMARY KEY (scope, key)) WITHOUT ROWID;`,
+		`INSERT INTO settings VALUES ('app', 'theme', 'dark'), ('app', 'lang', 'en');`)
+	config := queryconfig.NewQueryConfig(false, nil)
+
+	data, err := sqqe.GetData(dbConn, "", "settings", 10, 0, false, nil, nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := data["rows"].([]map[string]interface{})
+	if columns := data["columns"].([]string); columns[0] != ROW_ID_COLUMN || len(rows) != 2 {
+		t.Fatal("data:", data)
+	}
+	rowID := rows[0]["0"].(string)
+	if rowID != `{"scope":"app","key":"lang"}` {
+		t.Error("row id:", rowID)
+	}
+	updated, err := sqqe.UpdateSingleData(dbConn, "", "settings", rowID, "key", "locale", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated["rowId"] != `{"key":"locale","scope":"app"}` {
+		t.Error("updated row id:", updated["rowId"])
+	}
+	if _, err := sqqe.DeleteData(dbConn, "", "settings", []string{updated["rowId"].(string)}, config); err != nil {
+		t.Fatal(err)
+	}
+	data, err = sqqe.GetData(dbConn, "", "settings", 10, 0, false, nil, nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := data["rows"].([]map[string]interface{}); len(rows) != 1 || rows[0]["2"] != "theme" {
+		t.Error("rows:", rows)
+	}
+}
+
+func TestRowIDTable(t *testing.T) {
+	sqqe := InitSQLiteQueryEngine(t.TempDir(), "")
+	dbConn := newTestDB(t, sqqe, "rowid",
+		`CREATE TABLE notes (body TEXT);`,
+		`INSERT INTO notes VALUES ('a');`)
+	config := queryconfig.NewQueryConfig(false, nil)
+
+	data, err := sqqe.GetData(dbConn, "", "notes", 10, 0, false, nil, nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rowID := data["rows"].([]map[string]interface{})[0]["0"]
+	updated, err := sqqe.UpdateSingleData(dbConn, "main", "notes", "1", "body", "b", config)
+	if err != nil || rowID != int64(1) || updated["rowId"] != "1" {
+		t.Error("row id:", rowID, updated, err)
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	sqqe := InitSQLiteQueryEngine(t.TempDir(), "")
+	newTestDB(t, sqqe, "files")
+	outside := filepath.Join(t.TempDir(), "outside.db")
+	if err := os.WriteFile(outside, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(sqqe.filesDir, "link.db")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sqqe.getFilePath("files.db"); err != nil {
+		t.Error("relative path:", err)
+	}
+	for _, path := range []string{outside, "../" + filepath.Base(filepath.Dir(outside)) + "/outside.db", "link.db", ".", "missing.db"} {
+		if _, err := sqqe.getFilePath(path); err == nil {
+			t.Error("path is allowed:", path)
+		}
+	}
+	if _, err := InitSQLiteQueryEngine("", "").getFilePath(outside); err == nil {
+		t.Error("path is allowed without a sqlite files directory")
+	}
+}
+
+func TestAppDBFileIsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	sqqe := InitSQLiteQueryEngine(dir, filepath.Join(dir, "app.db"))
+	dbConn := newTestDB(t, sqqe, "app")
+
+	if _, err := sqqe.RunQuery(dbConn, `CREATE TABLE users (email TEXT);`, queryconfig.NewQueryConfig(false, nil)); err == nil {
+		t.Error("app database is writable")
+	}
+}
+
+func TestAttachIsRefused(t *testing.T) {
+	sqqe := InitSQLiteQueryEngine(t.TempDir(), "")
+	dbConn := newTestDB(t, sqqe, "attach")
+	other := filepath.Join(t.TempDir(), "other.db")
+	config := queryconfig.NewQueryConfig(false, nil)
+
+	for _, query := range []string{"ATTACH DATABASE '" + other + "' AS other;", "VACUUM INTO '" + other + "';"} {
+		if _, err := sqqe.RunQuery(dbConn, query, config); err == nil {
+			t.Error("query is allowed:", query)
+		}
+	}
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Error("file is created:", err)
+	}
+}
@@ -0,0 +1,36 @@
+package sqlitequeryengine
+
+import (
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+func buildDBDataModel(tableData map[string]interface{}) *qemodels.DBDataModel {
+	view := qemodels.DBDataModel{
+		Name:       tableData["0"].(string),
+		SchemaName: tableData["1"].(string),
+		Kind:       qemodels.DATA_MODEL_KIND_TABLE,
+	}
+	return &view
+}
+
+func buildDBDataModelField(fieldData map[string]interface{}) *qemodels.DBDataModelField {
+	view := qemodels.DBDataModelField{
+		Name:       fieldData["name"].(string),
+		Type:       fieldData["type"].(string),
+		IsNullable: fieldData["isNullable"].(bool),
+		IsPrimary:  fieldData["isPrimary"].(bool),
+		Tags:       fieldData["tags"].([]string),
+	}
+	if defaultValue, ok := fieldData["default"].(string); ok {
+		view.Default = &defaultValue
+	}
+	return &view
+}
+
+func buildDBDataModelIndex(indexData map[string]interface{}) *qemodels.DBDataModelIndex {
+	view := qemodels.DBDataModelIndex{
+		Name:     indexData["0"].(string),
+		IndexDef: indexData["1"].(string),
+	}
+	return &view
+}
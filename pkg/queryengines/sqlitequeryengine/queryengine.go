@@ -0,0 +1,445 @@
+package sqlitequeryengine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+	"slashbase.com/backend/internal/models"
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+	"slashbase.com/backend/pkg/queryengines/queryconfig"
+	"slashbase.com/backend/pkg/queryengines/sqlitequeryengine/sqliteutils"
+)
+
+// ROW_ID_COLUMN is the column returned by GetData with the rowid of the row, or the values of the primary key
+// as json for the WITHOUT ROWID tables, it is used as the row id for UpdateSingleData and DeleteData.
+const ROW_ID_COLUMN = "_rowid"
+
+type SQLiteQueryEngine struct {
+	openConnections map[string]sqliteDBInstance
+	filesDir        string
+	appDBFile       string
+}
+
+// InitSQLiteQueryEngine creates the sqlite engine which only opens the database files inside filesDir,
+// the app database file, if it is inside filesDir, is always opened read only.
+func InitSQLiteQueryEngine(filesDir, appDBFile string) *SQLiteQueryEngine {
+	return &SQLiteQueryEngine{
+		openConnections: map[string]sqliteDBInstance{},
+		filesDir:        filesDir,
+		appDBFile:       appDBFile,
+	}
+}
+
+func (sqqe *SQLiteQueryEngine) RunQuery(dbConn *models.DBConnection, query string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	return sqqe.runQuery(dbConn, query, nil, config)
+}
+
+func (sqqe *SQLiteQueryEngine) runQuery(dbConn *models.DBConnection, query string, args []interface{}, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	db, err := sqqe.getConnection(dbConn.ID, string(dbConn.DBHost), config.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := config.GetContextWithTimeout()
+	defer cancel()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	isReadQuery := false
+	err = conn.Raw(func(driverConn interface{}) error {
+		stmt, err := driverConn.(*sqlite3.SQLiteConn).Prepare(query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		isReadQuery = stmt.(*sqlite3.SQLiteStmt).Readonly()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !isReadQuery && config.ReadOnly {
+		return nil, errors.New("not allowed run this query")
+	}
+
+	if isReadQuery {
+		rows, err := conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, toQueryError(err)
+		}
+		defer rows.Close()
+		columnsMetadata, err := sqliteutils.SQLiteColumnsMetadata(rows)
+		if err != nil {
+			return nil, toQueryError(err)
+		}
+		columns, rowsData, truncated, err := sqliteutils.SQLiteRowsToJson(rows, config.MaxRows)
+		if err != nil {
+			return nil, toQueryError(err)
+		}
+		if config.CreateLogFn != nil {
+			config.CreateLogFn(query)
+		}
+		return map[string]interface{}{
+			"columns":         columns,
+			"columnsMetadata": columnsMetadata,
+			"rows":            rowsData,
+			"truncated":       truncated,
+		}, nil
+	}
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, toQueryError(err)
+	}
+	if config.CreateLogFn != nil {
+		config.CreateLogFn(query)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	lastInsertId, _ := result.LastInsertId()
+	return map[string]interface{}{
+		"message":      fmt.Sprintf("%d rows affected", rowsAffected),
+		"lastInsertId": lastInsertId,
+	}, nil
+}
+
+// toQueryError reports writes refused by the query_only read only connections
+// with the same error as the other engines.
+func toQueryError(err error) error {
+	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.Code == sqlite3.ErrReadonly {
+		return errors.New("not allowed run this query")
+	}
+	return err
+}
+
+func (sqqe *SQLiteQueryEngine) TestConnection(dbConn *models.DBConnection, config *queryconfig.QueryConfig) bool {
+	query := "SELECT 1 AS test;"
+	data, err := sqqe.RunQuery(dbConn, query, config)
+	if err != nil {
+		return false
+	}
+	test := data["rows"].([]map[string]interface{})[0]["0"].(int64)
+	return test == 1
+}
+
+func (sqqe *SQLiteQueryEngine) GetDataModels(dbConn *models.DBConnection, config *queryconfig.QueryConfig) ([]*qemodels.DBDataModel, error) {
+	data, err := sqqe.RunQuery(dbConn, "SELECT name, 'main' FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name;", config)
+	if err != nil {
+		return nil, err
+	}
+	dataModels := []*qemodels.DBDataModel{}
+	for _, table := range data["rows"].([]map[string]interface{}) {
+		dataModels = append(dataModels, buildDBDataModel(table))
+	}
+	return dataModels, nil
+}
+
+func (sqqe *SQLiteQueryEngine) GetSingleDataModel(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) (*qemodels.DBDataModel, error) {
+	fieldsData, err := sqqe.GetSingleDataModelFields(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	indexesData, err := sqqe.GetSingleDataModelIndexes(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeysData, err := sqqe.GetSingleDataModelForeignKeys(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	allFields := []qemodels.DBDataModelField{}
+	for _, field := range fieldsData {
+		allFields = append(allFields, *buildDBDataModelField(field))
+	}
+	allIndexes := []qemodels.DBDataModelIndex{}
+	for _, index := range indexesData {
+		allIndexes = append(allIndexes, *buildDBDataModelIndex(index))
+	}
+	dataModel := qemodels.DBDataModel{
+		SchemaName:  schemaOrMain(schema),
+		Name:        name,
+		Kind:        qemodels.DATA_MODEL_KIND_TABLE,
+		Fields:      allFields,
+		Indexes:     allIndexes,
+		ForeignKeys: sqliteutils.QueryToForeignKeys(schemaOrMain(schema), foreignKeysData),
+	}
+	return &dataModel, nil
+}
+
+func (sqqe *SQLiteQueryEngine) GetSingleDataModelFields(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	schema = schemaOrMain(schema)
+	// get fields
+	query := `SELECT cid, name, type, "notnull", dflt_value, pk FROM pragma_table_info(?, ?) ORDER BY cid;`
+	data, err := sqqe.runQuery(dbConn, query, []interface{}{name, schema}, config)
+	if err != nil {
+		return nil, err
+	}
+	fieldsData := data["rows"].([]map[string]interface{})
+	// get unique columns
+	query = `SELECT ii.name FROM pragma_index_list(?, ?) AS il, pragma_index_info(il.name, ?) AS ii
+		WHERE il."unique" = 1 AND il.origin != 'pk';`
+	data, err = sqqe.runQuery(dbConn, query, []interface{}{name, schema, schema}, config)
+	if err != nil {
+		return nil, err
+	}
+	uniqueColumns := []string{}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		uniqueColumns = append(uniqueColumns, row["0"].(string))
+	}
+	// get foreign keys
+	query = `SELECT "table", "from", "to" FROM pragma_foreign_key_list(?, ?);`
+	data, err = sqqe.runQuery(dbConn, query, []interface{}{name, schema}, config)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeysData := data["rows"].([]map[string]interface{})
+	return sqliteutils.QueryToDataModel(fieldsData, uniqueColumns, foreignKeysData), err
+}
+
+func (sqqe *SQLiteQueryEngine) GetSingleDataModelIndexes(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	schema = schemaOrMain(schema)
+	query := fmt.Sprintf(`SELECT il.name, COALESCE(m.sql, CASE il.origin WHEN 'pk' THEN 'PRIMARY KEY' ELSE 'UNIQUE' END)
+		FROM pragma_index_list(?, ?) AS il
+		LEFT JOIN %s.sqlite_master AS m ON m.type = 'index' AND m.name = il.name;`, sqliteutils.QuoteIdentifier(schema))
+	data, err := sqqe.runQuery(dbConn, query, []interface{}{name, schema}, config)
+	if err != nil {
+		return nil, err
+	}
+	returnedData := data["rows"].([]map[string]interface{})
+	return returnedData, err
+}
+
+func (sqqe *SQLiteQueryEngine) GetSingleDataModelForeignKeys(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]map[string]interface{}, error) {
+	schema = schemaOrMain(schema)
+	query := `SELECT id, "from", "table", "to", on_delete, on_update FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq;`
+	data, err := sqqe.runQuery(dbConn, query, []interface{}{name, schema}, config)
+	if err != nil {
+		return nil, err
+	}
+	returnedData := data["rows"].([]map[string]interface{})
+	return returnedData, err
+}
+
+func (sqqe *SQLiteQueryEngine) AddSingleDataModelField(dbConn *models.DBConnection, schema, name, columnName, dataType string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s;`, tableIdentifier(schema, name), sqliteutils.QuoteIdentifier(columnName), dataType)
+	return sqqe.RunQuery(dbConn, query, config)
+}
+
+func (sqqe *SQLiteQueryEngine) DeleteSingleDataModelField(dbConn *models.DBConnection, schema, name, columnName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf(`ALTER TABLE %s DROP COLUMN %s;`, tableIdentifier(schema, name), sqliteutils.QuoteIdentifier(columnName))
+	return sqqe.RunQuery(dbConn, query, config)
+}
+
+func (sqqe *SQLiteQueryEngine) CreateDataModel(dbConn *models.DBConnection, schema string, spec *qemodels.DataModelSpec, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := spec.CreateTableQuery(tableIdentifier(schema, spec.Name), sqliteutils.QuoteIdentifier)
+	return sqqe.runQuery(dbConn, query, nil, config)
+}
+
+// RenameDataModel renames the table, which stays in its schema.
+func (sqqe *SQLiteQueryEngine) RenameDataModel(dbConn *models.DBConnection, schema, name, newName string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", tableIdentifier(schema, name), sqliteutils.QuoteIdentifier(newName))
+	return sqqe.runQuery(dbConn, query, nil, config)
+}
+
+// TruncateDataModel deletes all the rows of the table, sqlite does not have TRUNCATE.
+func (sqqe *SQLiteQueryEngine) TruncateDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("DELETE FROM %s;", tableIdentifier(schema, name))
+	return sqqe.runQuery(dbConn, query, nil, config)
+}
+
+func (sqqe *SQLiteQueryEngine) DropDataModel(dbConn *models.DBConnection, schema, name string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	query := fmt.Sprintf("DROP TABLE %s;", tableIdentifier(schema, name))
+	return sqqe.runQuery(dbConn, query, nil, config)
+}
+
+// getRowIDColumns returns the columns of the primary key of a WITHOUT ROWID table,
+// and no columns for the tables whose rows are identified by their rowid.
+func (sqqe *SQLiteQueryEngine) getRowIDColumns(dbConn *models.DBConnection, schema string, name string, config *queryconfig.QueryConfig) ([]string, error) {
+	query := `SELECT wr FROM pragma_table_list WHERE schema = ? AND name = ?;`
+	data, err := sqqe.runQuery(dbConn, query, []interface{}{schemaOrMain(schema), name}, config)
+	if err != nil {
+		return nil, err
+	}
+	rows := data["rows"].([]map[string]interface{})
+	if len(rows) == 0 {
+		return nil, errors.New("table not found")
+	}
+	if fmt.Sprint(rows[0]["0"]) == "0" {
+		return []string{}, nil
+	}
+	query = `SELECT name FROM pragma_table_info(?, ?) WHERE pk > 0 ORDER BY pk;`
+	data, err = sqqe.runQuery(dbConn, query, []interface{}{name, schemaOrMain(schema)}, config)
+	if err != nil {
+		return nil, err
+	}
+	rowIDColumns := []string{}
+	for _, row := range data["rows"].([]map[string]interface{}) {
+		rowIDColumns = append(rowIDColumns, row["0"].(string))
+	}
+	return rowIDColumns, nil
+}
+
+func (sqqe *SQLiteQueryEngine) GetData(dbConn *models.DBConnection, schema string, name string, limit int, offset int64, fetchCount bool, filter *qemodels.Filter, sort []qemodels.SortField, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	rowIDColumns, err := sqqe.getRowIDColumns(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	rowIDQuery := "rowid"
+	if len(rowIDColumns) > 0 {
+		rowIDArgs := []string{}
+		for _, column := range rowIDColumns {
+			rowIDArgs = append(rowIDArgs, fmt.Sprintf(`%s, %s`, sqliteutils.QuoteString(column), sqliteutils.QuoteIdentifier(column)))
+		}
+		rowIDQuery = fmt.Sprintf(`json_object(%s)`, strings.Join(rowIDArgs, ", "))
+	}
+	whereQuery := ""
+	args := []interface{}{}
+	if filter != nil {
+		condition, filterArgs := sqliteutils.FilterQuery(filter)
+		whereQuery = " WHERE " + condition
+		args = append(args, filterArgs...)
+	}
+	sortQuery := sqliteutils.SortQuery(sort)
+	query := fmt.Sprintf(`SELECT %s AS %s, * FROM %s%s%s LIMIT ? OFFSET ?;`,
+		rowIDQuery, sqliteutils.QuoteIdentifier(ROW_ID_COLUMN), tableIdentifier(schema, name), whereQuery, sortQuery)
+	data, err := sqqe.runQuery(dbConn, query, append(args, limit, offset), config)
+	if err != nil {
+		return nil, err
+	}
+	if fetchCount {
+		countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM %s%s;`, tableIdentifier(schema, name), whereQuery)
+		countData, err := sqqe.runQuery(dbConn, countQuery, args, config)
+		if err != nil {
+			return nil, err
+		}
+		data["count"] = countData["rows"].([]map[string]interface{})[0]["0"]
+	}
+	return data, err
+}
+
+func (sqqe *SQLiteQueryEngine) UpdateSingleData(dbConn *models.DBConnection, schema string, name string, rowID string, columnName string, value string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	rowIDColumns, err := sqqe.getRowIDColumns(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	rowIDValues, whereQuery, whereArgs, err := rowIDToCondition(rowIDColumns, rowID)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE %s;`, tableIdentifier(schema, name), sqliteutils.QuoteIdentifier(columnName), whereQuery)
+	_, err = sqqe.runQuery(dbConn, query, append([]interface{}{value}, whereArgs...), config)
+	if err != nil {
+		return nil, err
+	}
+	newRowID := rowID
+	if _, exists := rowIDValues[columnName]; exists {
+		rowIDValues[columnName] = value
+		rowIDJson, err := json.Marshal(rowIDValues)
+		if err != nil {
+			return nil, err
+		}
+		newRowID = string(rowIDJson)
+	}
+	data := map[string]interface{}{
+		"rowId": newRowID,
+	}
+	return data, err
+}
+
+func (sqqe *SQLiteQueryEngine) AddData(dbConn *models.DBConnection, schema string, name string, data map[string]interface{}, config *queryconfig.QueryConfig) (*qemodels.AddDataResponse, error) {
+	keys := []string{}
+	placeholders := []string{}
+	values := []interface{}{}
+	for key, value := range data {
+		keys = append(keys, sqliteutils.QuoteIdentifier(key))
+		placeholders = append(placeholders, "?")
+		values = append(values, value)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s(%s) VALUES(%s);`, tableIdentifier(schema, name), strings.Join(keys, ", "), strings.Join(placeholders, ", "))
+	rowIDColumns, err := sqqe.getRowIDColumns(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	rData, err := sqqe.runQuery(dbConn, query, values, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(rowIDColumns) > 0 {
+		// the values of the primary key of a WITHOUT ROWID table are always inserted
+		rowIDValues := map[string]interface{}{}
+		for _, column := range rowIDColumns {
+			rowIDValues[column] = data[column]
+		}
+		newRowID, err := json.Marshal(rowIDValues)
+		if err != nil {
+			return nil, err
+		}
+		return &qemodels.AddDataResponse{NewID: string(newRowID)}, nil
+	}
+	return &qemodels.AddDataResponse{NewID: fmt.Sprint(rData["lastInsertId"])}, err
+}
+
+func (sqqe *SQLiteQueryEngine) DeleteData(dbConn *models.DBConnection, schema string, name string, rowIDs []string, config *queryconfig.QueryConfig) (map[string]interface{}, error) {
+	if len(rowIDs) == 0 {
+		return nil, errors.New("no rows to delete")
+	}
+	rowIDColumns, err := sqqe.getRowIDColumns(dbConn, schema, name, config)
+	if err != nil {
+		return nil, err
+	}
+	conditions := []string{}
+	args := []interface{}{}
+	for _, rowID := range rowIDs {
+		_, whereQuery, whereArgs, err := rowIDToCondition(rowIDColumns, rowID)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "("+whereQuery+")")
+		args = append(args, whereArgs...)
+	}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s;`, tableIdentifier(schema, name), strings.Join(conditions, " OR "))
+	return sqqe.runQuery(dbConn, query, args, config)
+}
+
+// rowIDToCondition returns the where condition selecting the row with its arguments, from the rowid
+// or from the json of the primary key values of a WITHOUT ROWID table returned by GetData.
+func rowIDToCondition(rowIDColumns []string, rowID string) (map[string]interface{}, string, []interface{}, error) {
+	if len(rowIDColumns) == 0 {
+		return nil, "rowid = ?", []interface{}{rowID}, nil
+	}
+	rowIDValues := map[string]interface{}{}
+	decoder := json.NewDecoder(strings.NewReader(rowID))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rowIDValues); err != nil || len(rowIDValues) != len(rowIDColumns) {
+		return nil, "", nil, errors.New("invalid row id")
+	}
+	conditions := []string{}
+	args := []interface{}{}
+	for _, column := range rowIDColumns {
+		value, exists := rowIDValues[column]
+		if !exists {
+			return nil, "", nil, errors.New("invalid row id")
+		}
+		if number, isNumber := value.(json.Number); isNumber {
+			value = number.String()
+		}
+		conditions = append(conditions, sqliteutils.QuoteIdentifier(column)+" = ?")
+		args = append(args, value)
+	}
+	return rowIDValues, strings.Join(conditions, " AND "), args, nil
+}
+
+func schemaOrMain(schema string) string {
+	if schema == "" {
+		return "main"
+	}
+	return schema
+}
+
+func tableIdentifier(schema, name string) string {
+	return sqliteutils.QuoteIdentifier(schemaOrMain(schema)) + "." + sqliteutils.QuoteIdentifier(name)
+}
@@ -0,0 +1,201 @@
+package sqliteutils
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"slashbase.com/backend/pkg/queryengines/qemodels"
+)
+
+// SQLiteColumnsMetadata returns the type and, when the driver knows it, the nullability of the columns of the rows.
+func SQLiteColumnsMetadata(rows *sql.Rows) ([]*qemodels.ColumnMetadata, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	columns := []*qemodels.ColumnMetadata{}
+	for _, columnType := range columnTypes {
+		column := &qemodels.ColumnMetadata{
+			Name: columnType.Name(),
+			Type: columnType.DatabaseTypeName(),
+		}
+		if nullable, ok := columnType.Nullable(); ok {
+			column.Nullable = &nullable
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// SQLiteRowsToJson reads the rows as json, stopping after maxRows rows (0 for no limit).
+// truncated is true when there were more rows.
+func SQLiteRowsToJson(rows *sql.Rows, maxRows int) ([]string, []map[string]interface{}, bool, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	count := len(columns)
+	tableData := make([]map[string]interface{}, 0)
+
+	values := make([]interface{}, count)
+	valuePtrs := make([]interface{}, count)
+	for rows.Next() {
+		if maxRows > 0 && len(tableData) == maxRows {
+			return columns, tableData, true, nil
+		}
+		for i := 0; i < count; i++ {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, nil, false, err
+		}
+		entry := make(map[string]interface{})
+		for i := range columns {
+			if b, ok := values[i].([]byte); ok {
+				entry[strconv.Itoa(i)] = string(b)
+			} else {
+				entry[strconv.Itoa(i)] = values[i]
+			}
+		}
+		tableData = append(tableData, entry)
+	}
+	return columns, tableData, false, rows.Err()
+}
+
+// QuoteIdentifier quotes a table, column or schema name using double quotes.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteString quotes a string literal with single quotes.
+func QuoteString(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", "''") + "'"
+}
+
+// FilterQuery compiles the filter tree to a where condition with ? placeholders for the values.
+func FilterQuery(filter *qemodels.Filter) (string, []interface{}) {
+	args := []interface{}{}
+	if filter.IsGroup() {
+		conditions := []string{}
+		for _, condition := range filter.Conditions {
+			query, conditionArgs := FilterQuery(condition)
+			conditions = append(conditions, query)
+			args = append(args, conditionArgs...)
+		}
+		return "(" + strings.Join(conditions, " "+filter.Group+" ") + ")", args
+	}
+	column := QuoteIdentifier(filter.Field)
+	switch filter.Operator {
+	case qemodels.FILTER_OP_IS_NULL, qemodels.FILTER_OP_IS_NOT_NULL:
+		return fmt.Sprintf(`%s %s`, column, filter.Operator), args
+	case qemodels.FILTER_OP_IN, qemodels.FILTER_OP_NOT_IN:
+		placeholders := []string{}
+		for _, value := range filter.Values {
+			placeholders = append(placeholders, "?")
+			args = append(args, value)
+		}
+		return fmt.Sprintf(`%s %s (%s)`, column, filter.Operator, strings.Join(placeholders, ", ")), args
+	case qemodels.FILTER_OP_BETWEEN:
+		args = append(args, filter.Values[0], filter.Values[1])
+		return fmt.Sprintf(`%s BETWEEN ? AND ?`, column), args
+	case qemodels.FILTER_OP_ILIKE:
+		// LIKE is already case insensitive in sqlite
+		args = append(args, filter.Value)
+		return fmt.Sprintf(`%s LIKE ?`, column), args
+	case qemodels.FILTER_OP_NOT_ILIKE:
+		args = append(args, filter.Value)
+		return fmt.Sprintf(`%s NOT LIKE ?`, column), args
+	case qemodels.FILTER_OP_REGEX:
+		args = append(args, filter.Value)
+		return fmt.Sprintf(`%s REGEXP ?`, column), args
+	}
+	args = append(args, filter.Value)
+	return fmt.Sprintf(`%s %s ?`, column, filter.Operator), args
+}
+
+// SortQuery builds the order by clause for the sort fields.
+func SortQuery(sort []qemodels.SortField) string {
+	if len(sort) == 0 {
+		return ""
+	}
+	sortColumns := []string{}
+	for _, field := range sort {
+		sortColumns = append(sortColumns, QuoteIdentifier(field.Field)+" "+field.Direction)
+	}
+	return " ORDER BY " + strings.Join(sortColumns, ", ")
+}
+
+// QueryToDataModel builds the fields from the rows of pragma_table_info,
+// the unique columns from pragma_index_list and the rows of pragma_foreign_key_list.
+func QueryToDataModel(fieldQueryData []map[string]interface{}, uniqueColumns []string, foreignKeysQueryData []map[string]interface{}) []map[string]interface{} {
+	fields := []map[string]interface{}{}
+
+	foreignKeyMap := map[string]map[string]interface{}{}
+	for _, foreignKey := range foreignKeysQueryData {
+		foreignKeyMap[foreignKey["1"].(string)] = foreignKey
+	}
+
+	for _, fieldData := range fieldQueryData {
+		name := fieldData["1"].(string)
+		field := map[string]interface{}{
+			"name":       name,
+			"type":       fieldData["2"].(string),
+			"isNullable": fieldData["3"].(int64) == 0,
+			"isPrimary":  fieldData["5"].(int64) > 0,
+		}
+		tags := []string{}
+		for _, column := range uniqueColumns {
+			if column == name {
+				tags = append(tags, "Unique")
+				break
+			}
+		}
+		if foreignKey, exists := foreignKeyMap[name]; exists {
+			reference := foreignKey["0"].(string)
+			if to, ok := foreignKey["2"].(string); ok {
+				reference += "(" + to + ")"
+			}
+			tags = append(tags, "Foreign Key: "+reference)
+		}
+		if fieldData["4"] != nil {
+			field["default"] = fieldData["4"]
+			tags = append(tags, "Default: "+fieldData["4"].(string))
+		}
+		field["tags"] = tags
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// QueryToForeignKeys converts the rows of pragma_foreign_key_list, one per column of a foreign key ordered
+// by id and seq. The columns are the id, the column, the referenced table and column, and the delete and
+// update actions. Foreign keys do not have names in sqlite and reference tables of their own schema.
+func QueryToForeignKeys(schema string, foreignKeysQueryData []map[string]interface{}) []qemodels.DBDataModelForeignKey {
+	foreignKeys := []qemodels.DBDataModelForeignKey{}
+	lastID := int64(-1)
+	for _, foreignKeyData := range foreignKeysQueryData {
+		id := foreignKeyData["0"].(int64)
+		if id != lastID {
+			foreignKeys = append(foreignKeys, qemodels.DBDataModelForeignKey{
+				Columns:              []string{},
+				ReferencedSchemaName: schema,
+				ReferencedName:       foreignKeyData["2"].(string),
+				ReferencedColumns:    []string{},
+				OnDelete:             foreignKeyData["4"].(string),
+				OnUpdate:             foreignKeyData["5"].(string),
+			})
+			lastID = id
+		}
+		foreignKey := &foreignKeys[len(foreignKeys)-1]
+		foreignKey.Columns = append(foreignKey.Columns, foreignKeyData["1"].(string))
+		// the referenced column is null when the primary key is referenced
+		if to, ok := foreignKeyData["3"].(string); ok {
+			foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, to)
+		}
+	}
+	return foreignKeys
+}
@@ -0,0 +1,133 @@
+package sqliteutils
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	if quoted := QuoteIdentifier(`my"table`); quoted != `"my""table"` {
+		t.Error("quoted:", quoted)
+	}
+}
+
+func TestQueryToDataModel(t *testing.T) {
+	fieldsData := []map[string]interface{}{
+		{"0": int64(0), "1": "id", "2": "INTEGER", "3": int64(1), "4": nil, "5": int64(1)},
+		{"0": int64(1), "1": "email", "2": "TEXT", "3": int64(0), "4": "''", "5": int64(0)},
+		{"0": int64(2), "1": "team_id", "2": "INTEGER", "3": int64(0), "4": nil, "5": int64(0)},
+	}
+	foreignKeysData := []map[string]interface{}{
+		{"0": "teams", "1": "team_id", "2": "id"},
+	}
+	fields := QueryToDataModel(fieldsData, []string{"email"}, foreignKeysData)
+	if len(fields) != 3 {
+		t.Fatal("fields:", fields)
+	}
+	if !fields[0]["isPrimary"].(bool) || fields[0]["isNullable"].(bool) {
+		t.Error("id:", fields[0])
+	}
+	if tags := fields[1]["tags"].([]string); len(tags) != 2 || tags[0] != "Unique" || tags[1] != "Default: ''" {
+		t.Error("email tags:", tags)
+	}
+	if tags := fields[2]["tags"].([]string); len(tags) != 1 || tags[0] != "Foreign Key: teams(id)" {
+		t.Error("team_id tags:", tags)
+	}
+}
+
+func TestSQLiteRowsToJsonMaxRows(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	query := "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 5) SELECT i FROM n"
+	for _, test := range []struct {
+		maxRows   int
+		rows      int
+		truncated bool
+	}{
+		{0, 5, false},
+		{5, 5, false},
+		{3, 3, true},
+	} {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, data, truncated, err := SQLiteRowsToJson(rows, test.maxRows)
+		rows.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != test.rows || truncated != test.truncated {
+			t.Error("maxRows:", test.maxRows, "rows:", len(data), "truncated:", truncated)
+		}
+	}
+}
+
+func TestSQLiteColumnsMetadata(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(255) NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT id, name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	columns, err := SQLiteColumnsMetadata(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 2 || columns[0].Name != "id" || columns[0].Type != "INTEGER" || columns[1].Type != "VARCHAR(255)" {
+		t.Error("columns:", columns[0], columns[1])
+	}
+}
+
+func TestQueryToForeignKeys(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE teams (id INTEGER, org_id INTEGER, PRIMARY KEY (id, org_id));
+		CREATE TABLE users (id INTEGER PRIMARY KEY, team_id INTEGER, org_id INTEGER, manager_id INTEGER REFERENCES users ON DELETE SET NULL,
+			FOREIGN KEY (team_id, org_id) REFERENCES teams (id, org_id) ON DELETE CASCADE);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query(`SELECT id, "from", "table", "to", on_delete, on_update FROM pragma_foreign_key_list('users', 'main') ORDER BY id, seq;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, data, _, err := SQLiteRowsToJson(rows, 0)
+	rows.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreignKeys := QueryToForeignKeys("main", data)
+	if len(foreignKeys) != 2 {
+		t.Fatal("foreignKeys:", foreignKeys)
+	}
+	for _, foreignKey := range foreignKeys {
+		switch foreignKey.ReferencedName {
+		case "teams":
+			if len(foreignKey.Columns) != 2 || foreignKey.Columns[1] != "org_id" || len(foreignKey.ReferencedColumns) != 2 || foreignKey.OnDelete != "CASCADE" {
+				t.Error("teams:", foreignKey)
+			}
+		case "users":
+			if len(foreignKey.Columns) != 1 || len(foreignKey.ReferencedColumns) != 0 || foreignKey.OnDelete != "SET NULL" || foreignKey.ReferencedSchemaName != "main" {
+				t.Error("users:", foreignKey)
+			}
+		default:
+			t.Error("foreignKey:", foreignKey)
+		}
+	}
+}